@@ -0,0 +1,222 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audio picks and positions the sounds played by the client.
+// Sound events are loaded from the sounds.json files in the resource
+// packs and are played by a pluggable Backend.
+package audio
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+
+	"github.com/thinkofdeath/steven/resource"
+)
+
+// Category is a group of sounds which share a volume setting.
+type Category string
+
+// Categories used by sounds.json
+const (
+	Master  Category = "master"
+	Music   Category = "music"
+	Records Category = "record"
+	Weather Category = "weather"
+	Blocks  Category = "block"
+	Hostile Category = "hostile"
+	Neutral Category = "neutral"
+	Players Category = "player"
+	Ambient Category = "ambient"
+)
+
+// Categories contains every category in the order they should be
+// displayed to the user.
+var Categories = []Category{
+	Master, Music, Records, Weather, Blocks, Hostile, Neutral, Players, Ambient,
+}
+
+const (
+	// Distance in blocks that a sound played at full volume
+	// can be heard from
+	soundDistance = 16.0
+	// Limit on how many events may refer to each other when
+	// picking a sound, protects against loops
+	maxEventDepth = 8
+)
+
+// Manager resolves sound events into files and plays them through
+// its backend. A Manager is not safe for use by multiple goroutines.
+type Manager struct {
+	backend Backend
+	events  map[string]*event
+	volumes map[Category]float64
+
+	// Position of the listener in the world
+	x, y, z float64
+}
+
+// NewManager returns a manager that plays sounds using the backend.
+// LoadSounds must be called before any sounds can be played.
+func NewManager(b Backend) *Manager {
+	return &Manager{
+		backend: b,
+		events:  map[string]*event{},
+		volumes: map[Category]float64{},
+	}
+}
+
+// LoadSounds (re)loads the sound events from the current resource
+// packs.
+func (m *Manager) LoadSounds() error {
+	m.events = map[string]*event{}
+	files, err := resource.OpenAll("minecraft", "sounds.json")
+	for _, f := range files {
+		defer f.Close()
+	}
+	if err != nil && len(files) == 0 {
+		return err
+	}
+	// Packs later in the list have a lower priority and need to
+	// be added first
+	for i := len(files) - 1; i >= 0; i-- {
+		if err := parseSounds("minecraft", files[i], m.events); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetVolume changes the volume (0-1) of the category. The master
+// volume applies to every category.
+func (m *Manager) SetVolume(c Category, v float64) {
+	m.volumes[c] = math.Max(0, math.Min(1, v))
+}
+
+// Volume returns the volume of the category, 1 if it hasn't been set.
+func (m *Manager) Volume(c Category) float64 {
+	if v, ok := m.volumes[c]; ok {
+		return v
+	}
+	return 1
+}
+
+// SetListener moves the position sounds are heard from.
+func (m *Manager) SetListener(x, y, z float64) {
+	m.x, m.y, m.z = x, y, z
+}
+
+// Play plays the named event at the position. Sounds further than
+// 16 blocks (or further for sounds louder than 1) are not played.
+// Returns whether a sound was played.
+func (m *Manager) Play(name string, x, y, z, volume, pitch float64) bool {
+	dx, dy, dz := x-m.x, y-m.y, z-m.z
+	dist := math.Sqrt(dx*dx + dy*dy + dz*dz)
+	rng := soundDistance * math.Max(volume, 1)
+	if dist >= rng {
+		return false
+	}
+	return m.play(name, math.Min(volume, 1)*(1-dist/rng), pitch)
+}
+
+// PlayGlobal plays the named event at the listener's position, e.g.
+// for sounds for the user interface.
+func (m *Manager) PlayGlobal(name string, volume, pitch float64) bool {
+	return m.play(name, volume, pitch)
+}
+
+func (m *Manager) play(name string, volume, pitch float64) bool {
+	ev, ok := m.events[qualify(name)]
+	if !ok {
+		return false
+	}
+	entry := m.pick(ev, 0)
+	if entry == nil {
+		return false
+	}
+	volume *= entry.Volume * m.Volume(ev.category)
+	if ev.category != Master {
+		volume *= m.Volume(Master)
+	}
+	if volume <= 0 {
+		return false
+	}
+	plugin, file := splitName(entry.Name)
+	m.backend.Play(Sound{
+		Event:    name,
+		Category: ev.category,
+		Plugin:   plugin,
+		File:     "sounds/" + file + ".ogg",
+		Volume:   math.Min(volume, 1),
+		Pitch:    pitch * entry.Pitch,
+		Stream:   entry.Stream,
+	})
+	return true
+}
+
+// pick selects one of the event's sounds at random based on their
+// weights, following references to other events.
+func (m *Manager) pick(ev *event, depth int) *soundEntry {
+	if depth > maxEventDepth || len(ev.sounds) == 0 {
+		return nil
+	}
+	total := 0
+	for _, s := range ev.sounds {
+		total += s.Weight
+	}
+	if total <= 0 {
+		return nil
+	}
+	n := rand.Intn(total)
+	for _, s := range ev.sounds {
+		n -= s.Weight
+		if n >= 0 {
+			continue
+		}
+		if s.Type == "event" {
+			ref, ok := m.events[qualify(s.Name)]
+			if !ok {
+				return nil
+			}
+			return m.pick(ref, depth+1)
+		}
+		return s
+	}
+	return nil
+}
+
+// Backend returns the backend the manager plays sounds with.
+func (m *Manager) Backend() Backend {
+	return m.backend
+}
+
+// Close closes the manager's backend.
+func (m *Manager) Close() error {
+	return m.backend.Close()
+}
+
+// qualify adds the default plugin to names without one.
+func qualify(name string) string {
+	if strings.ContainsRune(name, ':') {
+		return name
+	}
+	return "minecraft:" + name
+}
+
+func splitName(name string) (plugin, file string) {
+	if pos := strings.IndexRune(name, ':'); pos != -1 {
+		return name[:pos], name[pos+1:]
+	}
+	return "minecraft", name
+}
@@ -0,0 +1,171 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+const testSounds = `{
+	"random.click": {"category": "master", "sounds": ["random/click"]},
+	"mob.cow.say": {"category": "neutral", "sounds": [
+		{"name": "mob/cow/say1", "volume": 0.5, "pitch": 2}
+	]},
+	"music.game": {"category": "music", "sounds": [
+		{"name": "music/calm1", "stream": true}
+	]},
+	"alias": {"category": "neutral", "sounds": [
+		{"name": "mob.cow.say", "type": "event"}
+	]},
+	"loop": {"category": "neutral", "sounds": [
+		{"name": "loop", "type": "event"}
+	]}
+}`
+
+func testManager(t *testing.T) (*Manager, *Recorder) {
+	rec := &Recorder{}
+	m := NewManager(rec)
+	if err := parseSounds("minecraft", strings.NewReader(testSounds), m.events); err != nil {
+		t.Fatal(err)
+	}
+	return m, rec
+}
+
+func TestPlay(t *testing.T) {
+	m, rec := testManager(t)
+	if !m.Play("random.click", 0, 0, 0, 1, 1) {
+		t.Fatal("sound not played")
+	}
+	if !m.Play("mob.cow.say", 0, 0, 0, 1, 1) {
+		t.Fatal("sound not played")
+	}
+	if !m.PlayGlobal("minecraft:music.game", 1, 1) {
+		t.Fatal("sound not played")
+	}
+	played := rec.Played()
+	if len(played) != 3 {
+		t.Fatalf("expected 3 sounds, got %d", len(played))
+	}
+	if s := played[0]; s.Plugin != "minecraft" || s.File != "sounds/random/click.ogg" || s.Volume != 1 || s.Pitch != 1 {
+		t.Errorf("unexpected sound %+v", s)
+	}
+	if s := played[1]; s.Category != Neutral || s.Volume != 0.5 || s.Pitch != 2 {
+		t.Errorf("unexpected sound %+v", s)
+	}
+	if s := played[2]; !s.Stream || s.Category != Music {
+		t.Errorf("unexpected sound %+v", s)
+	}
+
+	rec.Reset()
+	if m.Play("missing", 0, 0, 0, 1, 1) || m.Play("loop", 0, 0, 0, 1, 1) {
+		t.Error("played invalid sound")
+	}
+	if !m.Play("alias", 0, 0, 0, 1, 1) {
+		t.Error("sound not played")
+	}
+	if played := rec.Played(); len(played) != 1 || played[0].File != "sounds/mob/cow/say1.ogg" {
+		t.Errorf("unexpected sounds %+v", played)
+	}
+}
+
+func TestAttenuation(t *testing.T) {
+	m, rec := testManager(t)
+	m.SetListener(100, 64, 100)
+	if !m.Play("random.click", 108, 64, 100, 1, 1) {
+		t.Fatal("sound not played")
+	}
+	if m.Play("random.click", 116, 64, 100, 1, 1) {
+		t.Error("sound out of range played")
+	}
+	// Louder sounds can be heard from further away
+	if !m.Play("random.click", 116, 64, 100, 2, 1) {
+		t.Error("loud sound not played")
+	}
+	played := rec.Played()
+	if len(played) != 2 {
+		t.Fatalf("expected 2 sounds, got %d", len(played))
+	}
+	if v := played[0].Volume; math.Abs(v-0.5) > 1e-9 {
+		t.Errorf("expected volume 0.5, got %f", v)
+	}
+	if v := played[1].Volume; math.Abs(v-0.5) > 1e-9 {
+		t.Errorf("expected volume 0.5, got %f", v)
+	}
+}
+
+func TestVolumes(t *testing.T) {
+	m, rec := testManager(t)
+	m.SetVolume(Master, 0.5)
+	m.SetVolume(Neutral, 0.5)
+	m.Play("random.click", 0, 0, 0, 1, 1)
+	m.Play("mob.cow.say", 0, 0, 0, 1, 1)
+	m.SetVolume(Music, 0)
+	if m.PlayGlobal("music.game", 1, 1) {
+		t.Error("muted sound played")
+	}
+	played := rec.Played()
+	if len(played) != 2 {
+		t.Fatalf("expected 2 sounds, got %d", len(played))
+	}
+	if v := played[0].Volume; v != 0.5 {
+		t.Errorf("expected volume 0.5, got %f", v)
+	}
+	if v := played[1].Volume; v != 0.125 {
+		t.Errorf("expected volume 0.125, got %f", v)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	m, rec := testManager(t)
+	pack := `{
+		"random.click": {"replace": true, "sounds": ["custom:click"]},
+		"mob.cow.say": {"sounds": [{"name": "mob/cow/say2", "weight": 0}]}
+	}`
+	if err := parseSounds("minecraft", strings.NewReader(pack), m.events); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(m.events["minecraft:mob.cow.say"].sounds); n != 2 {
+		t.Errorf("expected 2 sounds, got %d", n)
+	}
+	m.PlayGlobal("random.click", 1, 1)
+	if played := rec.Played(); len(played) != 1 || played[0].Plugin != "custom" || played[0].File != "sounds/click.ogg" {
+		t.Errorf("unexpected sounds %+v", played)
+	}
+}
+
+func TestVorbisSampleRate(t *testing.T) {
+	// Page header with a single segment followed by the start of
+	// the identification header
+	file := append([]byte("OggS"), make([]byte, 22)...)
+	file = append(file, 1, 30)
+	file = append(file, 1)
+	file = append(file, "vorbis"...)
+	file = append(file, 0, 0, 0, 0, 2, 0x44, 0xAC, 0, 0)
+	r := bufio.NewReader(bytes.NewReader(file))
+	if rate := vorbisSampleRate(r); rate != 44100 {
+		t.Errorf("got rate %d, wanted 44100", rate)
+	}
+	// The player still gets the whole file
+	if b, _ := r.Peek(4); string(b) != "OggS" {
+		t.Error("reader was advanced")
+	}
+	if rate := vorbisSampleRate(bufio.NewReader(strings.NewReader("not ogg"))); rate != 0 {
+		t.Errorf("got rate %d for a non ogg file", rate)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"io"
+	"sync"
+
+	"github.com/thinkofdeath/steven/resource"
+)
+
+// Sound is a single sound file to be played by a backend.
+type Sound struct {
+	// The name of the event the sound was picked from
+	Event    string
+	Category Category
+	// Plugin and path of the ogg file in the resource packs
+	Plugin, File string
+	// Volume (0-1) after attenuation and the category's volume
+	// have been applied
+	Volume float64
+	Pitch  float64
+	// Whether the file is long (e.g. music) and should be
+	// streamed instead of loaded all at once
+	Stream bool
+}
+
+// Open opens the sound's file from the resource packs.
+func (s Sound) Open() (io.ReadCloser, error) {
+	return resource.Open(s.Plugin, s.File)
+}
+
+// Backend plays sounds picked by a Manager.
+type Backend interface {
+	// Play starts playing the sound, this shouldn't block
+	// until the sound has finished.
+	Play(s Sound)
+	// Close stops all sounds and frees the backend's resources.
+	Close() error
+}
+
+// NullBackend is a backend that discards all sounds.
+type NullBackend struct{}
+
+// Play does nothing.
+func (NullBackend) Play(s Sound) {}
+
+// Close does nothing.
+func (NullBackend) Close() error { return nil }
+
+// Recorder is a backend that keeps a list of the sounds played
+// instead of playing them.
+type Recorder struct {
+	lock   sync.Mutex
+	played []Sound
+}
+
+// Play records the sound.
+func (r *Recorder) Play(s Sound) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.played = append(r.played, s)
+}
+
+// Close does nothing.
+func (r *Recorder) Close() error { return nil }
+
+// Played returns the sounds played since the last call to Reset.
+func (r *Recorder) Played() []Sound {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	out := make([]Sound, len(r.played))
+	copy(out, r.played)
+	return out
+}
+
+// Reset clears the list of played sounds.
+func (r *Recorder) Reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.played = nil
+}
@@ -0,0 +1,167 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"strconv"
+	"sync"
+)
+
+// Limit on the number of sounds playing at once, extra sounds
+// are dropped
+const maxPlaying = 32
+
+// player is an external program that can play an ogg file read
+// from its stdin.
+type player struct {
+	name string
+	// args returns the arguments to play a sound with. rate is the
+	// sample rate of the file, 0 if it isn't known.
+	args func(s Sound, rate int) []string
+}
+
+// players in the order they are preferred
+var players = []player{
+	{"mpv", func(s Sound, rate int) []string {
+		return []string{
+			"--no-video", "--really-quiet", "--no-config",
+			"--volume=" + strconv.Itoa(int(s.Volume*100)),
+			// Changes the pitch along with the speed, like
+			// vanilla does
+			"--speed=" + strconv.FormatFloat(s.Pitch, 'f', 3, 64),
+			"--audio-pitch-correction=no",
+			"-",
+		}
+	}},
+	{"ffplay", func(s Sound, rate int) []string {
+		args := []string{
+			"-nodisp", "-autoexit", "-loglevel", "quiet",
+			"-volume", strconv.Itoa(int(s.Volume * 100)),
+		}
+		if rate > 0 && s.Pitch != 1 {
+			args = append(args, "-af", fmt.Sprintf(
+				"asetrate=%d,aresample=%d", int(float64(rate)*s.Pitch), rate,
+			))
+		}
+		return append(args, "-i", "pipe:0")
+	}},
+}
+
+var errNoPlayer = errors.New("audio: no supported player (mpv or ffplay) found")
+
+// CommandBackend plays sounds by piping their files into an external
+// player, one process per sound.
+type CommandBackend struct {
+	player player
+	path   string
+
+	lock    sync.Mutex
+	playing map[*exec.Cmd]struct{}
+	closed  bool
+}
+
+// NewCommandBackend returns a backend using the first supported
+// player found in the PATH.
+func NewCommandBackend() (*CommandBackend, error) {
+	for _, p := range players {
+		path, err := exec.LookPath(p.name)
+		if err != nil {
+			continue
+		}
+		return &CommandBackend{
+			player:  p,
+			path:    path,
+			playing: map[*exec.Cmd]struct{}{},
+		}, nil
+	}
+	return nil, errNoPlayer
+}
+
+// Player returns the name of the program used to play sounds.
+func (c *CommandBackend) Player() string {
+	return c.player.name
+}
+
+// Play starts a player process for the sound.
+func (c *CommandBackend) Play(s Sound) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.closed || len(c.playing) >= maxPlaying {
+		return
+	}
+	f, err := s.Open()
+	if err != nil {
+		log.Printf("Playing %s: %s", s.File, err)
+		return
+	}
+	r := bufio.NewReader(f)
+	cmd := exec.Command(c.path, c.player.args(s, vorbisSampleRate(r))...)
+	cmd.Stdin = r
+	if err := cmd.Start(); err != nil {
+		f.Close()
+		log.Printf("Playing %s: %s", s.File, err)
+		return
+	}
+	c.playing[cmd] = struct{}{}
+	go func() {
+		cmd.Wait()
+		f.Close()
+		c.lock.Lock()
+		delete(c.playing, cmd)
+		c.lock.Unlock()
+	}()
+}
+
+// Close stops every sound that is playing.
+func (c *CommandBackend) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.closed = true
+	for cmd := range c.playing {
+		cmd.Process.Kill()
+	}
+	return nil
+}
+
+// vorbisSampleRate returns the sample rate from the identification
+// header at the start of an ogg vorbis file, 0 if it couldn't be
+// read. The reader isn't advanced.
+func vorbisSampleRate(r *bufio.Reader) int {
+	// The first page contains only the identification header.
+	// It starts after the 27 byte page header and its segment
+	// table.
+	head, err := r.Peek(27)
+	if err != nil || string(head[:4]) != "OggS" {
+		return 0
+	}
+	start := 27 + int(head[26])
+	// Packet type, "vorbis", version (4 bytes) and channels
+	// come before the rate
+	b, err := r.Peek(start + 16)
+	if err != nil && err != io.EOF {
+		return 0
+	}
+	if len(b) < start+16 || b[start] != 1 || string(b[start+1:start+7]) != "vorbis" {
+		return 0
+	}
+	return int(binary.LittleEndian.Uint32(b[start+12:]))
+}
@@ -0,0 +1,88 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// event is a named sound that the server can play, made up of one
+// or more sounds which are picked from at random.
+type event struct {
+	category Category
+	sounds   []*soundEntry
+}
+
+type soundEntry struct {
+	Name   string
+	Volume float64
+	Pitch  float64
+	Weight int
+	Stream bool
+	// Either "file" or "event", events refer to another event
+	// to pick a sound from
+	Type string
+}
+
+// UnmarshalJSON handles entries that are only the name of the file
+// as well as the full object.
+func (s *soundEntry) UnmarshalJSON(data []byte) error {
+	s.Volume, s.Pitch, s.Weight, s.Type = 1, 1, 1, "file"
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &s.Name)
+	}
+	type entry soundEntry
+	return json.Unmarshal(data, (*entry)(s))
+}
+
+type jsSoundEvent struct {
+	Category Category
+	Replace  bool
+	Sounds   []*soundEntry
+}
+
+// parseSounds adds the events in the sounds.json file to the
+// events map. Sounds are added to existing events unless the
+// event replaces them.
+func parseSounds(plugin string, r io.Reader, events map[string]*event) error {
+	var data map[string]*jsSoundEvent
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return err
+	}
+	for name, e := range data {
+		if e == nil {
+			continue
+		}
+		for _, s := range e.Sounds {
+			// Files without a plugin belong to the pack's
+			if s.Type == "file" && !strings.ContainsRune(s.Name, ':') {
+				s.Name = plugin + ":" + s.Name
+			}
+		}
+		name = plugin + ":" + name
+		ev, ok := events[name]
+		if !ok || e.Replace {
+			ev = &event{category: Master}
+			events[name] = ev
+		}
+		if e.Category != "" {
+			ev.category = e.Category
+		}
+		ev.sounds = append(ev.sounds, e.Sounds...)
+	}
+	return nil
+}
@@ -52,6 +52,19 @@ type Block interface {
 	Collidable() bool
 	CollisionBounds() []vmath.AABB
 
+	// Slipperiness is how much of an entity's speed is kept each
+	// tick whilst walking on the block, before air resistance.
+	Slipperiness() float64
+	// Climbable returns whether entities inside the block can
+	// climb it like a ladder.
+	Climbable() bool
+	// MotionMultiplier scales the horizontal speed of entities
+	// inside the block each tick.
+	MotionMultiplier() float64
+	// Sticky returns whether entities that touch the block are
+	// slowed to a crawl for their next move, like in cobwebs.
+	Sticky() bool
+
 	Hardness() float64
 
 	Renderable() bool
@@ -71,6 +84,7 @@ type Block interface {
 	CreateBlockEntity() BlockEntity
 
 	init(name string)
+	loadPhysics(tag reflect.StructTag)
 	toData() int
 }
 
@@ -92,6 +106,11 @@ type baseBlock struct {
 	renderable    bool
 	bounds        []vmath.AABB
 	hardness      float64
+
+	slipperiness     float64
+	climbable        bool
+	motionMultiplier float64
+	sticky           bool
 }
 
 // Is returns whether this block is a member of the passed Set
@@ -117,6 +136,17 @@ func (b *baseBlock) init(name string) {
 	b.collidable = true
 	b.renderable = true
 	b.hardness = 1.0
+	b.slipperiness = 0.6
+	b.motionMultiplier = 1.0
+}
+
+// loadPhysics reads the physics properties shared by every type of
+// block from the block's tag.
+func (b *baseBlock) loadPhysics(tag reflect.StructTag) {
+	if v, err := strconv.ParseFloat(tag.Get("slipperiness"), 64); err == nil {
+		b.slipperiness = v
+	}
+	b.sticky = tag.Get("sticky") == "true"
 }
 
 func (b *baseBlock) NameLocaleKey() string {
@@ -156,6 +186,22 @@ func (b *baseBlock) CollisionBounds() []vmath.AABB {
 	return b.bounds
 }
 
+func (b *baseBlock) Slipperiness() float64 {
+	return b.slipperiness
+}
+
+func (b *baseBlock) Climbable() bool {
+	return b.climbable
+}
+
+func (b *baseBlock) MotionMultiplier() float64 {
+	return b.motionMultiplier
+}
+
+func (b *baseBlock) Sticky() bool {
+	return b.sticky
+}
+
 func (b *baseBlock) Renderable() bool {
 	return b.renderable
 }
@@ -280,6 +326,14 @@ func GetBlockByCombinedID(id uint16) Block {
 	return b
 }
 
+// GetBlockByStateID returns the block with the matching state id,
+// the form used by the protocol outside of chunk data.
+// The state id is:
+//     block id | data << 12
+func GetBlockByStateID(id int) Block {
+	return GetBlockByCombinedID(uint16((id&0xFFF)<<4 | (id>>12)&0xF))
+}
+
 // BlockSet is a collection of Blocks.
 type BlockSet struct {
 	ID int
@@ -389,10 +443,9 @@ func alloc(initial Block) *BlockSet {
 func cloneBlock(b Block) Block {
 	v := reflect.ValueOf(b).Elem()
 	nv := reflect.New(v.Type()).Elem()
-	for i := 0; i < v.NumField(); i++ {
-		f := v.Field(i)
-		nv.Field(i).Set(f)
-	}
+	// Copied as a whole, the embedded baseBlock can't be set on
+	// its own as it isn't exported
+	nv.Set(v)
 	return nv.Addr().Interface().(Block)
 }
 
@@ -416,24 +469,14 @@ func (bs *BlockSet) stringify(block Block) string {
 }
 
 func initBlocks() {
+	initBlockIDs()
 	missingModel := findStateModel("steven", "missing_block")
-	// Flatten the ids
 	for _, bs := range blockSetsByID {
 		if bs == nil {
 			continue
 		}
-		for i, b := range bs.Blocks {
+		for _, b := range bs.Blocks {
 			br := reflect.ValueOf(b).Elem()
-			br.FieldByName("Index").SetInt(int64(i))
-			br.FieldByName("StevenID").SetUint(uint64(len(allBlocks)))
-			allBlocks = append(allBlocks, b)
-			if len(allBlocks) > math.MaxUint16 {
-				panic("ran out of ids, time to do this correctly :(")
-			}
-			data := b.toData()
-			if data != -1 {
-				blocks[(bs.ID<<4)|data] = b
-			}
 			// Liquids have custom rendering
 			if l, ok := b.(*blockLiquid); ok {
 				if l.Lava {
@@ -466,6 +509,29 @@ func initBlocks() {
 	}
 }
 
+// initBlockIDs flattens the ids of every block, unlike initBlocks
+// this doesn't need any resources.
+func initBlockIDs() {
+	for _, bs := range blockSetsByID {
+		if bs == nil {
+			continue
+		}
+		for i, b := range bs.Blocks {
+			br := reflect.ValueOf(b).Elem()
+			br.FieldByName("Index").SetInt(int64(i))
+			br.FieldByName("StevenID").SetUint(uint64(len(allBlocks)))
+			allBlocks = append(allBlocks, b)
+			if len(allBlocks) > math.MaxUint16 {
+				panic("ran out of ids, time to do this correctly :(")
+			}
+			data := b.toData()
+			if data != -1 {
+				blocks[(bs.ID<<4)|data] = b
+			}
+		}
+	}
+}
+
 func reinitBlocks() {
 	blockStateModels = map[pluginKey]*blockStateModel{}
 	missingModel := findStateModel("steven", "missing_block")
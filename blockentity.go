@@ -26,16 +26,20 @@ import (
 
 func init() {
 	addSystem(entitysys.Add, esSkullAdd)
+	addSystem(entitysys.Tick, esSkullTick)
 	addSystem(entitysys.Remove, esSkullRemove)
 	addSystem(entitysys.Add, esSignAdd)
+	addSystem(entitysys.Tick, esSignTick)
 }
 
 // updates the Colors of the model to fake lighting
 func lightBlockModel(model *render.StaticModel, bp Position) {
 	bx, by, bz := bp.X, bp.Y, bp.Z
 	bl := float64(chunkMap.BlockLight(bx, by, bz)) / 16
-	sl := float64(chunkMap.SkyLight(bx, by, bz)) / 16
+	sl := float64(chunkMap.SkyLight(bx, by, bz)) / 16 * float64(render.SkyBrightness)
 	light := math.Max(bl, sl) + (1 / 16.0)
+	// Night vision brightens the light but not any tint
+	light += (1 - light) * float64(render.NightVision)
 	for i := range model.Colors {
 		model.Colors[i] = [4]float32{
 			float32(light),
@@ -1067,6 +1067,8 @@ type blockVines struct {
 
 func (b *blockVines) load(tag reflect.StructTag) {
 	b.cullAgainst = false
+	b.collidable = false
+	b.climbable = true
 }
 
 func (b *blockVines) ModelVariant() string {
@@ -1865,3 +1867,68 @@ func (b *blockStoneBrick) toData() int {
 	data := int(b.Variant)
 	return data
 }
+
+// Ladder
+
+type blockLadder struct {
+	baseBlock
+	Facing direction.Type `state:"facing,2-5"`
+}
+
+func (b *blockLadder) load(tag reflect.StructTag) {
+	b.cullAgainst = false
+	b.climbable = true
+}
+
+func (b *blockLadder) ModelVariant() string {
+	return fmt.Sprintf("facing=%s", b.Facing)
+}
+
+func (b *blockLadder) CollisionBounds() []vmath.AABB {
+	if b.bounds == nil {
+		// The ladder is against the side of the block opposite
+		// to the way it faces
+		const depth = 2 / 16.0
+		var bound vmath.AABB
+		switch b.Facing {
+		case direction.North:
+			bound = vmath.NewAABB(0, 0, 1-depth, 1, 1, 1)
+		case direction.South:
+			bound = vmath.NewAABB(0, 0, 0, 1, 1, depth)
+		case direction.West:
+			bound = vmath.NewAABB(1-depth, 0, 0, 1, 1, 1)
+		default:
+			bound = vmath.NewAABB(0, 0, 0, depth, 1, 1)
+		}
+		b.bounds = []vmath.AABB{bound}
+	}
+	return b.bounds
+}
+
+func (b *blockLadder) toData() int {
+	return int(b.Facing)
+}
+
+// Soul sand
+
+type blockSoulSand struct {
+	baseBlock
+}
+
+func (b *blockSoulSand) load(tag reflect.StructTag) {
+	b.motionMultiplier = 0.4
+}
+
+func (b *blockSoulSand) CollisionBounds() []vmath.AABB {
+	if b.bounds == nil {
+		// Entities sink slightly into soul sand
+		b.bounds = []vmath.AABB{
+			vmath.NewAABB(0, 0, 0, 1, 14/16.0, 1),
+		}
+	}
+	return b.bounds
+}
+
+func (b *blockSoulSand) toData() int {
+	return 0
+}
@@ -54,4 +54,6 @@ func init() {
 	registerBlockType("portal", &blockPortal{})
 	registerBlockType("lilypad", &blockLilypad{})
 	registerBlockType("stonebrick", &blockStoneBrick{})
+	registerBlockType("ladder", &blockLadder{})
+	registerBlockType("soulSand", &blockSoulSand{})
 }
@@ -52,7 +52,7 @@ var Blocks = struct {
 	GoldenRail                 *BlockSet `type:"poweredRail"`
 	DetectorRail               *BlockSet `type:"poweredRail"`
 	StickyPiston               *BlockSet `type:"piston"`
-	Web                        *BlockSet `cullAgainst:"false" collidable:"false"`
+	Web                        *BlockSet `cullAgainst:"false" collidable:"false" sticky:"true"`
 	TallGrass                  *BlockSet `type:"tallGrass" mc:"tallgrass"`
 	DeadBush                   *BlockSet `type:"deadBush" mc:"deadbush"`
 	Piston                     *BlockSet `type:"piston"`
@@ -87,7 +87,7 @@ var Blocks = struct {
 	FurnaceLit                 *BlockSet
 	StandingSign               *BlockSet `type:"floorSign"`
 	WoodenDoor                 *BlockSet `type:"door"`
-	Ladder                     *BlockSet
+	Ladder                     *BlockSet `type:"ladder"`
 	Rail                       *BlockSet `type:"rail"`
 	StoneStairs                *BlockSet `type:"stairs"`
 	WallSign                   *BlockSet `type:"wallSign"`
@@ -101,7 +101,7 @@ var Blocks = struct {
 	RedstoneTorch              *BlockSet `type:"torch" model:"redstone_torch"`
 	StoneButton                *BlockSet
 	SnowLayer                  *BlockSet
-	Ice                        *BlockSet
+	Ice                        *BlockSet `slipperiness:"0.98"`
 	Snow                       *BlockSet
 	Cactus                     *BlockSet `cullAgainst:"false"`
 	Clay                       *BlockSet
@@ -110,7 +110,7 @@ var Blocks = struct {
 	Fence                      *BlockSet `type:"fence"`
 	Pumpkin                    *BlockSet
 	Netherrack                 *BlockSet
-	SoulSand                   *BlockSet
+	SoulSand                   *BlockSet `type:"soulSand"`
 	Glowstone                  *BlockSet
 	Portal                     *BlockSet `type:"portal"`
 	PumpkinLit                 *BlockSet
@@ -187,7 +187,7 @@ var Blocks = struct {
 	Log2                       *BlockSet `type:"log" second:"true"`
 	AcaciaStairs               *BlockSet `type:"stairs"`
 	DarkOakStairs              *BlockSet `type:"stairs"`
-	Slime                      *BlockSet
+	Slime                      *BlockSet `slipperiness:"0.8"`
 	Barrier                    *BlockSet `cullAgainst:"false" renderable:"false"`
 	IronTrapDoor               *BlockSet
 	Prismarine                 *BlockSet
@@ -196,7 +196,7 @@ var Blocks = struct {
 	Carpet                     *BlockSet `type:"carpet"`
 	HardenedClay               *BlockSet
 	CoalBlock                  *BlockSet
-	PackedIce                  *BlockSet
+	PackedIce                  *BlockSet `slipperiness:"0.98"`
 	DoublePlant                *BlockSet
 	StandingBanner             *BlockSet
 	WallBanner                 *BlockSet
@@ -261,6 +261,7 @@ func init() {
 		if l, ok := block.(loadable); ok {
 			l.load(tag)
 		}
+		block.loadPhysics(tag)
 		set := alloc(block)
 		fv.Set(reflect.ValueOf(set))
 	}
@@ -155,6 +155,13 @@ func esSignAdd(s *signComponent, b BlockComponent) {
 	s.create()
 }
 
+// Keeps the lighting up to date, e.g. with night vision
+func esSignTick(s *signComponent) {
+	if s.model != nil {
+		lightBlockModel(s.model, s.position)
+	}
+}
+
 func esSignRemove(s *signComponent) {
 	s.free()
 }
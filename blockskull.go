@@ -55,71 +55,71 @@ func (s *skullComponent) CanHandleAction(action int) bool {
 }
 
 func (s *skullComponent) Deserilize(tag *nbt.Compound) {
-	t, ok := tag.Items["SkullType"].(int8)
-	if !ok {
-		return
-	}
-	s.SkullType = skullType(t)
-	rot, ok := tag.Items["Rot"].(int8)
-	if !ok {
-		return
-	}
-	s.Rotation = int(rot)
-
-	if s.SkullType != skullPlayer {
-		s.free()
-		s.create()
-		return
+	// Missing tags are left at their defaults (a skeleton skull
+	// without an owner) and tags with the wrong type are skipped,
+	// the rest of the skull is still used
+	var skull struct {
+		SkullType int8
+		Rot       int8
+		Owner     struct {
+			Properties struct {
+				Textures []struct {
+					Value     string
+					Signature *string
+				} `nbt:"textures"`
+			}
+		}
 	}
+	nbt.Unmarshal(tag, &skull)
+	s.SkullType = skullType(skull.SkullType)
+	s.Rotation = int(skull.Rot)
 
-	owner, ok := tag.Items["Owner"].(*nbt.Compound)
-	if !ok {
-		return
-	}
-	props, ok := owner.Items["Properties"].(*nbt.Compound)
-	if !ok {
-		return
-	}
-	tex, ok := props.Items["textures"].(*nbt.List)
-	if !ok || tex.Type != nbt.TagCompound || len(tex.Elements) < 1 {
-		return
+	s.free()
+	s.Owner = ""
+	if s.SkullType == skullPlayer {
+		for _, tex := range skull.Owner.Properties.Textures {
+			if owner, ok := skullSkin(tex.Value, tex.Signature); ok {
+				s.Owner = owner
+				render.RefSkin(s.Owner)
+				s.OwnerSkin = render.Skin(s.Owner)
+				break
+			}
+		}
 	}
-	texP := tex.Elements[0].(*nbt.Compound)
-	value := texP.Items["Value"].(string)
-	sigV, hasSig := texP.Items["Signature"].(string)
+	s.create()
+}
 
+// skullSkin returns the hash of the skin in the skull's texture
+// property.
+func skullSkin(value string, signature *string) (string, bool) {
 	data, err := base64.StdEncoding.DecodeString(value)
 	if err != nil {
-		return
+		return "", false
 	}
 
-	if hasSig {
-		sig, err := base64.StdEncoding.DecodeString(sigV)
+	if signature != nil {
+		sig, err := base64.StdEncoding.DecodeString(*signature)
 		if err != nil {
-			return
+			return "", false
 		}
 
 		if err := verifySkinSignature([]byte(value), sig); err != nil {
-			return
+			return "", false
 		}
 	}
 
 	var blob skinBlob
-	err = json.Unmarshal(data, &blob)
-	if err != nil {
-		return
+	if err := json.Unmarshal(data, &blob); err != nil {
+		return "", false
 	}
 	url := blob.Textures.Skin.Url
 	// We can only handle textures from textures.minecraft.net currently,
 	// luckily these are the only ones we really see in practice and
 	// mojang seemed to have blocked other urls
-	if strings.HasPrefix(url, "http://textures.minecraft.net/texture/") {
-		s.free()
-		s.Owner = url[len("http://textures.minecraft.net/texture/"):]
-		render.RefSkin(s.Owner)
-		s.OwnerSkin = render.Skin(s.Owner)
-		s.create()
+	if !strings.HasPrefix(url, "http://textures.minecraft.net/texture/") {
+		return "", false
 	}
+	return url[len("http://textures.minecraft.net/texture/"):], true
 }
 
 func (s *skullComponent) free() {
@@ -206,6 +206,13 @@ func esSkullAdd(s *skullComponent, p BlockComponent) {
 	s.create()
 }
 
+// Keeps the lighting up to date, e.g. with night vision
+func esSkullTick(s *skullComponent) {
+	if s.model != nil {
+		lightBlockModel(s.model, s.position)
+	}
+}
+
 func esSkullRemove(s *skullComponent) {
 	s.free()
 }
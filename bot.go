@@ -0,0 +1,256 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steven
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/go-gl/glfw/v3.1/glfw"
+	"github.com/thinkofdeath/steven/chat"
+	"github.com/thinkofdeath/steven/protocol"
+	"github.com/thinkofdeath/steven/protocol/mojang"
+	"github.com/thinkofdeath/steven/render"
+	"github.com/thinkofdeath/steven/resource"
+)
+
+// The bot controlling the client, nil unless running headless
+var bot *Bot
+
+// Bot controls a client started with RunBot. The methods may be
+// called from any goroutine, they are run on the client's goroutine
+// between frames. Once the client has disconnected the methods do
+// nothing and return zero values.
+type Bot struct {
+	done chan struct{}
+
+	chatLock sync.Mutex
+	onChat   []func(msg chat.AnyComponent)
+}
+
+// RunBot connects to the server without creating a window or an
+// OpenGL context and calls script (on its own goroutine) with a Bot
+// to control the client. The network, world and entities are handled
+// as normal but nothing is rendered.
+//
+// RunBot returns once the client has disconnected, either by the
+// server or by calling Disconnect. Only one client may be run per
+// process.
+func RunBot(username, uuid, accessToken, s string, script func(b *Bot)) error {
+	profile = mojang.Profile{
+		Username:    username,
+		ID:          uuid,
+		AccessToken: accessToken,
+	}
+	server = s
+	render.Headless = true
+
+	initResources()
+
+	for _, pck := range Config.Game.ResourcePacks {
+		resource.LoadZip(pck)
+	}
+	loadBiomes()
+	initAudio()
+
+	render.LoadTextures()
+	initBlocks()
+
+	bot = &Bot{done: make(chan struct{})}
+	defer close(bot.done)
+	connect()
+	go script(bot)
+
+	frame := time.NewTicker(time.Second / 60)
+	defer frame.Stop()
+	for connected {
+		<-frame.C
+		delta := frameDelta()
+		handleEvents()
+		handleErrors()
+		if ready && Client != nil {
+			tickClient(delta)
+		}
+		render.Update()
+	}
+	if disconnectReason.Value != nil {
+		return errors.New(disconnectReason.String())
+	}
+	return nil
+}
+
+// run runs f on the client's goroutine and waits for it to complete.
+func (b *Bot) run(f func()) {
+	ret := make(chan struct{})
+	select {
+	case syncChan <- func() { f(); close(ret) }:
+	case <-b.done:
+		return
+	}
+	select {
+	case <-ret:
+	case <-b.done:
+	}
+}
+
+func (b *Bot) message(msg chat.AnyComponent) {
+	b.chatLock.Lock()
+	defer b.chatLock.Unlock()
+	for _, f := range b.onChat {
+		f(msg)
+	}
+}
+
+// Done returns a channel that is closed once the client has
+// disconnected.
+func (b *Bot) Done() <-chan struct{} {
+	return b.done
+}
+
+// Wait pauses for the passed duration. This returns false if the
+// client disconnected whilst waiting.
+func (b *Bot) Wait(d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-b.done:
+		return false
+	}
+}
+
+// WaitReady waits until the client has joined the server and been
+// given its position. This returns false if the client disconnected
+// before then.
+func (b *Bot) WaitReady() bool {
+	for {
+		if b.Ready() {
+			return true
+		}
+		if !b.Wait(time.Second / 20) {
+			return false
+		}
+	}
+}
+
+// Ready returns whether the client has joined the server and been
+// given its position.
+func (b *Bot) Ready() (r bool) {
+	b.run(func() { r = ready })
+	return
+}
+
+// OnChat adds a function to be called with every chat message the
+// client receives. The function is called on the client's goroutine
+// so it must not call any of the bot's methods directly.
+func (b *Bot) OnChat(f func(msg chat.AnyComponent)) {
+	b.chatLock.Lock()
+	defer b.chatLock.Unlock()
+	b.onChat = append(b.onChat, f)
+}
+
+// Chat sends the message (or command) to the server.
+func (b *Bot) Chat(msg string) {
+	b.run(func() { Client.network.Write(&protocol.ChatMessage{Message: msg}) })
+}
+
+// Position returns the position of the client's player.
+func (b *Bot) Position() (x, y, z float64) {
+	b.run(func() { x, y, z = Client.X, Client.Y, Client.Z })
+	return
+}
+
+// Health returns the health of the client's player.
+func (b *Bot) Health() (h float64) {
+	b.run(func() { h = Client.Health })
+	return
+}
+
+// Look rotates the player to face the passed direction. The angles
+// are in degrees and use the same orientation as Minecraft.
+func (b *Bot) Look(yaw, pitch float64) {
+	b.run(func() {
+		Client.Yaw = -yaw * (math.Pi / 180)
+		Client.Pitch = -pitch*(math.Pi/180) + math.Pi
+	})
+}
+
+// LookAt rotates the player so that it is looking at the passed
+// point.
+func (b *Bot) LookAt(x, y, z float64) {
+	b.run(func() {
+		dx := x - Client.X
+		dy := y - (Client.Y + playerHeight)
+		dz := z - Client.Z
+		Client.Yaw = math.Atan2(dx, dz)
+		Client.Pitch = math.Atan2(dy, math.Hypot(dx, dz)) + math.Pi
+	})
+}
+
+// SetKey presses or releases the movement key.
+func (b *Bot) SetKey(k Key, down bool) {
+	b.run(func() { Client.KeyState[k] = down })
+}
+
+// Attack holds or releases the attack button, digging the block or
+// hitting the entity the player is looking at whilst held.
+func (b *Bot) Attack(down bool) {
+	b.run(func() { Client.MouseAction(glfw.MouseButtonLeft, down) })
+}
+
+// Use uses the held item on the block or entity the player is looking
+// at, placing a block if the item is one.
+func (b *Bot) Use() {
+	b.run(func() { Client.MouseAction(glfw.MouseButtonRight, true) })
+}
+
+// SelectSlot changes the selected hotbar slot (0-8).
+func (b *Bot) SelectSlot(slot int) {
+	b.run(func() {
+		Client.currentHotbarSlot = slot
+		Client.network.Write(&protocol.HeldItemChange{Slot: int16(slot)})
+	})
+}
+
+// Block returns the block at the passed location.
+func (b *Bot) Block(x, y, z int) (bl Block) {
+	b.run(func() { bl = chunkMap.Block(x, y, z) })
+	return
+}
+
+// Entities returns the entities the client is currently tracking
+// mapped by their network id. Entities can be inspected via their
+// components, e.g. PositionComponent.
+func (b *Bot) Entities() map[int]Entity {
+	out := map[int]Entity{}
+	b.run(func() {
+		for id, e := range Client.entities.entities {
+			out[id] = e
+		}
+	})
+	return out
+}
+
+// Respawn respawns the player after dying.
+func (b *Bot) Respawn() {
+	b.run(func() { Client.network.Write(&protocol.ClientStatus{ActionID: 0}) })
+}
+
+// Disconnect disconnects the client from the server causing RunBot
+// to return.
+func (b *Bot) Disconnect() {
+	b.run(func() { Client.network.SignalClose(errManualDisconnect) })
+}
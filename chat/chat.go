@@ -161,6 +161,9 @@ type (
 		Score struct {
 			Name      string `json:"name,omitempty"`
 			Objective string `json:"objective,omitempty"`
+			// The resolved value of the score, filled in by the
+			// server or client
+			Value string `json:"value,omitempty"`
 		} `json:"score"`
 		Component
 	}
@@ -40,3 +40,9 @@ func (c *Component) String() string {
 func (t *TextComponent) String() string {
 	return t.Text + t.Component.String()
 }
+
+// String provides a string version of the component without
+// formatting.
+func (s *ScoreComponent) String() string {
+	return s.Score.Value + s.Component.String()
+}
@@ -155,6 +155,7 @@ func (w world) EntitiesIn(bounds vmath.AABB) (out []Entity) {
 
 func clearChunks() {
 	for _, c := range chunkMap {
+		Client.chunkCache.save(c)
 		c.free()
 	}
 	chunkMap = map[chunkPosition]*chunk{}
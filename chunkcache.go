@@ -0,0 +1,420 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steven
+
+import (
+	"encoding/binary"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thinkofdeath/steven/encoding/nbt"
+	"github.com/thinkofdeath/steven/protocol"
+	"github.com/thinkofdeath/steven/type/nibble"
+	"github.com/thinkofdeath/steven/world/anvil"
+)
+
+var errInvalidChunk = errors.New("invalid chunk")
+
+// chunkCache saves the chunks received from a server to disk in the
+// Anvil region format. The saved world can be opened in other tools
+// or viewed in steven by setting STEVEN_VIEW to its directory.
+//
+// Chunks are saved when they are unloaded or when the client
+// disconnects. The methods are safe to call on a nil cache.
+type chunkCache struct {
+	dir       string
+	name      string
+	dimension int
+	// Set once the server has told the client which dimension it is
+	// in, before then the chunks are steven's own
+	active bool
+
+	// Chunks waiting to be written. Saving a chunk again before
+	// it is written replaces the queued copy so the queue never
+	// blocks the main thread and doesn't grow without bound.
+	lock    sync.Mutex
+	pending map[chunkSaveKey]*nbt.Compound
+	closed  bool
+	// Wakes the writer when chunks are queued
+	wake chan struct{}
+	done chan struct{}
+	// Written once all the chunks have been saved
+	level *nbt.Compound
+}
+
+type chunkSaveKey struct {
+	dimension int
+	x, z      int
+}
+
+// newChunkCache creates a chunk cache for the server. The world is
+// saved in a directory named after the server within the directory
+// set in the config.
+func newChunkCache(server string) *chunkCache {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`:/\*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, server)
+	cc := &chunkCache{
+		dir:     filepath.Join(Config.Game.ChunkCache, name),
+		name:    server,
+		pending: map[chunkSaveKey]*nbt.Compound{},
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	go cc.writer()
+	return cc
+}
+
+// setDimension changes the dimension chunks are saved in to.
+func (cc *chunkCache) setDimension(dimension int) {
+	if cc == nil {
+		return
+	}
+	cc.active = true
+	cc.dimension = dimension
+}
+
+// save queues the chunk to be written to disk.
+func (cc *chunkCache) save(c *chunk) {
+	if cc == nil || !cc.active {
+		return
+	}
+	cc.queue(c)
+	cc.signal()
+}
+
+// queue converts the chunk and adds it to the pending saves.
+func (cc *chunkCache) queue(c *chunk) {
+	tag := chunkToNBT(c)
+	cc.lock.Lock()
+	cc.pending[chunkSaveKey{cc.dimension, c.X, c.Z}] = tag
+	cc.lock.Unlock()
+}
+
+// signal wakes the writer without waiting for it.
+func (cc *chunkCache) signal() {
+	select {
+	case cc.wake <- struct{}{}:
+	default:
+	}
+}
+
+// close saves all loaded chunks and the level information. The
+// returned channel is closed once everything has been written.
+func (cc *chunkCache) close() <-chan struct{} {
+	var level *nbt.Compound
+	if cc.active {
+		for _, c := range chunkMap {
+			cc.queue(c)
+		}
+		level = levelToNBT(cc.name, Client.X, Client.Y, Client.Z)
+	}
+	cc.lock.Lock()
+	cc.level = level
+	cc.closed = true
+	cc.lock.Unlock()
+	cc.signal()
+	return cc.done
+}
+
+func (cc *chunkCache) writer() {
+	defer close(cc.done)
+	regions := map[string]*anvil.Region{}
+	defer func() {
+		for _, r := range regions {
+			r.Close()
+		}
+	}()
+	for {
+		<-cc.wake
+		cc.lock.Lock()
+		saves := cc.pending
+		cc.pending = map[chunkSaveKey]*nbt.Compound{}
+		closed := cc.closed
+		cc.lock.Unlock()
+
+		for key, tag := range saves {
+			cc.write(regions, key, tag)
+		}
+		if closed {
+			break
+		}
+	}
+	if cc.level != nil {
+		if err := nbt.WriteFile(filepath.Join(cc.dir, "level.dat"), cc.level, nbt.Gzip); err != nil {
+			log.Printf("Failed to save level.dat: %s\n", err)
+		}
+	}
+}
+
+// write saves a single chunk, opening its region if needed.
+func (cc *chunkCache) write(regions map[string]*anvil.Region, key chunkSaveKey, tag *nbt.Compound) {
+	dir := cc.dir
+	switch key.dimension {
+	case -1:
+		dir = filepath.Join(dir, "DIM-1")
+	case 1:
+		dir = filepath.Join(dir, "DIM1")
+	}
+	dir = filepath.Join(dir, "region")
+	path := filepath.Join(dir, anvil.RegionFile(anvil.RegionPosition(key.x, key.z)))
+	r, ok := regions[path]
+	if !ok {
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			log.Printf("Failed to save chunk: %s\n", err)
+			return
+		}
+		var err error
+		r, err = anvil.OpenRegion(path)
+		if err != nil {
+			log.Printf("Failed to save chunk: %s\n", err)
+			return
+		}
+		regions[path] = r
+	}
+	if err := r.WriteChunk(key.x, key.z, tag); err != nil {
+		log.Printf("Failed to save chunk %d,%d: %s\n", key.x, key.z, err)
+	}
+}
+
+// chunkToNBT converts the chunk into the format used by Minecraft's
+// region files.
+func chunkToNBT(c *chunk) *nbt.Compound {
+	level := nbt.NewCompound()
+	level.Items["xPos"] = int32(c.X)
+	level.Items["zPos"] = int32(c.Z)
+	level.Items["LastUpdate"] = int64(0)
+	level.Items["InhabitedTime"] = int64(0)
+	level.Items["V"] = int8(1)
+	// Stop the game generating over the saved chunks
+	level.Items["TerrainPopulated"] = int8(1)
+	level.Items["LightPopulated"] = int8(1)
+	level.Items["Biomes"] = append([]byte(nil), c.Biomes[:]...)
+	level.Items["Entities"] = &nbt.List{Type: nbt.TagCompound}
+	level.Items["TileEntities"] = &nbt.List{Type: nbt.TagCompound}
+
+	heightMap := make([]int32, 16*16)
+	sections := &nbt.List{Type: nbt.TagCompound}
+	for _, cs := range c.Sections {
+		if cs == nil {
+			continue
+		}
+		blocks := make([]byte, 16*16*16)
+		data := nibble.Array(nibble.New(16 * 16 * 16))
+		for i, sid := range cs.Blocks {
+			b := allBlocks[sid]
+			id := legacyBlockID(b)
+			blocks[i] = byte(id >> 4)
+			data.Set(i, byte(id&0xF))
+			if !b.Is(Blocks.Air) {
+				heightMap[i&0xFF] = int32(cs.Y<<4 + i>>8 + 1)
+			}
+		}
+		section := nbt.NewCompound()
+		section.Items["Y"] = int8(cs.Y)
+		section.Items["Blocks"] = blocks
+		section.Items["Data"] = []byte(data)
+		section.Items["BlockLight"] = append([]byte(nil), cs.BlockLight...)
+		section.Items["SkyLight"] = append([]byte(nil), cs.SkyLight...)
+		sections.Elements = append(sections.Elements, section)
+	}
+	level.Items["Sections"] = sections
+	level.Items["HeightMap"] = heightMap
+
+	root := nbt.NewCompound()
+	root.Items["Level"] = level
+	return root
+}
+
+// chunkDataFromNBT converts a chunk from a region file into the packet
+// a server would send for it.
+func chunkDataFromNBT(tag *nbt.Compound) (*protocol.ChunkData, error) {
+	type section struct {
+		Y                    int8
+		Blocks, Data, Add    []byte
+		BlockLight, SkyLight []byte
+	}
+	var chunk struct {
+		Level *struct {
+			X        int32 `nbt:"xPos"`
+			Z        int32 `nbt:"zPos"`
+			Biomes   []byte
+			Sections []*section
+		}
+	}
+	if err := nbt.Unmarshal(tag, &chunk); err != nil {
+		return nil, err
+	}
+	level := chunk.Level
+	if level == nil {
+		return nil, errInvalidChunk
+	}
+
+	var secs [16]*section
+	var mask uint16
+	for _, s := range level.Sections {
+		if s.Y < 0 || s.Y > 15 || len(s.Blocks) != 4096 || len(s.Data) != 2048 ||
+			len(s.BlockLight) != 2048 || len(s.SkyLight) != 2048 {
+			return nil, errInvalidChunk
+		}
+		if len(s.Add) != 2048 {
+			s.Add = nil
+		}
+		secs[s.Y] = s
+		mask |= 1 << uint(s.Y)
+	}
+	var data []byte
+	for _, s := range secs {
+		if s == nil {
+			continue
+		}
+		var buf [2]byte
+		for i, b := range s.Blocks {
+			id := uint16(b)
+			if s.Add != nil {
+				id |= uint16(nibble.Array(s.Add).Get(i)) << 8
+			}
+			binary.LittleEndian.PutUint16(buf[:], id<<4|uint16(nibble.Array(s.Data).Get(i)))
+			data = append(data, buf[:]...)
+		}
+	}
+	for _, s := range secs {
+		if s != nil {
+			data = append(data, s.BlockLight...)
+		}
+	}
+	for _, s := range secs {
+		if s != nil {
+			data = append(data, s.SkyLight...)
+		}
+	}
+	biomes := level.Biomes
+	if len(biomes) != 256 {
+		biomes = make([]byte, 256)
+		for i := range biomes {
+			biomes[i] = 1 // Plains
+		}
+	}
+	data = append(data, biomes...)
+
+	return &protocol.ChunkData{
+		ChunkX:  level.X,
+		ChunkZ:  level.Z,
+		New:     true,
+		BitMask: mask,
+		Data:    data,
+	}, nil
+}
+
+// Cache of the ids Minecraft uses for each block, indexed by SID.
+// -1 if the id hasn't been worked out yet.
+var legacyIDs []int32
+
+// legacyBlockID returns the combined id (id << 4 | data) Minecraft
+// uses for the block.
+func legacyBlockID(b Block) uint16 {
+	if legacyIDs == nil {
+		legacyIDs = make([]int32, len(allBlocks))
+		for i := range legacyIDs {
+			legacyIDs[i] = -1
+		}
+	}
+	if id := legacyIDs[b.SID()]; id != -1 {
+		return uint16(id)
+	}
+	bs := b.BlockSet()
+	if bs == nil || bs == Blocks.MissingBlock {
+		legacyIDs[b.SID()] = 0
+		return 0
+	}
+	data := b.toData()
+	if data == -1 {
+		// Some states (e.g. fence connections) are worked out by the
+		// client and can't be stored. Use the storable block that
+		// shares the most states with this one instead.
+		states := b.states()
+		best := -1
+		for _, o := range bs.Blocks {
+			d := o.toData()
+			if d == -1 {
+				continue
+			}
+			score := 0
+			for i, s := range o.states() {
+				if s.Value == states[i].Value {
+					score++
+				}
+			}
+			if score > best {
+				best, data = score, d
+			}
+		}
+		if data == -1 {
+			data = 0
+		}
+	}
+	id := uint16(bs.ID<<4 | data)
+	legacyIDs[b.SID()] = int32(id)
+	return id
+}
+
+// levelToNBT creates the level.dat information for a cached world.
+func levelToNBT(name string, x, y, z float64) *nbt.Compound {
+	data := nbt.NewCompound()
+	data.Items["version"] = int32(19133) // Anvil
+	data.Items["LevelName"] = name
+	data.Items["generatorName"] = "default"
+	data.Items["GameType"] = int32(gmCreative)
+	data.Items["SpawnX"] = int32(x)
+	data.Items["SpawnY"] = int32(y)
+	data.Items["SpawnZ"] = int32(z)
+	data.Items["LastPlayed"] = time.Now().Unix() * 1000
+	data.Items["RandomSeed"] = int64(0)
+	data.Items["Time"] = int64(0)
+	data.Items["DayTime"] = int64(6000)
+	data.Items["MapFeatures"] = int8(0)
+	data.Items["allowCommands"] = int8(1)
+	data.Items["hardcore"] = int8(0)
+	data.Items["initialized"] = int8(1)
+
+	root := nbt.NewCompound()
+	root.Items["Data"] = data
+	return root
+}
+
+// readLevelSpawn returns the spawn point stored in the level.dat file.
+func readLevelSpawn(path string) (x, y, z int, err error) {
+	root, _, err := nbt.ReadFile(path)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	var level struct {
+		Data struct {
+			SpawnX, SpawnY, SpawnZ int
+		}
+	}
+	if err := nbt.Unmarshal(root, &level); err != nil {
+		return 0, 0, 0, err
+	}
+	return level.Data.SpawnX, level.Data.SpawnY, level.Data.SpawnZ, nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steven
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/thinkofdeath/steven/world/anvil"
+)
+
+func TestChunkCacheSave(t *testing.T) {
+	dir, err := ioutil.TempDir("", "steven-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(d string) { Config.Game.ChunkCache = d }(Config.Game.ChunkCache)
+	Config.Game.ChunkCache = dir
+
+	defer func(c *ClientState) { Client = c }(Client)
+	Client = newPhysicsClient(0.5, 64, 0.5)
+	cc := newChunkCache("test")
+	cc.setDimension(0)
+	// Saves of the same chunk are merged while they wait for the
+	// writer so this never has to wait for the disk
+	saved := make(chan struct{})
+	go func() {
+		for i := 0; i < 1000; i++ {
+			cc.save(&chunk{chunkPosition: chunkPosition{i % 4, 0}})
+		}
+		close(saved)
+	}()
+	select {
+	case <-saved:
+	case <-time.After(10 * time.Second):
+		t.Fatal("saving chunks blocked")
+	}
+	<-cc.close()
+
+	r, err := anvil.OpenRegion(filepath.Join(dir, "test", "region", anvil.RegionFile(anvil.RegionPosition(0, 0))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	for x := 0; x < 4; x++ {
+		if !r.HasChunk(x, 0) {
+			t.Errorf("chunk %d,0 wasn't saved", x)
+		}
+	}
+}
@@ -50,6 +50,12 @@ func initClient() {
 			Client.entities.container.RemoveEntity(Client.entity)
 		}
 		Client.playerList.free()
+		Client.scoreboard.free()
+		Client.title.free()
+		Client.worldBorder.free()
+		Client.maps.free()
+		Client.effectsUI.free()
+		render.ClearParticles()
 
 		Client.playerInventory.Close()
 		Client.hotbarScene.Hide()
@@ -72,21 +78,30 @@ type ClientState struct {
 
 	entity      *clientEntity
 	entityAdded bool
+	// The network id of the player's entity
+	entityID int
+	// The network id of the entity the player is riding,
+	// -1 when they aren't riding anything
+	vehicleID int
 
-	LX, LY, LZ float64
 	X, Y, Z    float64
 	Yaw, Pitch float64
 
 	Health float64
 	Hunger float64
 
-	VSpeed                   float64
-	KeyState                 [6]bool
+	KeyState                 [7]bool
 	OnGround, didTouchGround bool
 	isLeftDown               bool
+	physics                  playerPhysics
 
-	GameMode gameMode
-	HardCore bool
+	effects effectsComponent
+	// Smoothed zoom from the player's speed
+	fovModifier float64
+
+	GameMode  gameMode
+	HardCore  bool
+	Dimension int
 
 	Bounds vmath.AABB
 
@@ -94,6 +109,7 @@ type ClientState struct {
 		enabled  bool
 		position *ui.Text
 		facing   *ui.Text
+		heldItem *ui.Text
 		fps      *ui.Text
 		memory   *ui.Text
 
@@ -117,13 +133,26 @@ type ClientState struct {
 	itemNameUI                        *ui.Formatted
 	itemNameTimer                     float64
 
-	network    networkManager
-	chat       ChatUI
-	playerList playerListUI
-	entities   clientEntities
+	network     networkManager
+	chunkCache  *chunkCache
+	chat        ChatUI
+	playerList  playerListUI
+	scoreboard  scoreboard
+	teams       teams
+	title       titleUI
+	worldTime   worldTime
+	worldBorder worldBorder
+	maps        clientMaps
+	effectsUI   effectsUI
+	entities    clientEntities
 
 	playerInventory *Inventory
 	hotbarScene     *scene.Type
+	// The window opened by the server, nil if only the player's
+	// inventory is open
+	currentWindow *containerWindow
+	// The item held by the mouse while a window is open
+	cursorItem *ItemStack
 
 	currentBreakingBlock    Block
 	currentBreakingPos      Position
@@ -141,7 +170,8 @@ func newClient() {
 			Min: mgl32.Vec3{-0.3, 0, -0.3},
 			Max: mgl32.Vec3{0.3, 1.8, 0.3},
 		},
-		scene: scene.New(true),
+		scene:     scene.New(true),
+		vehicleID: -1,
 	}
 	Client = c
 	c.playerInventory = NewInventory(InvPlayer, 45)
@@ -203,6 +233,13 @@ func newClient() {
 	c.chat.init()
 	c.initDebug()
 	c.playerList.init()
+	c.scoreboard.init()
+	c.teams.init()
+	c.title.init()
+	c.worldTime.init()
+	c.worldBorder.init()
+	c.maps.init()
+	c.effectsUI.init()
 	c.entities.init()
 
 	c.initEntity(false)
@@ -221,8 +258,13 @@ type clientEntity struct {
 
 func (c *ClientState) initEntity(head bool) {
 	ce := &clientEntity{}
-	ub, _ := hex.DecodeString(profile.ID)
-	copy(ce.uuid[:], ub)
+	if profile.ID != "" {
+		ub, _ := hex.DecodeString(profile.ID)
+		copy(ce.uuid[:], ub)
+	} else {
+		// Offline mode servers derive the uuid from the username
+		ce.uuid = protocol.OfflineUUID(profile.Username)
+	}
 	c.entity = ce
 	ce.hasHead = head
 	ce.isFirstPerson = !head
@@ -247,97 +289,24 @@ func (c *ClientState) cycleCamera() {
 
 func (c *ClientState) renderTick(delta float64) {
 	c.delta = delta
+	c.worldTime.tick(delta)
 	c.hotbarUI.SetX(-184 + 24 + 40*float64(c.currentHotbarSlot))
 	c.tickItemName()
 
-	forward, yaw := c.calculateMovement()
-
-	c.LX, c.LY, c.LZ = c.X, c.Y, c.Z
-	lx, ly, lz := c.X, c.Y, c.Z
-
-	if c.GameMode.Fly() {
-		c.X += forward * math.Cos(yaw) * -math.Cos(c.Pitch) * delta * 0.2
-		c.Z -= forward * math.Sin(yaw) * -math.Cos(c.Pitch) * delta * 0.2
-		c.Y -= forward * math.Sin(c.Pitch) * delta * 0.2
-	} else if chunkMap[chunkPosition{int(math.Floor(c.X)) >> 4, int(math.Floor(c.Z)) >> 4}] != nil {
-		speed := 4.317 / 60.0
-		if c.KeyState[KeySprint] {
-			speed = 5.612 / 60.0
-		}
-		if _, ok := chunkMap.Block(int(math.Floor(c.X)), int(math.Floor(c.Y)), int(math.Floor(c.Z))).(*blockLiquid); ok {
-			speed = 2.20 / 60.0
-			if c.KeyState[KeyJump] {
-				c.VSpeed = 0.05
-			} else {
-				c.VSpeed -= 0.005 * delta
-				if c.VSpeed < -0.05 {
-					c.VSpeed = -0.05
-				}
-			}
-		} else if !c.OnGround {
-			c.VSpeed -= 0.01 * delta
-			if c.VSpeed < -0.3 {
-				c.VSpeed = -0.3
-			}
-		} else if c.KeyState[KeyJump] {
-			c.VSpeed = 0.15
-		} else {
-			c.VSpeed = 0
-		}
-		c.X += forward * math.Cos(yaw) * delta * speed
-		c.Z -= forward * math.Sin(yaw) * delta * speed
-		c.Y += c.VSpeed * delta
-	}
-
-	if !c.GameMode.NoClip() {
-		cx := c.X
-		cy := c.Y
-		cz := c.Z
-		c.Y = c.LY
-		c.Z = c.LZ
-
-		// We handle each axis separately to allow for a sliding
-		// effect when pushing up against walls.
-
-		bounds, xhit := c.checkCollisions(c.Bounds)
-		c.X = float64(bounds.Min[0] + 0.3)
-		c.LX = c.X
-
-		c.Z = cz
-		bounds, zhit := c.checkCollisions(c.Bounds)
-		c.Z = float64(bounds.Min[2] + 0.3)
-		c.LZ = c.Z
-
-		// Half block jumps
-		// Minecraft lets you 'jump' up 0.5 blocks
-		// for slabs and stairs (or smaller blocks).
-		// Currently we implement this as a teleport to the
-		// top of the block if we could move there
-		// but this isn't smooth.
-		if (xhit || zhit) && c.OnGround {
-			ox, oz := c.X, c.Z
-			c.X, c.Z = cx, cz
-			for i := 1.0 / 16.0; i <= 0.5; i += 1.0 / 16.0 {
-				mini := c.Bounds.Shift(0, float32(i), 0)
-				_, hit := c.checkCollisions(mini)
-				if !hit {
-					cy += i
-					ox, oz = c.X, c.Z
-					break
-				}
-			}
-			c.X, c.Z = ox, oz
+	p := &c.physics
+	vehicle := c.vehicle()
+	if vehicle != nil {
+		// The server moves the vehicle, the player just
+		// follows it
+		if x, y, z, ok := mountedPosition(vehicle); ok {
+			c.X, c.Y, c.Z = x, y+playerRidingOffset, z
+			p.lastX, p.lastY, p.lastZ = c.X, c.Y, c.Z
 		}
-
-		c.Y = cy
-		bounds, yhit := c.checkCollisions(c.Bounds)
-		c.Y = float64(bounds.Min.Y())
-		if yhit {
-			c.VSpeed = 0
-		}
-
-		c.checkGround()
 	}
+	// The player is only moved once a tick so their position
+	// is smoothed between ticks
+	p.partial = math.Min(1, p.partial+delta/3)
+	x, y, z := c.interpolatedPosition()
 
 	c.Pitch = math.Mod(c.Pitch, math.Pi*2)
 	c.Yaw = math.Mod(c.Yaw, math.Pi*2)
@@ -350,13 +319,15 @@ func (c *ClientState) renderTick(delta float64) {
 
 	ox := math.Cos(c.Yaw-math.Pi/2) * 0.25
 	oz := -math.Sin(c.Yaw-math.Pi/2) * 0.25
-	c.entity.SetTargetPosition(c.X-ox, c.Y, c.Z-oz)
+	c.entity.SetTargetPosition(x-ox, y, z-oz)
 	c.entity.SetTargetYaw(-c.Yaw)
 	c.entity.SetTargetPitch(-c.Pitch - math.Pi)
-	c.entity.walking = c.X != lx || c.Y != ly || c.Z != lz
+	c.entity.walking = c.X != p.lastX || c.Y != p.lastY || c.Z != p.lastZ
+	c.entity.riding = vehicle != nil
 
 	//  Highlights the target block
 	c.highlightTarget()
+	c.worldBorder.tick(delta)
 
 	// Debug displays
 	c.renderDebug()
@@ -365,8 +336,15 @@ func (c *ClientState) renderTick(delta float64) {
 	c.chat.Draw(delta)
 
 	c.playerList.render(delta)
+	c.scoreboard.render(delta)
+	c.title.render(delta)
+	c.maps.render(delta)
+	c.updateEffects(delta)
+	c.effectsUI.render(delta)
 	c.entities.tick()
+	render.TickParticles(delta, particleWorld{})
 	c.copyToCamera()
+	tickAudio()
 }
 
 func (c *ClientState) tickItemName() {
@@ -376,14 +354,7 @@ func (c *ClientState) tickItemName() {
 		c.lastHotbarItem = item
 		c.entity.SetCurrentItem(item)
 		if item != nil {
-			var name chat.AnyComponent
-			if di, ok := item.Type.(DisplayTag); ok && di.DisplayName() != "" {
-				name = chat.AnyComponent{Value: &chat.TextComponent{Text: di.DisplayName()}}
-				chat.ConvertLegacy(name)
-			} else {
-				name = chat.AnyComponent{Value: &chat.TranslateComponent{Translate: item.Type.NameLocaleKey()}}
-			}
-			c.itemNameUI.Update(name)
+			c.itemNameUI.Update(item.displayName())
 			c.itemNameTimer = 120
 		} else {
 			c.itemNameUI.Update(chat.AnyComponent{Value: &chat.TextComponent{}})
@@ -792,18 +763,6 @@ func (c *ClientState) highlightTarget() {
 	}
 }
 
-func (c *ClientState) checkGround() {
-	ground := vmath.AABB{
-		Min: mgl32.Vec3{-0.3, -0.05, -0.3},
-		Max: mgl32.Vec3{0.3, 0.0, 0.3},
-	}
-	prev := c.OnGround
-	_, c.OnGround = c.checkCollisions(ground)
-	if !prev && c.OnGround {
-		c.didTouchGround = true
-	}
-}
-
 func (c *ClientState) calculateMovement() (float64, float64) {
 	forward := 0.0
 	yaw := c.Yaw - math.Pi/2
@@ -849,39 +808,6 @@ func (c *ClientState) viewVector() mgl32.Vec3 {
 	}
 }
 
-func (c *ClientState) checkCollisions(bounds vmath.AABB) (vmath.AABB, bool) {
-	bounds = bounds.Shift(float32(c.X), float32(c.Y), float32(c.Z))
-
-	dir := mgl32.Vec3{
-		-float32(c.LX - c.X),
-		-float32(c.LY - c.Y),
-		-float32(c.LZ - c.Z),
-	}
-
-	minX, minY, minZ := int(bounds.Min.X()-1), int(bounds.Min.Y()-1), int(bounds.Min.Z()-1)
-	maxX, maxY, maxZ := int(bounds.Max.X()+1), int(bounds.Max.Y()+1), int(bounds.Max.Z()+1)
-
-	hit := false
-	for y := minY; y < maxY; y++ {
-		for z := minZ; z < maxZ; z++ {
-			for x := minX; x < maxX; x++ {
-				b := chunkMap.Block(x, y, z)
-
-				if b.Collidable() {
-					for _, bb := range b.CollisionBounds() {
-						bb = bb.Shift(float32(x), float32(y), float32(z))
-						if bb.Intersects(bounds) {
-							bounds = bounds.MoveOutOf(bb, dir)
-							hit = true
-						}
-					}
-				}
-			}
-		}
-	}
-	return bounds, hit
-}
-
 func (c *ClientState) copyToCamera() {
 	x, y, z := c.entity.Position()
 
@@ -923,6 +849,11 @@ func (c *ClientState) tick() {
 	// what did you expect?
 	// TODO(Think) Use the smaller packets when possible
 
+	if c.Health <= 0 {
+		return
+	}
+	c.physicsTick()
+
 	// Force the server to know when touched the ground
 	// otherwise if it happens between ticks the server
 	// will think we are flying.
@@ -932,16 +863,71 @@ func (c *ClientState) tick() {
 		onGround = true
 	}
 
-	if c.Health > 0 {
-		c.network.Write(&protocol.PlayerPositionLook{
-			X:        c.X,
-			Y:        c.Y,
-			Z:        c.Z,
-			Yaw:      float32(-c.Yaw * (180 / math.Pi)),
-			Pitch:    float32((-c.Pitch - math.Pi) * (180 / math.Pi)),
+	yaw := float32(-c.Yaw * (180 / math.Pi))
+	pitch := float32((-c.Pitch - math.Pi) * (180 / math.Pi))
+	if c.vehicle() != nil {
+		// The server controls the vehicle's position so only
+		// the look and the movement keys are sent
+		c.network.Write(&protocol.PlayerLook{
+			Yaw:      yaw,
+			Pitch:    pitch,
 			OnGround: onGround,
 		})
+		c.network.Write(c.steerVehicle())
+		return
+	}
+	c.network.Write(&protocol.PlayerPositionLook{
+		X:        c.X,
+		Y:        c.Y,
+		Z:        c.Z,
+		Yaw:      yaw,
+		Pitch:    pitch,
+		OnGround: onGround,
+	})
+}
+
+// vehicle returns the entity the player is riding, nil if they
+// aren't riding anything.
+func (c *ClientState) vehicle() Entity {
+	if c.vehicleID == -1 {
+		return nil
+	}
+	e, ok := c.entities.entities[c.vehicleID]
+	if !ok {
+		// The vehicle has been removed
+		c.vehicleID = -1
+		return nil
+	}
+	return e
+}
+
+// steerVehicle converts the movement keys into the input the server
+// uses to move the vehicle the player is riding.
+func (c *ClientState) steerVehicle() *protocol.SteerVehicle {
+	// The vanilla client scales its input by 0.98 before
+	// sending it
+	const input = 0.98
+	steer := &protocol.SteerVehicle{}
+	if c.KeyState[KeyForward] {
+		steer.Forward += input
+	}
+	if c.KeyState[KeyBackwards] {
+		steer.Forward -= input
+	}
+	if c.KeyState[KeyLeft] {
+		steer.Sideways += input
+	}
+	if c.KeyState[KeyRight] {
+		steer.Sideways -= input
+	}
+	if c.KeyState[KeyJump] {
+		steer.Flags |= 0x1
+	}
+	if c.KeyState[KeySneak] {
+		// Dismounts the player
+		steer.Flags |= 0x2
 	}
+	return steer
 }
 
 type gameMode int
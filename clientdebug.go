@@ -32,6 +32,9 @@ func (c *ClientState) initDebug() {
 	c.debug.facing = ui.NewText("Facing: invalid", 5, 23, 255, 255, 255).
 		Attach(ui.Top, ui.Left)
 	c.scene.AddDrawable(c.debug.facing)
+	c.debug.heldItem = ui.NewText("", 5, 41, 255, 255, 255).
+		Attach(ui.Top, ui.Left)
+	c.scene.AddDrawable(c.debug.heldItem)
 
 	c.debug.fps = ui.NewText("FPS: 0", 5, 5, 255, 255, 255).
 		Attach(ui.Top, ui.Right)
@@ -55,6 +58,7 @@ func (c *ClientState) toggleDebug() {
 	e := c.debug.enabled
 	c.debug.position.SetDraw(e)
 	c.debug.facing.SetDraw(e)
+	c.debug.heldItem.SetDraw(e)
 	c.debug.fps.SetDraw(e)
 	c.debug.memory.SetDraw(e)
 	c.debug.target.SetDraw(e)
@@ -71,6 +75,7 @@ func (c *ClientState) renderDebug() {
 	}
 	c.debug.position.Update(fmt.Sprintf("X: %.2f, Y: %.2f, Z: %.2f", c.X, c.Y, c.Z))
 	c.debug.facing.Update(fmt.Sprintf("Facing: %s", c.facingDirection()))
+	c.debug.heldItem.Update(c.heldItemInfo())
 
 	c.displayTargetInfo()
 
@@ -87,6 +92,28 @@ func (c *ClientState) renderDebug() {
 	c.debug.fps.Update(fmt.Sprintf("FPS: %d", c.debug.fpsValue))
 }
 
+// Limit on the length of the held item's tag so that it doesn't
+// run off the screen.
+const maxDebugTagLength = 100
+
+func (c *ClientState) heldItemInfo() string {
+	item := c.playerInventory.Items[c.currentHotbarSlot+invPlayerHotbarOffset]
+	if item == nil {
+		return ""
+	}
+	info := fmt.Sprintf("Held: %s x%d", item.Type.Name(), item.Count)
+	if item.Tag != nil {
+		// Cut on a rune boundary so that the text stays valid
+		// UTF-8
+		tag := []rune(item.Tag.String())
+		if len(tag) > maxDebugTagLength {
+			tag = append(tag[:maxDebugTagLength], []rune("...")...)
+		}
+		info += " " + string(tag)
+	}
+	return info
+}
+
 func formatMemory(alloc uint64) string {
 	const letters = "BKMG"
 	i := 0
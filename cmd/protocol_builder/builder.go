@@ -24,18 +24,27 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 )
 
 const (
-	idSearchString = "Currently the packet id is: 0x"
-	searchString   = "This is a packet"
+	idSearchString      = "Currently the packet id is: 0x"
+	searchString        = "This is a packet"
+	versionSearchString = "In protocol "
+	versionIDString     = " the packet id is: 0x"
+	// Packets that can't be mapped to the version
+	versionChangedString = " the packet's layout changed"
+	versionRemovedString = " the packet was removed"
 )
 
+// unsupportedID is the id of packets that exist in the package's
+// version but can't be used in another version.
+const unsupportedID = -1
+
 var (
-	protocol, dir string
-	notProtocol   bool
+	notProtocol bool
 
 	structs = map[string]*ast.TypeSpec{}
 	packets []packet
@@ -45,6 +54,10 @@ var (
 type packet struct {
 	id   int
 	name string
+	// Protocol version -> packet id for versions other than
+	// the one the package targets, unsupportedID if the packet
+	// can't be used in the version
+	versions map[int]int
 }
 
 func main() {
@@ -54,15 +67,37 @@ func main() {
 	}
 
 	input := os.Args[1]
+	var protocol, dir string
 	if len(os.Args) >= 4 {
 		protocol = os.Args[2]
 		dir = os.Args[3]
 	}
 
+	b, err := generate(input, protocol, dir)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	o, err := os.Create(input[:len(input)-len(filepath.Ext(input))] + "_proto.go")
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer o.Close()
+	o.Write(b)
+}
+
+// generate returns the formatted source of the methods (and, if the
+// protocol state and direction are set, the registration of the
+// packets) for the packets declared in the input file.
+func generate(input, protocol, dir string) ([]byte, error) {
+	structs = map[string]*ast.TypeSpec{}
+	packets = nil
+	imports = map[string]struct{}{}
+
 	fs := token.NewFileSet()
 	parsedFile, err := parser.ParseFile(fs, input, nil, parser.ParseComments)
 	if err != nil {
-		log.Fatalln(err)
+		return nil, err
 	}
 	notProtocol = parsedFile.Name.String() != "protocol"
 
@@ -74,7 +109,7 @@ func main() {
 			}
 
 			if len(decl.Specs) != 1 {
-				return
+				return nil, fmt.Errorf("%s: grouped type declarations aren't supported", fs.Position(decl.Pos()))
 			}
 
 			tSpec, ok := decl.Specs[0].(*ast.TypeSpec)
@@ -104,14 +139,23 @@ func main() {
 
 			var packetID int64 = -1
 			if !noId {
-				packetID, err = strconv.ParseInt(strings.TrimSpace(doc[pos+len(idSearchString):]), 16, 32)
+				idStr := doc[pos+len(idSearchString):]
+				if end := strings.IndexRune(idStr, '\n'); end != -1 {
+					idStr = idStr[:end]
+				}
+				packetID, err = strconv.ParseInt(strings.TrimSpace(idStr), 16, 32)
 				if err != nil {
-					panic(err)
+					return nil, fmt.Errorf("%s: %s", tSpec.Name.Name, err)
 				}
 			}
+			versions, err := parseVersionIDs(doc)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", tSpec.Name.Name, err)
+			}
 			packets = append(packets, packet{
-				id:   int(packetID),
-				name: tSpec.Name.Name,
+				id:       int(packetID),
+				name:     tSpec.Name.Name,
+				versions: versions,
 			})
 		}
 	}
@@ -154,6 +198,15 @@ func main() {
 		for _, p := range packets {
 			fmt.Fprintf(&buf, "packetCreator[%s][%s][%d] = func () Packet { return &%s{} }\n", protocol, dir, p.id, p.name)
 		}
+		for _, p := range packets {
+			for _, v := range sortedVersions(p.versions) {
+				if id := p.versions[v]; id == unsupportedID {
+					fmt.Fprintf(&buf, "registerUnsupportedPacket(%d, %s, %s, %d)\n", v, protocol, dir, p.id)
+				} else {
+					fmt.Fprintf(&buf, "registerPacketID(%d, %s, %s, %d, %d)\n", v, protocol, dir, id, p.id)
+				}
+			}
+		}
 		buf.WriteString("}\n")
 	}
 
@@ -174,14 +227,59 @@ func main() {
 
 	b, err := format.Source(header.Bytes())
 	if err != nil {
-		log.Println(header.String())
-		log.Fatalf("format error: %s", err)
+		return nil, fmt.Errorf("format error: %s\n%s", err, header.String())
 	}
+	return b, nil
+}
 
-	o, err := os.Create(input[:len(input)-len(filepath.Ext(input))] + "_proto.go")
-	if err != nil {
-		log.Fatalln(err)
+// parseVersionIDs parses the packet ids for other protocol versions
+// from a packet's documentation. Each version is on its own line in
+// one of the forms
+//
+//	In protocol 107 the packet id is: 0x1F
+//	In protocol 107 the packet's layout changed, its id is: 0x20
+//	In protocol 107 the packet was removed
+//
+// The first maps the packet to the id, the others mark it as
+// unsupported (unsupportedID) in the version as only the id can be
+// remapped.
+func parseVersionIDs(doc string) (map[int]int, error) {
+	versions := map[int]int{}
+	for _, line := range strings.Split(doc, "\n") {
+		if !strings.HasPrefix(line, versionSearchString) {
+			continue
+		}
+		line = line[len(versionSearchString):]
+		end := strings.IndexRune(line, ' ')
+		if end == -1 {
+			return nil, fmt.Errorf("malformed version line %q", line)
+		}
+		version, err := strconv.Atoi(line[:end])
+		if err != nil {
+			return nil, err
+		}
+		line = line[end:]
+		switch {
+		case strings.HasPrefix(line, versionChangedString), line == versionRemovedString:
+			versions[version] = unsupportedID
+		case strings.HasPrefix(line, versionIDString):
+			id, err := strconv.ParseInt(strings.TrimSpace(line[len(versionIDString):]), 16, 32)
+			if err != nil {
+				return nil, err
+			}
+			versions[version] = int(id)
+		default:
+			return nil, fmt.Errorf("malformed version line %q", line)
+		}
 	}
-	defer o.Close()
-	o.Write(b)
+	return versions, nil
+}
+
+func sortedVersions(versions map[int]int) []int {
+	out := make([]int, 0, len(versions))
+	for v := range versions {
+		out = append(out, v)
+	}
+	sort.Ints(out)
+	return out
 }
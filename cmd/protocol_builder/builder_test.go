@@ -0,0 +1,87 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	out, err := generate("testdata/packets.go", "Play", "clientbound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(out)
+	if _, err := parser.ParseFile(token.NewFileSet(), "packets_proto.go", out, 0); err != nil {
+		t.Fatalf("generated code doesn't parse: %s\n%s", err, src)
+	}
+
+	want := []string{
+		"func (m *Moved) id() int { return 0 }",
+		"func (c *Changed) read(rr io.Reader) (err error) {",
+		"packetCreator[Play][clientbound][3] = func() Packet { return &Unchanged{} }",
+		"registerPacketID(107, Play, clientbound, 31, 0)",
+		"registerUnsupportedPacket(107, Play, clientbound, 1)",
+		"registerUnsupportedPacket(107, Play, clientbound, 2)",
+	}
+	for _, w := range want {
+		if !strings.Contains(src, w) {
+			t.Errorf("missing %q in\n%s", w, src)
+		}
+	}
+	if strings.Contains(src, "registerPacketID(107, Play, clientbound, 32") {
+		t.Error("the changed packet was mapped to its new id")
+	}
+	if n := strings.Count(src, "107"); n != 3 {
+		t.Errorf("expected 3 registrations for protocol 107, got %d", n)
+	}
+}
+
+func TestParseVersionIDs(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want map[int]int
+		err  bool
+	}{
+		{"Currently the packet id is: 0x01\n", map[int]int{}, false},
+		{"In protocol 107 the packet id is: 0x1F\nIn protocol 5 the packet id is: 0x02\n", map[int]int{107: 0x1F, 5: 0x02}, false},
+		{"In protocol 107 the packet's layout changed, its id is: 0x20\n", map[int]int{107: unsupportedID}, false},
+		{"In protocol 107 the packet was removed\n", map[int]int{107: unsupportedID}, false},
+		{"In protocol 107 the packet is purple\n", nil, true},
+		{"In protocol abc the packet id is: 0x01\n", nil, true},
+	}
+	for _, test := range tests {
+		got, err := parseVersionIDs(test.doc)
+		if (err != nil) != test.err {
+			t.Errorf("%q: unexpected error state %v", test.doc, err)
+			continue
+		}
+		if test.err {
+			continue
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("%q: got %v, wanted %v", test.doc, got, test.want)
+			continue
+		}
+		for v, id := range test.want {
+			if got[v] != id {
+				t.Errorf("%q: got %v, wanted %v", test.doc, got, test.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,34 @@
+// Fixture for the protocol_builder tests
+
+package protocol
+
+// Moved is a packet that moved to a new id.
+//
+// Currently the packet id is: 0x00
+// In protocol 107 the packet id is: 0x1F
+type Moved struct {
+	ID VarInt
+}
+
+// Changed is a packet with a different layout in protocol 107.
+//
+// Currently the packet id is: 0x01
+// In protocol 107 the packet's layout changed, its id is: 0x20
+type Changed struct {
+	X, Z int32
+}
+
+// Removed is a packet that doesn't exist in protocol 107.
+//
+// Currently the packet id is: 0x02
+// In protocol 107 the packet was removed
+type Removed struct {
+	Data []byte `length:"VarInt"`
+}
+
+// Unchanged is a packet without any other versions.
+//
+// Currently the packet id is: 0x03
+type Unchanged struct {
+	Message string
+}
@@ -0,0 +1,72 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// stevenbot joins a server without a window, walks forward for a while
+// and then leaves. This is mainly useful for testing servers (or
+// steven itself) on machines without a GPU.
+//
+//	stevenbot -server localhost:25565 -username Bot -chat "Hello" -walk 5s -stay 1m
+package main
+
+import (
+	"flag"
+	"log"
+	"runtime"
+	"time"
+
+	"github.com/thinkofdeath/steven"
+	"github.com/thinkofdeath/steven/chat"
+)
+
+var (
+	server   = flag.String("server", "localhost:25565", "address of the server to join")
+	username = flag.String("username", "Steven", "username to join with (offline mode only)")
+	message  = flag.String("chat", "", "message to send once joined")
+	walk     = flag.Duration("walk", 0, "how long to walk forward for")
+	stay     = flag.Duration("stay", 30*time.Second, "how long to stay connected for")
+)
+
+func main() {
+	runtime.GOMAXPROCS(runtime.NumCPU() * 2)
+	flag.Parse()
+
+	err := steven.RunBot(*username, "", "", *server, func(b *steven.Bot) {
+		b.OnChat(func(msg chat.AnyComponent) {
+			log.Printf("Chat: %s\n", msg)
+		})
+		if !b.WaitReady() {
+			return
+		}
+		x, y, z := b.Position()
+		log.Printf("Spawned at %.2f,%.2f,%.2f\n", x, y, z)
+		if *message != "" {
+			b.Chat(*message)
+		}
+		if *walk > 0 {
+			b.SetKey(steven.KeyForward, true)
+			if !b.Wait(*walk) {
+				return
+			}
+			b.SetKey(steven.KeyForward, false)
+			x, y, z = b.Position()
+			log.Printf("Walked to %.2f,%.2f,%.2f\n", x, y, z)
+		}
+		if b.Wait(*stay) {
+			b.Disconnect()
+		}
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// stevenproxy sits between a Minecraft client and an (offline mode)
+// server logging and filtering the packets sent between them.
+//
+//	stevenproxy -server localhost:25565 -listen :25566 -log ChatMessage,ServerMessage -drop TabComplete
+//
+// Packets are named by their type in the protocol package, passing
+// "*" to -log logs every packet.
+package main
+
+import (
+	"flag"
+	"log"
+	"reflect"
+	"strings"
+
+	"github.com/thinkofdeath/steven/protocol"
+	"github.com/thinkofdeath/steven/protocol/proxy"
+)
+
+var (
+	listen      = flag.String("listen", ":25566", "address to listen on")
+	server      = flag.String("server", "localhost:25565", "address of the server to forward to")
+	logPackets  = flag.String("log", "", "comma separated list of packets to log, * for all")
+	dropPackets = flag.String("drop", "", "comma separated list of packets to drop")
+	compression = flag.Int("compression", 256, "compression threshold for clients, -1 to disable")
+)
+
+func main() {
+	flag.Parse()
+
+	p := proxy.New(*server)
+	p.CompressionThreshold = *compression
+
+	if names := packetNames(*logPackets); len(names) > 0 {
+		_, all := names["*"]
+		p.AddHook(func(s *proxy.Session, serverbound bool, packet protocol.Packet) protocol.Packet {
+			if _, ok := names[packetName(packet)]; all || ok {
+				dir := "S->C"
+				if serverbound {
+					dir = "C->S"
+				}
+				log.Printf("%s %s: %+v\n", s.Username, dir, packet)
+			}
+			return packet
+		})
+	}
+	if names := packetNames(*dropPackets); len(names) > 0 {
+		p.AddHook(func(s *proxy.Session, serverbound bool, packet protocol.Packet) protocol.Packet {
+			if _, ok := names[packetName(packet)]; ok {
+				return nil
+			}
+			return packet
+		})
+	}
+
+	log.Printf("Forwarding %s to %s\n", *listen, *server)
+	log.Fatal(p.ListenAndServe(*listen))
+}
+
+func packetNames(list string) map[string]struct{} {
+	names := map[string]struct{}{}
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names[name] = struct{}{}
+		}
+	}
+	return names
+}
+
+func packetName(packet protocol.Packet) string {
+	return reflect.TypeOf(packet).Elem().Name()
+}
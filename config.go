@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"os"
 
+	"github.com/thinkofdeath/steven/audio"
 	"github.com/thinkofdeath/steven/protocol/mojang"
 )
 
@@ -38,6 +39,14 @@ type ConfigData struct {
 		MouseSensitivity int
 		UIScale          string
 		ResourcePacks    []string
+		// Directory to save the chunks received from servers
+		// to, disabled if empty
+		ChunkCache string
+	}
+	Sound struct {
+		// Volume (0-1) of each category of sounds, keyed by
+		// the category's name
+		Volumes map[string]float64
 	}
 }
 
@@ -59,6 +68,10 @@ func init() {
 	Config.Render.VSync = true
 	Config.Game.MouseSensitivity = 2000
 	Config.Game.UIScale = "auto"
+	Config.Sound.Volumes = map[string]float64{}
+	for _, c := range audio.Categories {
+		Config.Sound.Volumes[string(c)] = 1.0
+	}
 
 	f, err := os.Open("config.json")
 	if err != nil {
@@ -0,0 +1,578 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steven
+
+import (
+	"log"
+
+	"github.com/thinkofdeath/steven/chat"
+	"github.com/thinkofdeath/steven/protocol"
+)
+
+// Slot id used for clicks outside of the window
+const slotOutside = -999
+
+// Number of slots the player's inventory adds to a window, the
+// main inventory followed by the hotbar
+const (
+	playerWindowSlots = 36
+	playerMainSlots   = 27
+	// Offset of the player's main inventory in the player's own
+	// window
+	invPlayerMainOffset = 9
+)
+
+// Click modes used by ClickWindow
+const (
+	clickNormal byte = iota
+	clickShift
+	clickHotbar
+	clickMiddle
+	clickDrop
+	clickDrag
+	clickCollect
+)
+
+// containerWindow is a window opened by the server, e.g. a chest, or
+// the player's own inventory (window 0). The window's items are the
+// container's own slots followed by the player's inventory.
+type containerWindow struct {
+	id     byte
+	ty     string
+	title  chat.AnyComponent
+	layout *containerLayout
+	// Number of slots that belong to the container
+	size       int
+	items      []*ItemStack
+	properties map[int16]int16
+
+	// Action number to use for the next click
+	nextAction int16
+	// Clicks sent to the server that haven't been confirmed yet
+	pending map[int16]bool
+	// Set when the server rejects a click, no more clicks are
+	// sent until the server resends the window's items
+	desynced bool
+
+	// Button and slots of the current drag, dragButton is -1
+	// when not dragging
+	dragButton int
+	dragSlots  []int
+
+	// Set when the items or properties change and the window
+	// needs to be redrawn
+	dirty bool
+}
+
+func newContainerWindow(p *protocol.WindowOpen) *containerWindow {
+	layout := layoutFor(p.Type, int(p.SlotCount))
+	if layout == nil {
+		return nil
+	}
+	w := &containerWindow{
+		id:         p.ID,
+		ty:         p.Type,
+		title:      p.Title,
+		layout:     layout,
+		size:       len(layout.slots),
+		properties: map[int16]int16{},
+		pending:    map[int16]bool{},
+		dragButton: -1,
+		dirty:      true,
+	}
+	w.items = make([]*ItemStack, w.size+playerWindowSlots)
+	// Copy the player's inventory until the server sends the
+	// window's items
+	inv := Client.playerInventory.Items
+	copy(w.items[w.size:], inv[invPlayerMainOffset:invPlayerMainOffset+playerWindowSlots])
+	return w
+}
+
+// newInventoryWindow returns a window for the player's inventory,
+// unlike other windows this is opened by the client.
+func newInventoryWindow() *containerWindow {
+	w := &containerWindow{
+		title: chat.AnyComponent{Value: &chat.TranslateComponent{
+			Translate: "container.crafting",
+		}},
+		layout:     inventoryLayout,
+		size:       len(inventoryLayout.slots),
+		properties: map[int16]int16{},
+		pending:    map[int16]bool{},
+		dragButton: -1,
+		dirty:      true,
+	}
+	w.items = make([]*ItemStack, w.size+playerWindowSlots)
+	copy(w.items, Client.playerInventory.Items)
+	return w
+}
+
+// openInventory opens the player's inventory.
+func (c *ClientState) openInventory() {
+	if c.currentWindow != nil {
+		return
+	}
+	c.currentWindow = newInventoryWindow()
+	setScreen(newContainerScreen(c.currentWindow))
+}
+
+// openWindow opens the window from a WindowOpen packet, replacing
+// any window that is already open.
+func (c *ClientState) openWindow(p *protocol.WindowOpen) {
+	w := newContainerWindow(p)
+	if w == nil {
+		log.Printf("Unsupported window type %q", p.Type)
+		// Let the server know that the window isn't open
+		c.network.Write(&protocol.CloseWindow{ID: p.ID})
+		return
+	}
+	c.currentWindow = w
+	setScreen(newContainerScreen(w))
+}
+
+// closeWindow closes the open window, the server is told about it
+// unless it was the server that closed it.
+func (c *ClientState) closeWindow(notify bool) {
+	w := c.currentWindow
+	if w == nil {
+		return
+	}
+	c.forgetWindow(notify)
+	if cs, ok := currentScreen.(*containerScreen); ok && cs.win == w {
+		setScreen(nil)
+	}
+}
+
+// forgetWindow clears the open window without touching the current
+// screen.
+func (c *ClientState) forgetWindow(notify bool) {
+	if notify {
+		c.network.Write(&protocol.CloseWindow{ID: c.currentWindow.id})
+	}
+	c.currentWindow = nil
+	// The server drops or returns the held item
+	c.cursorItem = nil
+}
+
+// windowByID returns the open window with the id, nil if it isn't
+// open.
+func (c *ClientState) windowByID(id byte) *containerWindow {
+	if c.currentWindow != nil && c.currentWindow.id == id {
+		return c.currentWindow
+	}
+	return nil
+}
+
+// setItems replaces all of the window's items.
+func (w *containerWindow) setItems(items []protocol.ItemStack) {
+	for i, it := range items {
+		if i >= len(w.items) {
+			break
+		}
+		w.setSlot(i, ItemStackFromProtocol(it))
+	}
+	w.desynced = false
+	Client.playerInventory.Update()
+}
+
+// setSlot changes the item in the slot, slots that are part of the
+// player's inventory are also changed in the player's inventory.
+func (w *containerWindow) setSlot(slot int, item *ItemStack) {
+	if slot < 0 || slot >= len(w.items) {
+		return
+	}
+	w.items[slot] = item
+	w.dirty = true
+	if w.id == 0 {
+		// The player's own window is their inventory
+		Client.playerInventory.Items[slot] = item
+	} else if slot >= w.size {
+		Client.playerInventory.Items[slot-w.size+invPlayerMainOffset] = item
+	}
+}
+
+func (w *containerWindow) setProperty(property, value int16) {
+	w.properties[property] = value
+	w.dirty = true
+}
+
+// confirm handles the server's response to a click. Rejected clicks
+// are acknowledged by the handler after which the server will resend
+// the window's contents.
+func (w *containerWindow) confirm(p *protocol.ConfirmTransaction) {
+	if !w.pending[p.ActionNumber] {
+		// Not a click we are waiting on, either it was made before
+		// the last rejection or the action number is bogus
+		return
+	}
+	delete(w.pending, p.ActionNumber)
+	if p.Accepted {
+		return
+	}
+	// The server rejects every click after this one until the
+	// window is resynced so there is no point waiting on them
+	w.desynced = true
+	w.pending = map[int16]bool{}
+}
+
+// send sends a click to the server. The item is the contents of the
+// slot before the click.
+func (w *containerWindow) send(slot int, button, mode byte, item *ItemStack) {
+	action := w.nextAction
+	w.nextAction++
+	w.pending[action] = true
+	Client.network.Write(&protocol.ClickWindow{
+		ID:           w.id,
+		Slot:         int16(slot),
+		Button:       button,
+		ActionNumber: action,
+		Mode:         mode,
+		ClickedItem:  protocolItemStack(item),
+	})
+}
+
+// canClick returns whether clicks can currently be made.
+func (w *containerWindow) canClick() bool {
+	return !w.desynced
+}
+
+// canPlace returns whether the item can be put into the slot.
+func (w *containerWindow) canPlace(slot int, item *ItemStack) bool {
+	if slot >= w.size {
+		return true
+	}
+	if w.layout.isOutput(slot) {
+		return false
+	}
+	if a := w.layout.armorType(slot); a != -1 {
+		return item != nil && item.armorType() == a
+	}
+	return true
+}
+
+// click handles a normal left (button 0) or right (button 1) click
+// on a slot.
+func (w *containerWindow) click(slot int, button byte) {
+	if !w.canClick() {
+		return
+	}
+	var before *ItemStack
+	if slot != slotOutside {
+		before = w.items[slot]
+	}
+	w.send(slot, button, clickNormal, before)
+	defer w.changed()
+
+	cursor := Client.cursorItem
+	if slot == slotOutside {
+		// Drops the held item
+		if cursor != nil {
+			if button == 0 {
+				Client.cursorItem = nil
+			} else {
+				Client.cursorItem = cursor.withCount(cursor.Count - 1)
+			}
+		}
+		return
+	}
+	item := w.items[slot]
+	switch {
+	case cursor == nil:
+		if item == nil {
+			return
+		}
+		take := item.Count
+		if button == 1 {
+			take = (item.Count + 1) / 2
+		}
+		Client.cursorItem = item.withCount(take)
+		w.setSlot(slot, item.withCount(item.Count-take))
+	case !w.canPlace(slot, cursor):
+		// Output slots can only be taken from and only if the
+		// whole stack fits on the cursor
+		if w.layout.isOutput(slot) && item != nil && item.stacksWith(cursor) && cursor.Count+item.Count <= cursor.maxStackSize() {
+			Client.cursorItem = cursor.withCount(cursor.Count + item.Count)
+			w.setSlot(slot, nil)
+		}
+	case item == nil || item.stacksWith(cursor):
+		count := 0
+		if item != nil {
+			count = item.Count
+		}
+		place := cursor.Count
+		if button == 1 {
+			place = 1
+		}
+		if space := cursor.maxStackSize() - count; place > space {
+			place = space
+		}
+		w.setSlot(slot, cursor.withCount(count+place))
+		Client.cursorItem = cursor.withCount(cursor.Count - place)
+	default:
+		// Swap the held item with the slot's item
+		w.setSlot(slot, cursor)
+		Client.cursorItem = item
+	}
+}
+
+// shiftClick moves the slot's item between the container and the
+// player's inventory.
+func (w *containerWindow) shiftClick(slot int, button byte) {
+	if !w.canClick() || slot < 0 {
+		return
+	}
+	item := w.items[slot]
+	w.send(slot, button, clickShift, item)
+	if item == nil {
+		return
+	}
+	defer w.changed()
+
+	var remaining *ItemStack
+	playerStart, hotbarStart := w.size, w.size+playerMainSlots
+	switch {
+	case slot < w.size:
+		// Into the player's inventory starting from the end
+		// of the hotbar
+		remaining = w.merge(item, playerStart, len(w.items), true)
+	case w.layout.storage:
+		remaining = w.merge(item, 0, w.size, false)
+	default:
+		// Armor is put on before anything else
+		if a := item.armorType(); a != -1 && a < len(w.layout.armor) {
+			s := w.layout.armor[a]
+			if item = w.merge(item, s, s+1, false); item == nil {
+				break
+			}
+		}
+		if slot < hotbarStart {
+			remaining = w.merge(item, hotbarStart, len(w.items), false)
+		} else {
+			remaining = w.merge(item, playerStart, hotbarStart, false)
+		}
+	}
+	w.setSlot(slot, remaining)
+}
+
+// merge moves as much of the item as possible into the slots in the
+// range, first onto matching stacks and then into empty slots.
+// Returns the remaining item, nil if it was all moved.
+func (w *containerWindow) merge(item *ItemStack, start, end int, reverse bool) *ItemStack {
+	count := item.Count
+	max := item.maxStackSize()
+	each := func(f func(slot int)) {
+		for i := start; i < end; i++ {
+			slot := i
+			if reverse {
+				slot = end - 1 - (i - start)
+			}
+			if count > 0 && w.canPlace(slot, item) {
+				f(slot)
+			}
+		}
+	}
+	if max > 1 {
+		each(func(slot int) {
+			other := w.items[slot]
+			if !other.stacksWith(item) || other.Count >= max {
+				return
+			}
+			n := minInt(count, max-other.Count)
+			w.setSlot(slot, other.withCount(other.Count+n))
+			count -= n
+		})
+	}
+	each(func(slot int) {
+		if w.items[slot] != nil {
+			return
+		}
+		n := minInt(count, max)
+		w.setSlot(slot, item.withCount(n))
+		count -= n
+	})
+	return item.withCount(count)
+}
+
+// swapHotbar swaps the slot's item with the item in the hotbar slot
+// (0-8).
+func (w *containerWindow) swapHotbar(slot, hotbar int) {
+	if !w.canClick() || slot < 0 {
+		return
+	}
+	target := w.size + playerMainSlots + hotbar
+	item := w.items[slot]
+	w.send(slot, byte(hotbar), clickHotbar, item)
+	if target == slot || w.items[target] != nil && !w.canPlace(slot, w.items[target]) {
+		return
+	}
+	defer w.changed()
+	w.setSlot(slot, w.items[target])
+	w.setSlot(target, item)
+}
+
+// middleClick copies the slot's item as a full stack onto the cursor,
+// this only works in creative mode.
+func (w *containerWindow) middleClick(slot int) {
+	if !w.canClick() || slot < 0 {
+		return
+	}
+	item := w.items[slot]
+	w.send(slot, 2, clickMiddle, item)
+	if Client.GameMode != gmCreative || Client.cursorItem != nil || item == nil {
+		return
+	}
+	Client.cursorItem = item.withCount(item.maxStackSize())
+	w.changed()
+}
+
+// drop throws one (or all) of the slot's item out of the window.
+func (w *containerWindow) drop(slot int, all bool) {
+	if !w.canClick() || slot < 0 || Client.cursorItem != nil {
+		return
+	}
+	item := w.items[slot]
+	var button byte
+	if all {
+		button = 1
+	}
+	w.send(slot, button, clickDrop, item)
+	if item == nil {
+		return
+	}
+	if all {
+		w.setSlot(slot, nil)
+	} else {
+		w.setSlot(slot, item.withCount(item.Count-1))
+	}
+	w.changed()
+}
+
+// startDrag starts spreading the held item over slots. Button is 0
+// to split the item evenly and 1 to place one item in each slot.
+func (w *containerWindow) startDrag(button int) {
+	if !w.canClick() || Client.cursorItem == nil {
+		return
+	}
+	w.dragButton = button
+	w.dragSlots = w.dragSlots[:0]
+}
+
+// dragOver adds the slot to the current drag.
+func (w *containerWindow) dragOver(slot int) {
+	if w.dragButton == -1 || slot < 0 || !w.canPlace(slot, Client.cursorItem) {
+		return
+	}
+	for _, s := range w.dragSlots {
+		if s == slot {
+			return
+		}
+	}
+	cursor := Client.cursorItem
+	if item := w.items[slot]; item != nil && !item.stacksWith(cursor) {
+		return
+	}
+	// Each slot needs at least one item
+	if len(w.dragSlots) >= cursor.Count {
+		return
+	}
+	w.dragSlots = append(w.dragSlots, slot)
+}
+
+// endDrag finishes the current drag, returns false if the drag
+// didn't cover enough slots and should be treated as a normal
+// click instead.
+func (w *containerWindow) endDrag() bool {
+	button := w.dragButton
+	w.dragButton = -1
+	if button == -1 || len(w.dragSlots) < 2 || !w.canClick() {
+		return false
+	}
+	defer w.changed()
+
+	// Start, add and end use buttons 0, 1 and 2 for splitting
+	// evenly and 4, 5 and 6 for one each
+	base := byte(button * 4)
+	w.send(slotOutside, base, clickDrag, nil)
+	for _, slot := range w.dragSlots {
+		w.send(slot, base+1, clickDrag, nil)
+	}
+	w.send(slotOutside, base+2, clickDrag, nil)
+
+	cursor := Client.cursorItem
+	per := 1
+	if button == 0 {
+		per = cursor.Count / len(w.dragSlots)
+	}
+	count := cursor.Count
+	max := cursor.maxStackSize()
+	for _, slot := range w.dragSlots {
+		current := 0
+		if item := w.items[slot]; item != nil {
+			current = item.Count
+		}
+		n := minInt(per, max-current)
+		if n <= 0 {
+			continue
+		}
+		w.setSlot(slot, cursor.withCount(current+n))
+		count -= n
+	}
+	Client.cursorItem = cursor.withCount(count)
+	return true
+}
+
+// collect gathers items matching the held item from the window onto
+// the cursor, used when double clicking.
+func (w *containerWindow) collect(slot int) {
+	if !w.canClick() || slot < 0 {
+		return
+	}
+	w.send(slot, 0, clickCollect, w.items[slot])
+	cursor := Client.cursorItem
+	if cursor == nil {
+		return
+	}
+	defer w.changed()
+	count, max := cursor.Count, cursor.maxStackSize()
+	// Partial stacks are taken before full ones
+	for pass := 0; pass < 2; pass++ {
+		for i, item := range w.items {
+			if count >= max || w.layout.isOutput(i) || !item.stacksWith(cursor) {
+				continue
+			}
+			if pass == 0 && item.Count >= max {
+				continue
+			}
+			n := minInt(item.Count, max-count)
+			w.setSlot(i, item.withCount(item.Count-n))
+			count += n
+		}
+	}
+	Client.cursorItem = cursor.withCount(count)
+}
+
+// changed marks the window and the player's inventory as changed
+// after a click.
+func (w *containerWindow) changed() {
+	w.dirty = true
+	Client.playerInventory.Update()
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,198 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steven
+
+// slotPosition is the top left corner of a slot in the texture of
+// the window in texture pixels.
+type slotPosition struct {
+	x, y float64
+}
+
+// containerLayout describes how a type of window is drawn and where
+// its slots are. The player's inventory always follows the slots of
+// the container.
+type containerLayout struct {
+	texture string
+	// Size of the window in texture pixels
+	width, height float64
+	// Height of the top part of the texture to draw, the bottom
+	// 96 pixels of the texture (the player's inventory) is drawn
+	// below it. Zero draws the texture as a single piece.
+	split float64
+
+	slots []slotPosition
+	// Slots that items can be taken from but not placed into,
+	// e.g. the result of crafting
+	outputs []int
+	// Storage windows (e.g. chests) allow items to be moved
+	// into any of their slots by shift clicking
+	storage bool
+	// Slots that only accept armor, from the helmet down to the
+	// boots
+	armor []int
+
+	// Position of the top left slot of the player's inventory,
+	// the hotbar is drawn 58 pixels below it
+	playerX, playerY float64
+}
+
+// isOutput returns whether the container slot can only be taken
+// from.
+func (l *containerLayout) isOutput(slot int) bool {
+	for _, o := range l.outputs {
+		if o == slot {
+			return true
+		}
+	}
+	return false
+}
+
+// armorType returns the piece of armor (0 for helmets to 3 for
+// boots) the slot accepts, -1 if it isn't an armor slot.
+func (l *containerLayout) armorType(slot int) int {
+	for i, a := range l.armor {
+		if a == slot {
+			return i
+		}
+	}
+	return -1
+}
+
+// inventoryLayout is the layout of the player's inventory, window 0.
+// The crafting result and grid are followed by the armor slots.
+var inventoryLayout = &containerLayout{
+	texture: "gui/container/inventory",
+	width:   176, height: 166,
+	slots: append(append([]slotPosition{{154, 28}}, grid(98, 18, 2, 2)...),
+		grid(8, 8, 1, 4)...),
+	outputs: []int{0},
+	armor:   []int{5, 6, 7, 8},
+	playerX: 8, playerY: 84,
+}
+
+// grid returns the positions of a grid of slots starting at x, y.
+func grid(x, y float64, columns, rows int) []slotPosition {
+	var out []slotPosition
+	for r := 0; r < rows; r++ {
+		for c := 0; c < columns; c++ {
+			out = append(out, slotPosition{x + float64(c)*18, y + float64(r)*18})
+		}
+	}
+	return out
+}
+
+// layoutFor returns the layout of the window type with the number
+// of slots the server sent. Returns nil for unknown types.
+func layoutFor(ty string, slotCount int) *containerLayout {
+	switch ty {
+	case "minecraft:chest", "minecraft:container":
+		rows := slotCount / 9
+		if rows < 1 || rows > 6 {
+			return nil
+		}
+		return &containerLayout{
+			texture: "gui/container/generic_54",
+			width:   176, height: 114 + float64(rows)*18,
+			split:   17 + float64(rows)*18,
+			slots:   grid(8, 18, 9, rows),
+			storage: true,
+			playerX: 8, playerY: 31 + float64(rows)*18,
+		}
+	case "minecraft:dispenser", "minecraft:dropper":
+		return &containerLayout{
+			texture: "gui/container/dispenser",
+			width:   176, height: 166,
+			slots:   grid(62, 17, 3, 3),
+			storage: true,
+			playerX: 8, playerY: 84,
+		}
+	case "minecraft:hopper":
+		return &containerLayout{
+			texture: "gui/container/hopper",
+			width:   176, height: 133,
+			slots:   grid(44, 20, 5, 1),
+			storage: true,
+			playerX: 8, playerY: 51,
+		}
+	case "minecraft:furnace":
+		return &containerLayout{
+			texture: "gui/container/furnace",
+			width:   176, height: 166,
+			slots:   []slotPosition{{56, 17}, {56, 53}, {116, 35}},
+			outputs: []int{2},
+			playerX: 8, playerY: 84,
+		}
+	case "minecraft:crafting_table":
+		return &containerLayout{
+			texture: "gui/container/crafting_table",
+			width:   176, height: 166,
+			slots:   append([]slotPosition{{124, 35}}, grid(30, 17, 3, 3)...),
+			outputs: []int{0},
+			playerX: 8, playerY: 84,
+		}
+	case "minecraft:enchanting_table":
+		return &containerLayout{
+			texture: "gui/container/enchanting_table",
+			width:   176, height: 166,
+			slots:   []slotPosition{{15, 47}, {35, 47}},
+			playerX: 8, playerY: 84,
+		}
+	case "minecraft:brewing_stand":
+		return &containerLayout{
+			texture: "gui/container/brewing_stand",
+			width:   176, height: 166,
+			slots:   []slotPosition{{56, 46}, {79, 53}, {102, 46}, {79, 17}},
+			playerX: 8, playerY: 84,
+		}
+	case "minecraft:anvil":
+		return &containerLayout{
+			texture: "gui/container/anvil",
+			width:   176, height: 166,
+			slots:   []slotPosition{{27, 47}, {76, 47}, {134, 47}},
+			outputs: []int{2},
+			playerX: 8, playerY: 84,
+		}
+	case "minecraft:beacon":
+		return &containerLayout{
+			texture: "gui/container/beacon",
+			width:   230, height: 219,
+			slots:   []slotPosition{{136, 110}},
+			playerX: 36, playerY: 137,
+		}
+	case "minecraft:villager":
+		return &containerLayout{
+			texture: "gui/container/villager",
+			width:   176, height: 166,
+			slots:   []slotPosition{{36, 53}, {62, 53}, {120, 53}},
+			outputs: []int{2},
+			playerX: 8, playerY: 84,
+		}
+	case "EntityHorse":
+		// The saddle and armor slots followed by the chest of
+		// donkeys and mules
+		slots := []slotPosition{{8, 18}, {8, 36}}
+		if slotCount > 2 {
+			slots = append(slots, grid(80, 18, 5, (slotCount-2)/5)...)
+		}
+		return &containerLayout{
+			texture: "gui/container/horse",
+			width:   176, height: 166,
+			slots:   slots,
+			storage: slotCount > 2,
+			playerX: 8, playerY: 84,
+		}
+	}
+	return nil
+}
@@ -82,6 +82,10 @@ func startWindow() {
 		window.SwapBuffers()
 		glfw.PollEvents()
 	}
+	// Make sure the cached chunks are written before exiting
+	if Client.chunkCache != nil {
+		<-Client.chunkCache.close()
+	}
 }
 
 func onScroll(w *glfw.Window, xoff float64, yoff float64) {
@@ -133,13 +137,21 @@ func onMouseMove(w *glfw.Window, xpos float64, ypos float64) {
 
 func onMouseClick(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mod glfw.ModifierKey) {
 	if currentScreen != nil {
-		if button != glfw.MouseButtonLeft || action == glfw.Repeat {
+		if action == glfw.Repeat {
 			return
 		}
 		width, height := w.GetSize()
 		xpos, ypos := w.GetCursorPos()
 		fw, fh := w.GetFramebufferSize()
-		currentScreen.click(action == glfw.Press, xpos*(float64(fw)/float64(width)), ypos*(float64(fh)/float64(height)), fw, fh)
+		x, y := xpos*(float64(fw)/float64(width)), ypos*(float64(fh)/float64(height))
+		if ms, ok := currentScreen.(mouseScreen); ok {
+			ms.mouseClick(button, action == glfw.Press, mod, x, y, fw, fh)
+			return
+		}
+		if button != glfw.MouseButtonLeft {
+			return
+		}
+		currentScreen.click(action == glfw.Press, x, y, fw, fh)
 		return
 	}
 	if !Client.chat.enteringText && lockMouse && action != glfw.Repeat {
@@ -160,6 +172,7 @@ const (
 	KeyRight
 	KeySprint
 	KeyJump
+	KeySneak
 )
 
 var keyStateMap = map[glfw.Key]Key{
@@ -169,6 +182,7 @@ var keyStateMap = map[glfw.Key]Key{
 	glfw.KeyD:           KeyRight,
 	glfw.KeyLeftControl: KeySprint,
 	glfw.KeySpace:       KeyJump,
+	glfw.KeyLeftShift:   KeySneak,
 }
 
 func onKey(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
@@ -188,6 +202,10 @@ func onKey(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods
 		if action == glfw.Release {
 			setScreen(newGameMenu())
 		}
+	case glfw.KeyE:
+		if action == glfw.Release {
+			Client.openInventory()
+		}
 	case glfw.KeyF1:
 		if action == glfw.Release {
 			if Client.scene.IsVisible() {
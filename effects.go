@@ -0,0 +1,427 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steven
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/thinkofdeath/steven/chat"
+	"github.com/thinkofdeath/steven/entitysys"
+	"github.com/thinkofdeath/steven/protocol"
+	"github.com/thinkofdeath/steven/render"
+	"github.com/thinkofdeath/steven/ui"
+	"github.com/thinkofdeath/steven/ui/scene"
+)
+
+func init() {
+	addSystem(entitysys.Tick, esEffectsTick)
+	addSystem(entitysys.Tick, esEffectParticles)
+}
+
+// effectType is the id of a status effect.
+type effectType int
+
+const (
+	effectSpeed effectType = iota + 1
+	effectSlowness
+	effectHaste
+	effectMiningFatigue
+	effectStrength
+	effectInstantHealth
+	effectInstantDamage
+	effectJumpBoost
+	effectNausea
+	effectRegeneration
+	effectResistance
+	effectFireResistance
+	effectWaterBreathing
+	effectInvisibility
+	effectBlindness
+	effectNightVision
+	effectHunger
+	effectWeakness
+	effectPoison
+	effectWither
+	effectHealthBoost
+	effectAbsorption
+	effectSaturation
+)
+
+// effectInfo describes how a status effect is displayed.
+type effectInfo struct {
+	key string
+	// The index of the effect's icon in the inventory texture,
+	// -1 for effects without one
+	icon  int
+	color uint32
+}
+
+var effectInfos = map[effectType]effectInfo{
+	effectSpeed:          {"potion.moveSpeed", 0, 0x7CAFC6},
+	effectSlowness:       {"potion.moveSlowdown", 1, 0x5A6C81},
+	effectHaste:          {"potion.digSpeed", 2, 0xD9C043},
+	effectMiningFatigue:  {"potion.digSlowDown", 3, 0x4A4217},
+	effectStrength:       {"potion.damageBoost", 4, 0x932423},
+	effectInstantHealth:  {"potion.heal", -1, 0xF82423},
+	effectInstantDamage:  {"potion.harm", -1, 0x430A09},
+	effectJumpBoost:      {"potion.jump", 10, 0x22FF4C},
+	effectNausea:         {"potion.confusion", 11, 0x551D4A},
+	effectRegeneration:   {"potion.regeneration", 7, 0xCD5CAB},
+	effectResistance:     {"potion.resistance", 14, 0x99453A},
+	effectFireResistance: {"potion.fireResistance", 15, 0xE49A3A},
+	effectWaterBreathing: {"potion.waterBreathing", 16, 0x2E5299},
+	effectInvisibility:   {"potion.invisibility", 8, 0x7F8392},
+	effectBlindness:      {"potion.blindness", 13, 0x1F1F23},
+	effectNightVision:    {"potion.nightVision", 12, 0x1F1FA1},
+	effectHunger:         {"potion.hunger", 9, 0x587653},
+	effectWeakness:       {"potion.weakness", 5, 0x484D48},
+	effectPoison:         {"potion.poison", 6, 0x4E9331},
+	effectWither:         {"potion.wither", 17, 0x352A27},
+	effectHealthBoost:    {"potion.healthBoost", 23, 0xF87D23},
+	effectAbsorption:     {"potion.absorption", 18, 0x2552A5},
+	effectSaturation:     {"potion.saturation", -1, 0xF82423},
+}
+
+// The duration the server uses for effects that never run out.
+const effectMaxDuration = 32767
+
+// statusEffect is a potion effect active on an entity.
+type statusEffect struct {
+	Type      effectType
+	Amplifier int
+	// Remaining time in ticks
+	Duration      float64
+	HideParticles bool
+}
+
+// Keys of the attributes used by the client
+const (
+	attrMovementSpeed = "generic.movementSpeed"
+)
+
+// The modifier the server adds to the movement speed of sprinting
+// players. The client handles sprinting itself so this is ignored.
+var sprintSpeedModifier = protocol.UUID{
+	0x66, 0x2a, 0x6b, 0x8d, 0xda, 0x3e, 0x4c, 0x1c,
+	0x88, 0x13, 0x96, 0xea, 0x60, 0x97, 0x27, 0x8d,
+}
+
+// attribute is a value of an entity, such as its movement speed,
+// changed by a set of modifiers.
+type attribute struct {
+	base      float64
+	modifiers []protocol.PropertyModifier
+}
+
+// value applies the modifiers to the base value in the same order
+// as the server.
+func (a *attribute) value() float64 {
+	val := a.base
+	for _, m := range a.modifiers {
+		if m.Operation == 0 && m.UUID != sprintSpeedModifier {
+			val += m.Amount
+		}
+	}
+	out := val
+	for _, m := range a.modifiers {
+		if m.Operation == 1 && m.UUID != sprintSpeedModifier {
+			out += val * m.Amount
+		}
+	}
+	for _, m := range a.modifiers {
+		if m.Operation == 2 && m.UUID != sprintSpeedModifier {
+			out *= 1 + m.Amount
+		}
+	}
+	return out
+}
+
+// Effects
+
+type effectsComponent struct {
+	effects    map[effectType]*statusEffect
+	attributes map[string]*attribute
+}
+
+func (e *effectsComponent) AddEffect(ef statusEffect) {
+	if e.effects == nil {
+		e.effects = map[effectType]*statusEffect{}
+	}
+	e.effects[ef.Type] = &ef
+}
+
+func (e *effectsComponent) RemoveEffect(t effectType) {
+	delete(e.effects, t)
+}
+
+// Effect returns the effect of the type, nil if it isn't active.
+func (e *effectsComponent) Effect(t effectType) *statusEffect {
+	return e.effects[t]
+}
+
+func (e *effectsComponent) SetAttribute(key string, base float64, modifiers []protocol.PropertyModifier) {
+	if e.attributes == nil {
+		e.attributes = map[string]*attribute{}
+	}
+	e.attributes[key] = &attribute{base: base, modifiers: modifiers}
+}
+
+// Attribute returns the value of the attribute or def if the server
+// hasn't sent it.
+func (e *effectsComponent) Attribute(key string, def float64) float64 {
+	a, ok := e.attributes[key]
+	if !ok {
+		return def
+	}
+	return a.value()
+}
+
+// sortedEffects returns the active effects ordered by their type.
+func (e *effectsComponent) sortedEffects() []*statusEffect {
+	out := make([]*statusEffect, 0, len(e.effects))
+	for _, ef := range e.effects {
+		out = append(out, ef)
+	}
+	sort.Sort(effectsByType(out))
+	return out
+}
+
+// tick counts down the effects by delta (in 60ths of a second) and
+// removes the ones that have run out.
+func (e *effectsComponent) tick(delta float64) {
+	for t, ef := range e.effects {
+		if ef.Duration >= effectMaxDuration {
+			continue
+		}
+		ef.Duration -= delta / 3
+		if ef.Duration <= 0 {
+			delete(e.effects, t)
+		}
+	}
+}
+
+type EffectsComponent interface {
+	AddEffect(ef statusEffect)
+	RemoveEffect(t effectType)
+	Effect(t effectType) *statusEffect
+	SetAttribute(key string, base float64, modifiers []protocol.PropertyModifier)
+	Attribute(key string, def float64) float64
+}
+
+type effectsByType []*statusEffect
+
+func (e effectsByType) Len() int           { return len(e) }
+func (e effectsByType) Less(i, j int) bool { return e[i].Type < e[j].Type }
+func (e effectsByType) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+
+func esEffectsTick(e *effectsComponent) {
+	e.tick(Client.delta)
+}
+
+// Spawns swirls around the entity in the mixed color of its
+// effects.
+func esEffectParticles(e *effectsComponent, m *metadataComponent, p PositionComponent, s SizeComponent) {
+	var r, g, b, count float64
+	for _, ef := range e.effects {
+		if ef.HideParticles {
+			continue
+		}
+		c := effectInfos[ef.Type].color
+		weight := float64(ef.Amplifier + 1)
+		r += float64((c>>16)&0xFF) / 255 * weight
+		g += float64((c>>8)&0xFF) / 255 * weight
+		b += float64(c&0xFF) / 255 * weight
+		count += weight
+	}
+	if count == 0 {
+		return
+	}
+	// Invisible entities only give off the odd particle,
+	// others spawn one every other tick
+	chance := 0.5
+	if m.meta.Flags.Invisible() {
+		chance = 1 / 15.0
+	}
+	if rand.Float64() > chance*Client.delta/3 {
+		return
+	}
+	x, y, z := p.Position()
+	bounds := s.Bounds()
+	size := bounds.Max.Sub(bounds.Min)
+	spawnParticle(particleMobSpell,
+		x+float64(bounds.Min.X()+size.X()*rand.Float32()),
+		y+float64(bounds.Min.Y()+size.Y()*rand.Float32()),
+		z+float64(bounds.Min.Z()+size.Z()*rand.Float32()),
+		r/count, g/count, b/count, nil,
+	)
+}
+
+// Client
+
+// movementSpeed returns how fast the player walks compared to
+// normal.
+func (c *ClientState) movementSpeed() float64 {
+	// The player's base speed
+	const walkSpeed = 0.1
+	return c.effects.Attribute(attrMovementSpeed, walkSpeed) / walkSpeed
+}
+
+// jumpBoost returns the extra vertical speed (per a tick) the
+// player jumps with.
+func (c *ClientState) jumpBoost() float64 {
+	ef := c.effects.Effect(effectJumpBoost)
+	if ef == nil {
+		return 0
+	}
+	return float64(ef.Amplifier+1) * 0.1
+}
+
+// updateEffects applies the player's effects to the renderer.
+func (c *ClientState) updateEffects(delta float64) {
+	c.effects.tick(delta)
+
+	// Matches the zoom the vanilla client applies when the
+	// player's speed changes
+	fov := 1.0
+	if c.GameMode.Fly() {
+		fov *= 1.1
+	}
+	speed := c.movementSpeed()
+	if c.physics.sprinting {
+		speed *= 1.3
+	}
+	fov *= (speed + 1) / 2
+	if math.IsNaN(fov) || math.IsInf(fov, 0) {
+		fov = 1
+	}
+	if c.fovModifier == 0 {
+		c.fovModifier = 1
+	}
+	c.fovModifier += (fov - c.fovModifier) * (1 - math.Pow(0.5, delta/3))
+	// Same limits as vanilla
+	c.fovModifier = math.Max(0.1, math.Min(1.5, c.fovModifier))
+	// The projection breaks down at 180 degrees
+	render.FOV = int(math.Min(179, float64(Config.Render.FOV)*c.fovModifier))
+
+	render.NightVision = 0
+	if ef := c.effects.Effect(effectNightVision); ef != nil {
+		render.NightVision = 1
+		// Flickers as it runs out
+		if ef.Duration <= 200 {
+			render.NightVision = float32(0.7 + math.Sin(ef.Duration*math.Pi*0.2)*0.3)
+		}
+	}
+	render.Blindness = 0
+	if ef := c.effects.Effect(effectBlindness); ef != nil {
+		render.Blindness = float32(math.Min(1, ef.Duration/20))
+	}
+}
+
+// effectsUI lists the player's active effects in the top right
+// corner of the screen.
+type effectsUI struct {
+	scene   *scene.Type
+	entries []*effectsUIEntry
+}
+
+type effectsUIEntry struct {
+	icon *ui.Image
+	name *ui.Formatted
+	time *ui.Text
+
+	ty        effectType
+	amplifier int
+}
+
+func (e *effectsUIEntry) set(draw bool) {
+	e.icon.SetDraw(draw)
+	e.name.SetDraw(draw)
+	e.time.SetDraw(draw)
+}
+
+func (e *effectsUI) init() {
+	e.scene = scene.New(true)
+}
+
+func (e *effectsUI) free() {
+	e.scene.Hide()
+	render.NightVision = 0
+	render.Blindness = 0
+}
+
+func (e *effectsUI) render(delta float64) {
+	for _, en := range e.entries {
+		en.set(false)
+	}
+	for i, ef := range Client.effects.sortedEffects() {
+		if i >= len(e.entries) {
+			y := 4 + 40*float64(i)
+			icon := ui.NewImage(render.GetTexture("gui/container/inventory"), 4, y, 36, 36, 0, 198/256.0, 18/256.0, 18/256.0, 255, 255, 255).
+				Attach(ui.Top, ui.Right)
+			e.scene.AddDrawable(icon)
+			name := ui.NewFormatted(chat.AnyComponent{Value: &chat.TextComponent{}}, 44, y).
+				Attach(ui.Top, ui.Right)
+			e.scene.AddDrawable(name)
+			time := ui.NewText("", 44, y+18, 127, 127, 127).
+				Attach(ui.Top, ui.Right)
+			e.scene.AddDrawable(time)
+			e.entries = append(e.entries, &effectsUIEntry{
+				icon: icon,
+				name: name,
+				time: time,
+				ty:   -1,
+			})
+		}
+		en := e.entries[i]
+		en.set(true)
+		info := effectInfos[ef.Type]
+		if en.ty != ef.Type || en.amplifier != ef.Amplifier {
+			en.ty, en.amplifier = ef.Type, ef.Amplifier
+			en.name.Update(effectName(ef, info))
+			if info.icon >= 0 {
+				en.icon.SetTextureX(float64(info.icon%8*18) / 256)
+				en.icon.SetTextureY(float64(198+info.icon/8*18) / 256)
+			}
+		}
+		en.icon.SetDraw(info.icon >= 0)
+		en.time.Update(effectDuration(ef))
+	}
+}
+
+// effectName returns the translated name of the effect including
+// its level.
+func effectName(ef *statusEffect, info effectInfo) chat.AnyComponent {
+	name := &chat.TranslateComponent{Translate: info.key}
+	if ef.Amplifier > 0 && ef.Amplifier < 10 {
+		name.Extra = []chat.AnyComponent{
+			{Value: &chat.TextComponent{Text: " "}},
+			{Value: &chat.TranslateComponent{Translate: fmt.Sprintf("enchantment.level.%d", ef.Amplifier+1)}},
+		}
+	}
+	return chat.AnyComponent{Value: name}
+}
+
+// effectDuration formats the time left on the effect as minutes and
+// seconds.
+func effectDuration(ef *statusEffect) string {
+	if ef.Duration >= effectMaxDuration {
+		return "**:**"
+	}
+	secs := int(ef.Duration / 20)
+	return fmt.Sprintf("%d:%02d", secs/60, secs%60)
+}
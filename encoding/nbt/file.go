@@ -0,0 +1,155 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbt
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Compression is the method used to compress an NBT file.
+type Compression int
+
+// Compression methods used by vanilla's files. level.dat and player
+// files are gzipped, servers.dat is uncompressed.
+const (
+	Uncompressed Compression = iota
+	Gzip
+	Zlib
+)
+
+func (c Compression) String() string {
+	switch c {
+	case Uncompressed:
+		return "uncompressed"
+	case Gzip:
+		return "gzip"
+	case Zlib:
+		return "zlib"
+	}
+	return fmt.Sprintf("Compression(%d)", int(c))
+}
+
+// Read reads a root compound (including its type id and name) from
+// the reader. The compression used is detected from the start of the
+// stream and returned with the compound.
+func Read(r io.Reader) (*Compound, Compression, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err != nil {
+		return nil, Uncompressed, err
+	}
+	var cr io.Reader = br
+	compression := Uncompressed
+	switch {
+	case header[0] == 0x1F && header[1] == 0x8B:
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, Gzip, err
+		}
+		defer gr.Close()
+		cr, compression = bufio.NewReader(gr), Gzip
+	// The low nibble of a zlib header is always 8 (deflate) and the
+	// header is a multiple of 31. An uncompressed root always starts
+	// with 0x0A which can't match.
+	case header[0]&0x0F == 8 && (int(header[0])<<8|int(header[1]))%31 == 0:
+		zr, err := zlib.NewReader(br)
+		if err != nil {
+			return nil, Zlib, err
+		}
+		defer zr.Close()
+		cr, compression = bufio.NewReader(zr), Zlib
+	}
+
+	id, err := readByte(cr)
+	if err != nil {
+		return nil, compression, err
+	}
+	if TypeID(id) != TagCompound {
+		return nil, compression, ErrInvalidCompound
+	}
+	c := NewCompound()
+	if err := c.Deserialize(cr); err != nil {
+		return nil, compression, err
+	}
+	return c, compression, nil
+}
+
+// Write writes the compound as a root compound (including its type id
+// and name) to the writer compressing it with the passed method.
+func Write(w io.Writer, c *Compound, compression Compression) error {
+	var cw io.WriteCloser
+	switch compression {
+	case Uncompressed:
+		bw := bufio.NewWriter(w)
+		if err := writeRoot(bw, c); err != nil {
+			return err
+		}
+		return bw.Flush()
+	case Gzip:
+		cw = gzip.NewWriter(w)
+	case Zlib:
+		cw = zlib.NewWriter(w)
+	default:
+		return fmt.Errorf("nbt: unknown compression %s", compression)
+	}
+	if err := writeRoot(cw, c); err != nil {
+		return err
+	}
+	return cw.Close()
+}
+
+func writeRoot(w io.Writer, c *Compound) error {
+	if err := writeByte(w, byte(TagCompound)); err != nil {
+		return err
+	}
+	return c.Serialize(w)
+}
+
+// ReadFile reads the NBT file at the path, detecting the compression
+// used.
+func ReadFile(path string) (*Compound, Compression, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, Uncompressed, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// WriteFile writes the compound to the file at the path compressing
+// it with the passed method. The compound is written to a temporary
+// file first which then replaces the original so that a failed write
+// doesn't corrupt it.
+func WriteFile(path string, c *Compound, compression Compression) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	err = Write(f, c, compression)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,44 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbt
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestReadWrite(t *testing.T) {
+	c, err := ParseSNBT(`{Data:{LevelName:"world",SpawnX:1,SpawnY:64,SpawnZ:-3}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, compression := range []Compression{Uncompressed, Gzip, Zlib} {
+		var buf bytes.Buffer
+		if err := Write(&buf, c, compression); err != nil {
+			t.Fatalf("%s: %s", compression, err)
+		}
+		out, detected, err := Read(&buf)
+		if err != nil {
+			t.Fatalf("%s: %s", compression, err)
+		}
+		if detected != compression {
+			t.Errorf("detected %s, expected %s", detected, compression)
+		}
+		if !reflect.DeepEqual(c, out) {
+			t.Errorf("%s: got %s, expected %s", compression, out, c)
+		}
+	}
+}
@@ -0,0 +1,513 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbt
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+var (
+	compoundType = reflect.TypeOf((*Compound)(nil))
+	listType     = reflect.TypeOf((*List)(nil))
+)
+
+// An UnmarshalTypeError describes a tag that couldn't be stored in
+// a Go value of a specific type.
+type UnmarshalTypeError struct {
+	// The path to the tag, e.g. display.Lore[1]
+	Field string
+	Tag   TypeID
+	// The value of the tag if it overflowed the Go type, otherwise nil
+	Value interface{}
+	Type  reflect.Type
+}
+
+func (e *UnmarshalTypeError) Error() string {
+	if e.Value != nil {
+		return fmt.Sprintf("nbt: %s %v overflows Go value of type %s (%s)", e.Tag, e.Value, e.Type, e.Field)
+	}
+	return fmt.Sprintf("nbt: cannot unmarshal %s into Go value of type %s (%s)", e.Tag, e.Type, e.Field)
+}
+
+// An UnsupportedTypeError is returned by Marshal when trying to encode
+// a value with a type that has no tag equivalent.
+type UnsupportedTypeError struct {
+	Type reflect.Type
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return "nbt: unsupported type: " + e.Type.String()
+}
+
+// A MarshalOverflowError is returned by Marshal when a Go value is
+// too large for the tag its type is encoded as.
+type MarshalOverflowError struct {
+	Value interface{}
+	Tag   TypeID
+}
+
+func (e *MarshalOverflowError) Error() string {
+	return fmt.Sprintf("nbt: %v overflows %s", e.Value, e.Tag)
+}
+
+// Marshal returns a compound containing the encoding of v which
+// must be a struct (or a pointer to one) or a map with string keys.
+//
+// Struct fields are stored using the field name as the key unless
+// the field has an nbt tag which gives the key to use instead. As with
+// encoding/json a key of "-" skips the field and the "omitempty" option
+// skips the field if it has its zero value, e.g.:
+//
+//	Name string `nbt:"name,omitempty"`
+//
+// Go types are encoded as:
+//
+//	bool                  TagByte (0 or 1)
+//	int8, uint8           TagByte
+//	int16, uint16         TagShort
+//	int, int32, uint32    TagInt
+//	int64, uint, uint64   TagLong
+//	float32               TagFloat
+//	float64               TagDouble
+//	[]byte                TagByteArray
+//	string                TagString
+//	[]int32               TagIntArray
+//	other slices/arrays   TagList
+//	structs, maps         TagCompound
+//
+// An int that doesn't fit in a TagInt is an error instead of being
+// truncated. Nil pointers and interfaces are skipped. *Compound and *List values
+// are stored as is.
+func Marshal(v interface{}) (*Compound, error) {
+	val, err := marshalValue(reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	c, ok := val.(*Compound)
+	if !ok {
+		return nil, &UnsupportedTypeError{reflect.TypeOf(v)}
+	}
+	return c, nil
+}
+
+func marshalValue(v reflect.Value) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	switch v.Type() {
+	case compoundType, listType:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return v.Interface(), nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return marshalValue(v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			return int8(1), nil
+		}
+		return int8(0), nil
+	case reflect.Int8:
+		return int8(v.Int()), nil
+	case reflect.Uint8:
+		return int8(v.Uint()), nil
+	case reflect.Int16:
+		return int16(v.Int()), nil
+	case reflect.Uint16:
+		return int16(v.Uint()), nil
+	case reflect.Int, reflect.Int32:
+		i := v.Int()
+		if i < math.MinInt32 || i > math.MaxInt32 {
+			return nil, &MarshalOverflowError{Value: i, Tag: TagInt}
+		}
+		return int32(i), nil
+	case reflect.Uint32:
+		return int32(v.Uint()), nil
+	case reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint64:
+		return int64(v.Uint()), nil
+	case reflect.Float32:
+		return float32(v.Float()), nil
+	case reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Slice, reflect.Array:
+		switch v.Type().Elem().Kind() {
+		case reflect.Uint8:
+			out := make([]byte, v.Len())
+			for i := range out {
+				out[i] = byte(v.Index(i).Uint())
+			}
+			return out, nil
+		case reflect.Int32:
+			out := make([]int32, v.Len())
+			for i := range out {
+				out[i] = int32(v.Index(i).Int())
+			}
+			return out, nil
+		}
+		return marshalList(v)
+	case reflect.Struct:
+		c := NewCompound()
+		for _, f := range structFields(v.Type()) {
+			fv := fieldByIndex(v, f.index, false)
+			if f.omitEmpty && isEmptyValue(fv) {
+				continue
+			}
+			val, err := marshalValue(fv)
+			if err != nil {
+				return nil, err
+			}
+			if val != nil {
+				c.Items[f.name] = val
+			}
+		}
+		return c, nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, &UnsupportedTypeError{v.Type()}
+		}
+		c := NewCompound()
+		for _, k := range v.MapKeys() {
+			val, err := marshalValue(v.MapIndex(k))
+			if err != nil {
+				return nil, err
+			}
+			if val != nil {
+				c.Items[k.String()] = val
+			}
+		}
+		return c, nil
+	}
+	return nil, &UnsupportedTypeError{v.Type()}
+}
+
+func marshalList(v reflect.Value) (*List, error) {
+	l := &List{
+		Type:     TagEnd,
+		Elements: make([]interface{}, 0, v.Len()),
+	}
+	for i := 0; i < v.Len(); i++ {
+		val, err := marshalValue(v.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		if val == nil {
+			continue
+		}
+		id := getTypeID(val)
+		if len(l.Elements) == 0 {
+			l.Type = id
+		} else if id != l.Type {
+			return nil, fmt.Errorf("nbt: mixed types in list (%s and %s)", l.Type, id)
+		}
+		l.Elements = append(l.Elements, val)
+	}
+	// Try and work out the type of an empty list from the Go type
+	if len(l.Elements) == 0 {
+		if val, err := marshalValue(reflect.Zero(v.Type().Elem())); err == nil && val != nil {
+			l.Type = getTypeID(val)
+		}
+	}
+	return l, nil
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// Unmarshal stores the contents of the compound in the value pointed
+// to by v which must be a struct or a map with string keys. The
+// mapping between tags and Go values is the same as Marshal's, with
+// the addition that integer tags may be stored in any integer type
+// large enough to hold the value and lists, byte arrays and int arrays
+// may be stored in any slice of a suitable type.
+//
+// Tags without a matching field are ignored and fields without a
+// matching tag are left unchanged. If a tag can't be stored in its
+// field an UnmarshalTypeError is returned, the remaining tags are
+// still stored.
+func Unmarshal(c *Compound, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("nbt: Unmarshal(non-pointer %T)", v)
+	}
+	d := &decodeState{}
+	d.value(c, rv.Elem(), "")
+	return d.err
+}
+
+type decodeState struct {
+	// The first error encountered
+	err error
+}
+
+func (d *decodeState) typeError(tag interface{}, v reflect.Value, path string) {
+	if d.err == nil {
+		d.err = &UnmarshalTypeError{Field: path, Tag: getTypeID(tag), Type: v.Type()}
+	}
+}
+
+func (d *decodeState) value(tag interface{}, v reflect.Value, path string) {
+	switch v.Type() {
+	case compoundType, listType:
+		if reflect.TypeOf(tag) != v.Type() {
+			d.typeError(tag, v, path)
+			return
+		}
+		v.Set(reflect.ValueOf(tag))
+		return
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		d.value(tag, v.Elem(), path)
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			d.typeError(tag, v, path)
+			return
+		}
+		v.Set(reflect.ValueOf(tag))
+	case reflect.Bool:
+		b, ok := tag.(int8)
+		if !ok {
+			d.typeError(tag, v, path)
+			return
+		}
+		v.SetBool(b != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := tagInt(tag)
+		if !ok {
+			d.typeError(tag, v, path)
+			return
+		}
+		if v.OverflowInt(n) {
+			if d.err == nil {
+				d.err = &UnmarshalTypeError{Field: path, Tag: getTypeID(tag), Value: n, Type: v.Type()}
+			}
+			return
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := tagInt(tag)
+		if !ok {
+			d.typeError(tag, v, path)
+			return
+		}
+		if n < 0 || v.OverflowUint(uint64(n)) {
+			if d.err == nil {
+				d.err = &UnmarshalTypeError{Field: path, Tag: getTypeID(tag), Value: n, Type: v.Type()}
+			}
+			return
+		}
+		v.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		switch f := tag.(type) {
+		case float32:
+			v.SetFloat(float64(f))
+		case float64:
+			v.SetFloat(f)
+		default:
+			d.typeError(tag, v, path)
+		}
+	case reflect.String:
+		s, ok := tag.(string)
+		if !ok {
+			d.typeError(tag, v, path)
+			return
+		}
+		v.SetString(s)
+	case reflect.Slice, reflect.Array:
+		d.list(tag, v, path)
+	case reflect.Struct:
+		c, ok := tag.(*Compound)
+		if !ok {
+			d.typeError(tag, v, path)
+			return
+		}
+		for _, f := range structFields(v.Type()) {
+			if val, ok := c.Items[f.name]; ok {
+				d.value(val, fieldByIndex(v, f.index, true), joinPath(path, f.name))
+			}
+		}
+	case reflect.Map:
+		c, ok := tag.(*Compound)
+		if !ok || v.Type().Key().Kind() != reflect.String {
+			d.typeError(tag, v, path)
+			return
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		for k, val := range c.Items {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			d.value(val, elem, joinPath(path, k))
+			v.SetMapIndex(reflect.ValueOf(k).Convert(v.Type().Key()), elem)
+		}
+	default:
+		d.typeError(tag, v, path)
+	}
+}
+
+func (d *decodeState) list(tag interface{}, v reflect.Value, path string) {
+	var elements []interface{}
+	switch t := tag.(type) {
+	case *List:
+		elements = t.Elements
+	case []byte:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes(append([]byte(nil), t...))
+			return
+		}
+		elements = make([]interface{}, len(t))
+		for i, b := range t {
+			elements[i] = int8(b)
+		}
+	case []int32:
+		elements = make([]interface{}, len(t))
+		for i, n := range t {
+			elements[i] = n
+		}
+	default:
+		d.typeError(tag, v, path)
+		return
+	}
+	if v.Kind() == reflect.Slice {
+		v.Set(reflect.MakeSlice(v.Type(), len(elements), len(elements)))
+	}
+	for i, e := range elements {
+		if i >= v.Len() {
+			break
+		}
+		d.value(e, v.Index(i), fmt.Sprintf("%s[%d]", path, i))
+	}
+}
+
+// tagInt returns the value of an integer tag.
+func tagInt(tag interface{}) (int64, bool) {
+	switch n := tag.(type) {
+	case int8:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	}
+	return 0, false
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex but handles nil
+// embedded structs, either allocating them or returning an invalid
+// value.
+func fieldByIndex(v reflect.Value, index []int, alloc bool) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+type field struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// structFields returns the fields of the struct that should be
+// encoded, the fields of embedded structs without a tag are
+// included as if they were part of the struct.
+func structFields(t reflect.Type) []field {
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("nbt")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if pos := strings.IndexRune(tag, ','); pos != -1 {
+			name, opts = tag[:pos], tag[pos+1:]
+		}
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for _, f := range structFields(ft) {
+					f.index = append([]int{i}, f.index...)
+					fields = append(fields, f)
+				}
+				continue
+			}
+		}
+		if sf.PkgPath != "" {
+			// Unexported
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, field{
+			name:      name,
+			index:     []int{i},
+			omitEmpty: opts == "omitempty",
+		})
+	}
+	return fields
+}
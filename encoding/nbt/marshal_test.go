@@ -0,0 +1,150 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbt
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+type testItem struct {
+	ID     int16 `nbt:"id"`
+	Count  int8
+	Damage int16    `nbt:",omitempty"`
+	Tag    *testTag `nbt:"tag,omitempty"`
+}
+
+type testTag struct {
+	Display struct {
+		Name string
+		Lore []string
+	} `nbt:"display"`
+	Unbreakable bool
+	Skipped     string `nbt:"-"`
+}
+
+type testChest struct {
+	Items  []testItem
+	Lock   string
+	Levels [][]int32
+	Extra  map[string]float64
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	in := testChest{
+		Items: []testItem{
+			{ID: 1, Count: 64},
+			{ID: 276, Count: 1, Damage: 5, Tag: &testTag{Unbreakable: true, Skipped: "x"}},
+		},
+		Lock:   "key",
+		Levels: [][]int32{{1, 2}, {3}},
+		Extra:  map[string]float64{"a": 0.5},
+	}
+	in.Items[1].Tag.Display.Name = "Sword"
+	in.Items[1].Tag.Display.Lore = []string{"line 1", "line 2"}
+
+	c, err := Marshal(&in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := c.Items["Items"].(*List)
+	if items.Type != TagCompound || len(items.Elements) != 2 {
+		t.Fatalf("unexpected items list %#v", items)
+	}
+	first := items.Elements[0].(*Compound)
+	if _, ok := first.Items["Damage"]; ok {
+		t.Error("omitempty field was included")
+	}
+	if id, ok := first.Items["id"].(int16); !ok || id != 1 {
+		t.Errorf("wrong id %#v", first.Items["id"])
+	}
+
+	// Check it survives being serialized
+	var buf bytes.Buffer
+	if err := c.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	c = NewCompound()
+	if err := c.Deserialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var out testChest
+	if err := Unmarshal(c, &out); err != nil {
+		t.Fatal(err)
+	}
+	in.Items[1].Tag.Skipped = ""
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("got %#v, wanted %#v", out, in)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	c := NewCompound()
+	c.Items["Count"] = "many"
+	c.Items["id"] = int32(70000)
+	var item testItem
+	err := Unmarshal(c, &item)
+	if _, ok := err.(*UnmarshalTypeError); !ok {
+		t.Fatalf("expected UnmarshalTypeError, got %v", err)
+	}
+
+	// Integers can be widened but not narrowed
+	delete(c.Items, "Count")
+	var wide struct {
+		ID int64 `nbt:"id"`
+	}
+	if err := Unmarshal(c, &wide); err != nil || wide.ID != 70000 {
+		t.Errorf("failed to widen: %v", err)
+	}
+	err = Unmarshal(c, &item)
+	if e, ok := err.(*UnmarshalTypeError); !ok || e.Field != "id" || e.Value == nil {
+		t.Errorf("expected overflow error, got %v", err)
+	}
+
+	lore := NewCompound()
+	lore.Items["Lore"] = &List{Type: TagString, Elements: []interface{}{"a", int8(1)}}
+	display := NewCompound()
+	display.Items["display"] = lore
+	var tag testTag
+	err = Unmarshal(display, &tag)
+	if e, ok := err.(*UnmarshalTypeError); !ok || e.Field != "display.Lore[1]" || e.Tag != TagByte {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestMarshalOverflow(t *testing.T) {
+	var v struct {
+		Small, Large int
+	}
+	v.Small = math.MinInt32
+	c, err := Marshal(v)
+	if err != nil || c.Items["Small"] != int32(math.MinInt32) {
+		t.Fatalf("failed to marshal int: %v", err)
+	}
+
+	if strconv.IntSize == 32 {
+		t.Skip("int can't overflow TagInt")
+	}
+	large := int64(math.MaxInt32) + 1
+	v.Large = int(large)
+	_, err = Marshal(v)
+	if e, ok := err.(*MarshalOverflowError); !ok || e.Tag != TagInt || e.Value != large {
+		t.Errorf("expected overflow error, got %v", err)
+	}
+}
@@ -38,6 +38,28 @@ const (
 	TagIntArray
 )
 
+var typeNames = [...]string{
+	TagEnd:       "TAG_End",
+	TagByte:      "TAG_Byte",
+	TagShort:     "TAG_Short",
+	TagInt:       "TAG_Int",
+	TagLong:      "TAG_Long",
+	TagFloat:     "TAG_Float",
+	TagDouble:    "TAG_Double",
+	TagByteArray: "TAG_Byte_Array",
+	TagString:    "TAG_String",
+	TagList:      "TAG_List",
+	TagCompound:  "TAG_Compound",
+	TagIntArray:  "TAG_Int_Array",
+}
+
+func (t TypeID) String() string {
+	if t < 0 || int(t) >= len(typeNames) {
+		return fmt.Sprintf("TypeID(%d)", int(t))
+	}
+	return typeNames[t]
+}
+
 var (
 	ErrInvalidCompound = errors.New("invalid compound")
 )
@@ -107,6 +129,11 @@ func writeType(w io.Writer, v interface{}) error {
 			return err
 		}
 		return binary.Write(w, binary.BigEndian, v)
+	case []int32:
+		if err := binary.Write(w, binary.BigEndian, int32(len(v))); err != nil {
+			return err
+		}
+		return binary.Write(w, binary.BigEndian, v)
 	}
 	panic("unhandled type")
 }
@@ -133,7 +160,7 @@ func getTypeID(i interface{}) TypeID {
 		return TagList
 	case *Compound:
 		return TagCompound
-	case []int:
+	case []int, []int32:
 		return TagIntArray
 	}
 	panic(fmt.Sprintf("invalid type %T", i))
@@ -0,0 +1,484 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbt
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A SyntaxError is a description of an SNBT syntax error.
+type SyntaxError struct {
+	msg string
+	// The byte offset in the input the error occurred at
+	Offset int
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("nbt: %s at offset %d", e.msg, e.Offset)
+}
+
+var (
+	snbtByte   = regexp.MustCompile(`^[-+]?\d+[bB]$`)
+	snbtShort  = regexp.MustCompile(`^[-+]?\d+[sS]$`)
+	snbtInt    = regexp.MustCompile(`^[-+]?\d+$`)
+	snbtLong   = regexp.MustCompile(`^[-+]?\d+[lL]$`)
+	snbtFloat  = regexp.MustCompile(`^([-+]?(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?|[-+]?Infinity|NaN)[fF]$`)
+	snbtDouble = regexp.MustCompile(`^([-+]?(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?|[-+]?Infinity|NaN)[dD]$`)
+	// Doubles don't need a suffix if they have a decimal point
+	snbtDoubleNoSuffix = regexp.MustCompile(`^[-+]?(\d+\.\d*|\.\d+)([eE][-+]?\d+)?$`)
+	// Keys and strings that can be written without quotes
+	snbtPlain = regexp.MustCompile(`^[0-9A-Za-z._+-]+$`)
+)
+
+// ParseSNBT parses the stringified form of a compound, the same format
+// used by vanilla commands, e.g.:
+//
+//	{display:{Name:"Sword",Lore:["a","b"]},Unbreakable:1b}
+//
+// Numbers use suffixes to mark their type (b, s, L, f and d, ints have
+// none and decimals without a suffix are doubles), true and false are
+// bytes, byte and int arrays are written as [B;1b,2b] and [I;1,2].
+// Floats and doubles may also be NaN, Infinity or -Infinity (e.g.
+// NaNf), vanilla has no way to write these.
+func ParseSNBT(s string) (*Compound, error) {
+	p := &snbtParser{s: s}
+	p.skipSpace()
+	if p.peek() != '{' {
+		return nil, p.errorf("expected '{'")
+	}
+	c, err := p.compound()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos != len(p.s) {
+		return nil, p.errorf("unexpected %q after compound", p.s[p.pos])
+	}
+	return c, nil
+}
+
+type snbtParser struct {
+	s   string
+	pos int
+}
+
+func (p *snbtParser) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{msg: fmt.Sprintf(format, args...), Offset: p.pos}
+}
+
+func (p *snbtParser) peek() byte {
+	if p.pos >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *snbtParser) skipSpace() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) != -1 {
+		p.pos++
+	}
+}
+
+func (p *snbtParser) expect(b byte) error {
+	p.skipSpace()
+	if p.peek() != b {
+		if p.pos >= len(p.s) {
+			return p.errorf("expected %q but reached the end", b)
+		}
+		return p.errorf("expected %q but found %q", b, p.s[p.pos])
+	}
+	p.pos++
+	return nil
+}
+
+func (p *snbtParser) value() (interface{}, error) {
+	p.skipSpace()
+	switch p.peek() {
+	case '{':
+		return p.compound()
+	case '[':
+		return p.list()
+	case '"', '\'':
+		return p.quoted()
+	case 0:
+		return nil, p.errorf("expected a value but reached the end")
+	}
+	start := p.pos
+	token := p.token()
+	if token == "" {
+		return nil, p.errorf("unexpected %q", p.s[p.pos])
+	}
+	if v, ok := parseSNBTNumber(token); ok {
+		return v, nil
+	}
+	switch token {
+	case "true":
+		return int8(1), nil
+	case "false":
+		return int8(0), nil
+	}
+	if !snbtPlain.MatchString(token) {
+		p.pos = start
+		return nil, p.errorf("invalid value %q", token)
+	}
+	return token, nil
+}
+
+// parseSNBTNumber parses the token as a number. Numbers that don't
+// fit in their type are treated as strings as they are in vanilla.
+func parseSNBTNumber(token string) (interface{}, bool) {
+	trim := token[:len(token)-1]
+	switch {
+	case snbtByte.MatchString(token):
+		if v, err := strconv.ParseInt(trim, 10, 8); err == nil {
+			return int8(v), true
+		}
+	case snbtShort.MatchString(token):
+		if v, err := strconv.ParseInt(trim, 10, 16); err == nil {
+			return int16(v), true
+		}
+	case snbtLong.MatchString(token):
+		if v, err := strconv.ParseInt(trim, 10, 64); err == nil {
+			return v, true
+		}
+	case snbtInt.MatchString(token):
+		if v, err := strconv.ParseInt(token, 10, 32); err == nil {
+			return int32(v), true
+		}
+	case snbtFloat.MatchString(token):
+		if v, err := strconv.ParseFloat(trim, 32); err == nil {
+			return float32(v), true
+		}
+	case snbtDouble.MatchString(token):
+		if v, err := strconv.ParseFloat(trim, 64); err == nil {
+			return v, true
+		}
+	case snbtDoubleNoSuffix.MatchString(token):
+		if v, err := strconv.ParseFloat(token, 64); err == nil {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// token reads an unquoted key or value.
+func (p *snbtParser) token() string {
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte(",:]}[{ \t\r\n\"'", p.s[p.pos]) == -1 {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *snbtParser) quoted() (string, error) {
+	quote := p.s[p.pos]
+	p.pos++
+	var buf bytes.Buffer
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		p.pos++
+		switch c {
+		case quote:
+			return buf.String(), nil
+		case '\\':
+			if p.pos >= len(p.s) {
+				return "", p.errorf("unterminated string")
+			}
+			e := p.s[p.pos]
+			if e != '\\' && e != '"' && e != '\'' {
+				return "", p.errorf("invalid escape %q", e)
+			}
+			buf.WriteByte(e)
+			p.pos++
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *snbtParser) compound() (*Compound, error) {
+	p.pos++ // {
+	c := NewCompound()
+	p.skipSpace()
+	if p.peek() == '}' {
+		p.pos++
+		return c, nil
+	}
+	for {
+		p.skipSpace()
+		var key string
+		if q := p.peek(); q == '"' || q == '\'' {
+			var err error
+			if key, err = p.quoted(); err != nil {
+				return nil, err
+			}
+		} else if key = p.token(); key == "" {
+			return nil, p.errorf("expected a key")
+		}
+		if err := p.expect(':'); err != nil {
+			return nil, err
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		c.Items[key] = v
+
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return c, nil
+		default:
+			return nil, p.errorf("expected ',' or '}'")
+		}
+	}
+}
+
+func (p *snbtParser) list() (interface{}, error) {
+	p.pos++ // [
+	p.skipSpace()
+	// Typed arrays
+	if len(p.s)-p.pos >= 2 && p.s[p.pos+1] == ';' {
+		switch p.s[p.pos] {
+		case 'B':
+			p.pos += 2
+			return p.array(TagByte)
+		case 'I':
+			p.pos += 2
+			return p.array(TagInt)
+		default:
+			return nil, p.errorf("unsupported array type %q", p.s[p.pos])
+		}
+	}
+
+	l := &List{Type: TagEnd}
+	p.skipSpace()
+	if p.peek() == ']' {
+		p.pos++
+		return l, nil
+	}
+	for {
+		start := p.pos
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		id := getTypeID(v)
+		if len(l.Elements) == 0 {
+			l.Type = id
+		} else if id != l.Type {
+			p.pos = start
+			return nil, p.errorf("can't add %s to a list of %s", id, l.Type)
+		}
+		l.Elements = append(l.Elements, v)
+
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return l, nil
+		default:
+			return nil, p.errorf("expected ',' or ']'")
+		}
+	}
+}
+
+func (p *snbtParser) array(ty TypeID) (interface{}, error) {
+	var bs []byte
+	var is []int32
+	p.skipSpace()
+	if p.peek() == ']' {
+		p.pos++
+	} else {
+	values:
+		for {
+			start := p.pos
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			switch v := v.(type) {
+			case int8:
+				if ty != TagByte {
+					p.pos = start
+					return nil, p.errorf("can't add %s to an int array", TagByte)
+				}
+				bs = append(bs, byte(v))
+			case int32:
+				if ty != TagInt {
+					p.pos = start
+					return nil, p.errorf("can't add %s to a byte array", TagInt)
+				}
+				is = append(is, v)
+			default:
+				p.pos = start
+				return nil, p.errorf("can't add %s to an array", getTypeID(v))
+			}
+
+			p.skipSpace()
+			switch p.peek() {
+			case ',':
+				p.pos++
+			case ']':
+				p.pos++
+				break values
+			default:
+				return nil, p.errorf("expected ',' or ']'")
+			}
+		}
+	}
+	if ty == TagByte {
+		if bs == nil {
+			bs = []byte{}
+		}
+		return bs, nil
+	}
+	if is == nil {
+		is = []int32{}
+	}
+	return is, nil
+}
+
+// String returns the compound in the stringified form parsed by
+// ParseSNBT. Keys are sorted so the output is stable. The name of
+// the compound isn't included.
+func (c *Compound) String() string {
+	var buf bytes.Buffer
+	writeSNBT(&buf, c)
+	return buf.String()
+}
+
+// String returns the list in the stringified form parsed by ParseSNBT.
+func (l *List) String() string {
+	var buf bytes.Buffer
+	writeSNBT(&buf, l)
+	return buf.String()
+}
+
+func writeSNBT(buf *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case int8:
+		fmt.Fprintf(buf, "%db", v)
+	case int16:
+		fmt.Fprintf(buf, "%ds", v)
+	case int32:
+		fmt.Fprintf(buf, "%d", v)
+	case int64:
+		fmt.Fprintf(buf, "%dL", v)
+	case float32:
+		buf.WriteString(formatSNBTFloat(float64(v), 32))
+		buf.WriteByte('f')
+	case float64:
+		buf.WriteString(formatSNBTFloat(v, 64))
+		buf.WriteByte('d')
+	case string:
+		writeSNBTString(buf, v)
+	case []byte:
+		buf.WriteString("[B;")
+		for i, b := range v {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			fmt.Fprintf(buf, "%db", int8(b))
+		}
+		buf.WriteByte(']')
+	case []int32:
+		buf.WriteString("[I;")
+		for i, n := range v {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			fmt.Fprintf(buf, "%d", n)
+		}
+		buf.WriteByte(']')
+	case []int:
+		buf.WriteString("[I;")
+		for i, n := range v {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			fmt.Fprintf(buf, "%d", n)
+		}
+		buf.WriteByte(']')
+	case *List:
+		buf.WriteByte('[')
+		for i, e := range v.Elements {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			writeSNBT(buf, e)
+		}
+		buf.WriteByte(']')
+	case *Compound:
+		keys := make([]string, 0, len(v.Items))
+		for k := range v.Items {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			if snbtPlain.MatchString(k) {
+				buf.WriteString(k)
+			} else {
+				writeSNBTString(buf, k)
+			}
+			buf.WriteByte(':')
+			writeSNBT(buf, v.Items[k])
+		}
+		buf.WriteByte('}')
+	default:
+		panic(fmt.Sprintf("invalid type %T", v))
+	}
+}
+
+// formatSNBTFloat formats the float so that ParseSNBT can read it
+// back, including NaN and infinities.
+func formatSNBTFloat(v float64, bitSize int) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "Infinity"
+	case math.IsInf(v, -1):
+		return "-Infinity"
+	}
+	return strconv.FormatFloat(v, 'g', -1, bitSize)
+}
+
+func writeSNBTString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c == '"' || c == '\\' {
+			buf.WriteByte('\\')
+		}
+		buf.WriteByte(s[i])
+	}
+	buf.WriteByte('"')
+}
@@ -0,0 +1,141 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbt
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestSNBT(t *testing.T) {
+	const in = `{
+		display: {Name: "Sword \"1\"", Lore: ['a', "b"]},
+		Unbreakable: true,
+		"odd key": 1.5,
+		values: {b: -1b, s: 2s, i: 3, l: 4L, f: 0.5f, d: 2d, str: hello},
+		Pos: [1.0d, 2.0d, 3.0d],
+		Empty: [],
+		Bytes: [B; 1b, -2b],
+		Ints: [I; 1, 2, 3],
+	}`
+	if _, err := ParseSNBT(in); err == nil {
+		t.Fatal("expected an error for the trailing comma")
+	}
+	c, err := ParseSNBT(in[:len(in)-4] + "}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := c.Items["values"].(*Compound).Items
+	expected := map[string]interface{}{
+		"b": int8(-1), "s": int16(2), "i": int32(3), "l": int64(4),
+		"f": float32(0.5), "d": float64(2), "str": "hello",
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("values: got %#v, expected %#v", values, expected)
+	}
+	if name := c.Items["display"].(*Compound).Items["Name"]; name != `Sword "1"` {
+		t.Errorf("name: got %q", name)
+	}
+	if u := c.Items["Unbreakable"]; u != int8(1) {
+		t.Errorf("Unbreakable: got %#v", u)
+	}
+	if l := c.Items["Pos"].(*List); l.Type != TagDouble || len(l.Elements) != 3 {
+		t.Errorf("Pos: got %s", l)
+	}
+	if b := c.Items["Bytes"].([]byte); !reflect.DeepEqual(b, []byte{1, 0xFE}) {
+		t.Errorf("Bytes: got %v", b)
+	}
+
+	const expectedString = `{Bytes:[B;1b,-2b],Empty:[],Ints:[I;1,2,3],Pos:[1d,2d,3d],` +
+		`Unbreakable:1b,display:{Lore:["a","b"],Name:"Sword \"1\""},"odd key":1.5d,` +
+		`values:{b:-1b,d:2d,f:0.5f,i:3,l:4L,s:2s,str:"hello"}}`
+	if s := c.String(); s != expectedString {
+		t.Fatalf("string: got\n%s\nexpected\n%s", s, expectedString)
+	}
+
+	// The output should parse back to the same compound
+	c2, err := ParseSNBT(c.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c, c2) {
+		t.Errorf("round trip: got %s", c2)
+	}
+}
+
+func TestSNBTSpecialFloats(t *testing.T) {
+	c := NewCompound()
+	c.Items["nanf"] = float32(math.NaN())
+	c.Items["inff"] = float32(math.Inf(1))
+	c.Items["nand"] = math.NaN()
+	c.Items["inf"] = math.Inf(1)
+	c.Items["-inf"] = math.Inf(-1)
+
+	const expected = `{-inf:-Infinityd,inf:Infinityd,inff:Infinityf,nand:NaNd,nanf:NaNf}`
+	if s := c.String(); s != expected {
+		t.Fatalf("string: got %s, expected %s", s, expected)
+	}
+	c2, err := ParseSNBT(c.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// NaN never equals itself so the values have to be checked
+	// one at a time
+	items := c2.Items
+	if v, ok := items["nanf"].(float32); !ok || !math.IsNaN(float64(v)) {
+		t.Errorf("nanf: got %#v", items["nanf"])
+	}
+	if v, ok := items["inff"].(float32); !ok || !math.IsInf(float64(v), 1) {
+		t.Errorf("inff: got %#v", items["inff"])
+	}
+	if v, ok := items["nand"].(float64); !ok || !math.IsNaN(v) {
+		t.Errorf("nand: got %#v", items["nand"])
+	}
+	if v, ok := items["inf"].(float64); !ok || !math.IsInf(v, 1) {
+		t.Errorf("inf: got %#v", items["inf"])
+	}
+	if v, ok := items["-inf"].(float64); !ok || !math.IsInf(v, -1) {
+		t.Errorf("-inf: got %#v", items["-inf"])
+	}
+}
+
+func TestSNBTErrors(t *testing.T) {
+	tests := []struct {
+		in     string
+		offset int
+	}{
+		{`[]`, 0},
+		{`{a:1`, 4},
+		{`{a 1}`, 3},
+		{`{a:"b}`, 6},
+		{`{a:[1,2b]}`, 6},
+		{`{a:[B;1,2]}`, 6},
+		{`{a:1}b`, 5},
+		{`{:1}`, 1},
+	}
+	for _, test := range tests {
+		_, err := ParseSNBT(test.in)
+		serr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("%s: expected a syntax error, got %v", test.in, err)
+			continue
+		}
+		if serr.Offset != test.offset {
+			t.Errorf("%s: got offset %d (%v), expected %d", test.in, serr.Offset, err, test.offset)
+		}
+	}
+}
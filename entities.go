@@ -23,12 +23,17 @@ func newPlayer() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
 
+		metadataComponent
+		effectsComponent
 		playerComponent
 		playerModelComponent
 	}
 	p := &player{}
+	p.schema = playerSchema
 	p.hasHead = true
 	p.hasNameTag = true
 	p.isFirstPerson = false
@@ -43,7 +48,12 @@ func newCreeper() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
 
 		debugComponent
 	}
@@ -51,6 +61,7 @@ func newCreeper() Entity {
 		debugComponent: debugComponent{16, 117, 55},
 	}
 	c.NetworkID = 50
+	c.schema = livingSchema
 	c.bounds = vmath.NewAABB(-0.2, 0, -0.2, 0.4, 1.5, 0.4)
 	return c
 }
@@ -62,7 +73,12 @@ func newSkeleton() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
 
 		debugComponent
 	}
@@ -70,6 +86,7 @@ func newSkeleton() Entity {
 		debugComponent: debugComponent{255, 255, 255},
 	}
 	s.NetworkID = 51
+	s.schema = livingSchema
 	s.bounds = vmath.NewAABB(-0.3, 0, -0.3, 0.6, 1.8, 0.6)
 	return s
 }
@@ -81,7 +98,12 @@ func newSpider() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
 
 		debugComponent
 	}
@@ -89,6 +111,7 @@ func newSpider() Entity {
 		debugComponent: debugComponent{59, 7, 7},
 	}
 	s.NetworkID = 52
+	s.schema = livingSchema
 	s.bounds = vmath.NewAABB(-0.7, 0, -0.7, 1.4, 0.9, 1.4)
 	return s
 }
@@ -100,7 +123,13 @@ func newZombie() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
+		ageableComponent
 
 		debugComponent
 	}
@@ -108,6 +137,7 @@ func newZombie() Entity {
 		debugComponent: debugComponent{17, 114, 156},
 	}
 	z.NetworkID = 54
+	z.schema = zombieSchema
 	z.bounds = vmath.NewAABB(-0.3, 0, -0.3, 0.6, 1.8, 0.6)
 	return z
 }
@@ -119,7 +149,12 @@ func newSlime() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
 
 		debugComponent
 	}
@@ -127,6 +162,7 @@ func newSlime() Entity {
 		debugComponent: debugComponent{17, 114, 156},
 	}
 	s.NetworkID = 55
+	s.schema = livingSchema
 	s.bounds = vmath.NewAABB(-0.5, 0, -0.5, 1, 1, 1)
 	return s
 }
@@ -138,7 +174,12 @@ func newGhast() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
 
 		debugComponent
 	}
@@ -146,6 +187,7 @@ func newGhast() Entity {
 		debugComponent: debugComponent{191, 191, 191},
 	}
 	g.NetworkID = 56
+	g.schema = livingSchema
 	g.bounds = vmath.NewAABB(-2, 0, -2, 4, 4, 4)
 	return g
 }
@@ -157,7 +199,13 @@ func newZombiePigman() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
+		ageableComponent
 
 		debugComponent
 	}
@@ -165,6 +213,7 @@ func newZombiePigman() Entity {
 		debugComponent: debugComponent{204, 110, 198},
 	}
 	z.NetworkID = 57
+	z.schema = zombieSchema
 	z.bounds = vmath.NewAABB(-0.3, 0, -0.3, 0.6, 1.8, 0.6)
 	return z
 }
@@ -176,7 +225,12 @@ func newEnderman() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
 
 		debugComponent
 	}
@@ -184,6 +238,7 @@ func newEnderman() Entity {
 		debugComponent: debugComponent{74, 0, 69},
 	}
 	e.NetworkID = 58
+	e.schema = livingSchema
 	e.bounds = vmath.NewAABB(-0.3, 0, -0.3, 0.6, 2.9, 0.6)
 	return e
 }
@@ -195,7 +250,12 @@ func newCaveSpider() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
 
 		debugComponent
 	}
@@ -203,6 +263,7 @@ func newCaveSpider() Entity {
 		debugComponent: debugComponent{0, 116, 232},
 	}
 	c.NetworkID = 59
+	c.schema = livingSchema
 	c.bounds = vmath.NewAABB(-0.35, 0, -0.35, 0.7, 0.5, 0.7)
 	return c
 }
@@ -214,7 +275,12 @@ func newSilverfish() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
 
 		debugComponent
 	}
@@ -222,6 +288,7 @@ func newSilverfish() Entity {
 		debugComponent: debugComponent{128, 128, 128},
 	}
 	s.NetworkID = 60
+	s.schema = livingSchema
 	s.bounds = vmath.NewAABB(-0.2, 0, -0.2, 0.4, 0.3, 0.4)
 	return s
 }
@@ -233,7 +300,12 @@ func newBlaze() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
 
 		debugComponent
 	}
@@ -241,6 +313,7 @@ func newBlaze() Entity {
 		debugComponent: debugComponent{184, 61, 0},
 	}
 	b.NetworkID = 61
+	b.schema = livingSchema
 	b.bounds = vmath.NewAABB(-0.3, 0, -0.3, 0.6, 1.8, 0.6)
 	return b
 }
@@ -252,7 +325,12 @@ func newMagmaCube() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
 
 		debugComponent
 	}
@@ -260,6 +338,7 @@ func newMagmaCube() Entity {
 		debugComponent: debugComponent{186, 28, 28},
 	}
 	m.NetworkID = 62
+	m.schema = livingSchema
 	m.bounds = vmath.NewAABB(-0.5, 0, -0.5, 1, 1, 1)
 	return m
 }
@@ -271,7 +350,12 @@ func newEnderDragon() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
 
 		debugComponent
 	}
@@ -279,6 +363,7 @@ func newEnderDragon() Entity {
 		debugComponent: debugComponent{122, 59, 117},
 	}
 	e.NetworkID = 63
+	e.schema = livingSchema
 	e.bounds = vmath.NewAABB(-8, 0, -8, 16, 8, 16)
 	return e
 }
@@ -290,7 +375,12 @@ func newWither() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
 
 		debugComponent
 	}
@@ -298,6 +388,7 @@ func newWither() Entity {
 		debugComponent: debugComponent{64, 64, 64},
 	}
 	w.NetworkID = 64
+	w.schema = livingSchema
 	w.bounds = vmath.NewAABB(-0.45, 0, -0.45, 0.9, 3.5, 0.9)
 	return w
 }
@@ -309,7 +400,12 @@ func newBat() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
 
 		debugComponent
 	}
@@ -317,6 +413,7 @@ func newBat() Entity {
 		debugComponent: debugComponent{8, 8, 8},
 	}
 	b.NetworkID = 65
+	b.schema = livingSchema
 	b.bounds = vmath.NewAABB(-0.25, 0, -0.25, 0.5, 0.9, 0.5)
 	return b
 }
@@ -328,7 +425,12 @@ func newWitch() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
 
 		debugComponent
 	}
@@ -336,6 +438,7 @@ func newWitch() Entity {
 		debugComponent: debugComponent{87, 64, 0},
 	}
 	w.NetworkID = 66
+	w.schema = livingSchema
 	w.bounds = vmath.NewAABB(-0.3, 0, -0.3, 0.6, 1.8, 0.6)
 	return w
 }
@@ -347,7 +450,12 @@ func newEndermite() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
 
 		debugComponent
 	}
@@ -355,6 +463,7 @@ func newEndermite() Entity {
 		debugComponent: debugComponent{69, 47, 71},
 	}
 	e.NetworkID = 67
+	e.schema = livingSchema
 	e.bounds = vmath.NewAABB(-0.2, 0, -0.2, 0.4, 0.3, 0.4)
 	return e
 }
@@ -366,7 +475,12 @@ func newGuardian() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
 
 		debugComponent
 	}
@@ -374,6 +488,7 @@ func newGuardian() Entity {
 		debugComponent: debugComponent{69, 47, 71},
 	}
 	g.NetworkID = 68
+	g.schema = livingSchema
 	g.bounds = vmath.NewAABB(-0.425, 0, -0.425, 0.85, 0.85, 0.85)
 	return g
 }
@@ -385,7 +500,13 @@ func newPig() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
+		ageableComponent
 
 		debugComponent
 	}
@@ -393,6 +514,7 @@ func newPig() Entity {
 		debugComponent: debugComponent{252, 0, 194},
 	}
 	p.NetworkID = 90
+	p.schema = ageableSchema
 	p.bounds = vmath.NewAABB(-0.45, 0, -0.45, 0.9, 0.9, 0.9)
 	return p
 }
@@ -404,7 +526,14 @@ func newSheep() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
+		ageableComponent
+		woolComponent
 
 		debugComponent
 	}
@@ -412,6 +541,8 @@ func newSheep() Entity {
 		debugComponent: debugComponent{232, 232, 232},
 	}
 	s.NetworkID = 91
+	s.schema = sheepSchema
+	s.skin = [3]byte{214, 183, 166}
 	s.bounds = vmath.NewAABB(-0.45, 0, -0.45, 0.9, 1.3, 0.9)
 	return s
 }
@@ -423,7 +554,13 @@ func newCow() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
+		ageableComponent
 
 		debugComponent
 	}
@@ -431,6 +568,7 @@ func newCow() Entity {
 		debugComponent: debugComponent{125, 52, 0},
 	}
 	c.NetworkID = 92
+	c.schema = ageableSchema
 	c.bounds = vmath.NewAABB(-0.45, 0, -0.45, 0.9, 1.3, 0.9)
 	return c
 }
@@ -442,7 +580,13 @@ func newChicken() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
+		ageableComponent
 
 		debugComponent
 	}
@@ -450,6 +594,7 @@ func newChicken() Entity {
 		debugComponent: debugComponent{217, 217, 217},
 	}
 	c.NetworkID = 93
+	c.schema = ageableSchema
 	c.bounds = vmath.NewAABB(-0.2, 0, -0.2, 0.4, 0.7, 0.4)
 	return c
 }
@@ -461,7 +606,12 @@ func newSquid() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
 
 		debugComponent
 	}
@@ -469,6 +619,7 @@ func newSquid() Entity {
 		debugComponent: debugComponent{84, 39, 245},
 	}
 	s.NetworkID = 94
+	s.schema = livingSchema
 	s.bounds = vmath.NewAABB(-0.475, 0, -0.475, 0.95, 0.95, 0.95)
 	return s
 }
@@ -480,7 +631,13 @@ func newWolf() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
+		ageableComponent
 
 		debugComponent
 	}
@@ -488,6 +645,7 @@ func newWolf() Entity {
 		debugComponent: debugComponent{148, 148, 148},
 	}
 	w.NetworkID = 95
+	w.schema = ageableSchema
 	w.bounds = vmath.NewAABB(-0.3, 0, -0.3, 0.6, 0.8, 0.6)
 	return w
 }
@@ -499,7 +657,13 @@ func newMooshroom() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
+		ageableComponent
 
 		debugComponent
 	}
@@ -507,6 +671,7 @@ func newMooshroom() Entity {
 		debugComponent: debugComponent{145, 41, 0},
 	}
 	m.NetworkID = 96
+	m.schema = ageableSchema
 	m.bounds = vmath.NewAABB(-0.45, 0, -0.45, 0.9, 1.3, 0.9)
 	return m
 }
@@ -518,7 +683,12 @@ func newSnowman() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
 
 		debugComponent
 	}
@@ -526,6 +696,7 @@ func newSnowman() Entity {
 		debugComponent: debugComponent{225, 225, 255},
 	}
 	s.NetworkID = 97
+	s.schema = livingSchema
 	s.bounds = vmath.NewAABB(-0.35, 0, -0.35, 0.7, 1.9, 0.7)
 	return s
 }
@@ -537,7 +708,13 @@ func newOcelot() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
+		ageableComponent
 
 		debugComponent
 	}
@@ -545,6 +722,7 @@ func newOcelot() Entity {
 		debugComponent: debugComponent{242, 222, 0},
 	}
 	o.NetworkID = 98
+	o.schema = ageableSchema
 	o.bounds = vmath.NewAABB(-0.3, 0, -0.3, 0.6, 0.8, 0.6)
 	return o
 }
@@ -556,7 +734,12 @@ func newIronGolem() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
 
 		debugComponent
 	}
@@ -564,6 +747,7 @@ func newIronGolem() Entity {
 		debugComponent: debugComponent{125, 125, 125},
 	}
 	i.NetworkID = 99
+	i.schema = livingSchema
 	i.bounds = vmath.NewAABB(-0.7, 0, -0.7, 1.4, 2.9, 1.4)
 	return i
 }
@@ -575,7 +759,13 @@ func newHorse() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
+		ageableComponent
 
 		debugComponent
 	}
@@ -583,6 +773,7 @@ func newHorse() Entity {
 		debugComponent: debugComponent{191, 156, 0},
 	}
 	h.NetworkID = 100
+	h.schema = ageableSchema
 	h.bounds = vmath.NewAABB(-0.7, 0, -0.7, 1.4, 1.6, 1.4)
 	return h
 }
@@ -594,7 +785,13 @@ func newRabbit() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
+		ageableComponent
 
 		debugComponent
 	}
@@ -602,6 +799,7 @@ func newRabbit() Entity {
 		debugComponent: debugComponent{181, 123, 42},
 	}
 	r.NetworkID = 101
+	r.schema = ageableSchema
 	r.bounds = vmath.NewAABB(-0.3, 0, -0.3, 0.6, 0.7, 0.6)
 	return r
 }
@@ -613,7 +811,13 @@ func newVillager() Entity {
 		rotationComponent
 		targetRotationComponent
 		targetPositionComponent
+		velocityComponent
+		passengerComponent
 		sizeComponent
+		metadataComponent
+		effectsComponent
+		customNameComponent
+		ageableComponent
 
 		debugComponent
 	}
@@ -621,6 +825,7 @@ func newVillager() Entity {
 		debugComponent: debugComponent{212, 183, 142},
 	}
 	v.NetworkID = 120
+	v.schema = ageableSchema
 	v.bounds = vmath.NewAABB(-0.3, 0, -0.3, 0.6, 1.8, 0.6)
 	return v
 }
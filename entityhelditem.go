@@ -50,7 +50,13 @@ func staticModelFromItem(mdl *model, block Block, mode string) (out []*render.St
 		}
 	}
 
-	p := precomputeModel(mdl)
+	out = staticModelFromProcessed(precomputeModel(mdl), block)
+	return
+}
+
+// staticModelFromProcessed converts the faces of a processed model
+// into vertices for a static model centered on the origin.
+func staticModelFromProcessed(p *processedModel, block Block) (out []*render.StaticVertex) {
 	for fi := range p.faces {
 		f := p.faces[len(p.faces)-1-fi]
 		var cr, cg, cb byte
@@ -103,11 +109,15 @@ func staticModelFromItem(mdl *model, block Block, mode string) (out []*render.St
 }
 
 func genStaticModelFromItem(mdl *model, block Block, mode string) (out []*render.StaticVertex, mat mgl32.Mat4) {
-	if mode == "thirdperson" {
+	switch mode {
+	case "thirdperson":
 		mat = mgl32.Translate3D(0, 0, 2/16.0).
 			Mul4(mgl32.Rotate3DY(math.Pi).Mat4()).
 			Mul4(mgl32.Rotate3DZ(math.Pi).Mat4())
-	} else {
+	case "ground", "fixed":
+		// Upright and facing north
+		mat = mgl32.Rotate3DZ(math.Pi).Mat4()
+	default:
 		mat = mgl32.Translate3D(0, -8/16.0, 0).
 			Mul4(mgl32.Rotate3DX(math.Pi).Mat4()).
 			Mul4(mgl32.Rotate3DY(math.Pi).Mat4()).
@@ -204,3 +214,28 @@ func genStaticModelFromItem(mdl *model, block Block, mode string) (out []*render
 	}
 	return
 }
+
+// itemStaticModel returns the vertices of the item's model along with
+// the matrix to transform them by for the display mode. ok is false
+// if the item has no model.
+func itemStaticModel(item *ItemStack, mode string) (out []*render.StaticVertex, mat mgl32.Mat4, ok bool) {
+	mdl := getModel(item.Type.Name())
+	if mdl == nil {
+		return nil, mat, false
+	}
+
+	var blk Block
+	if bt, ok := item.Type.(*blockItem); ok {
+		blk = bt.block
+	}
+
+	switch mdl.builtIn {
+	case builtInGenerated:
+		out, mat = genStaticModelFromItem(mdl, blk, mode)
+	case builtInFalse:
+		out, mat = staticModelFromItem(mdl, blk, mode)
+	default:
+		return nil, mat, false
+	}
+	return out, mat, true
+}
@@ -0,0 +1,390 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steven
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/thinkofdeath/steven/entitysys"
+	"github.com/thinkofdeath/steven/protocol"
+	"github.com/thinkofdeath/steven/render"
+	"github.com/thinkofdeath/steven/type/vmath"
+)
+
+func init() {
+	addSystem(entitysys.Tick, esMetadataItemTick)
+	addSystem(entitysys.Add, esAgeableAdd)
+	addSystem(entitysys.Tick, esAgeableTick)
+	addSystem(entitysys.Tick, esWoolTick)
+	addSystem(entitysys.Tick, esOnFireTick)
+	addSystem(entitysys.Tick, esCustomNameTick)
+	addSystem(entitysys.Remove, esCustomNameRemove)
+}
+
+// entityFlags is the bit set sent at index 0 of every entity's
+// metadata.
+type entityFlags int8
+
+const (
+	flagOnFire entityFlags = 1 << iota
+	flagSneaking
+	_
+	flagSprinting
+	flagUsingItem
+	flagInvisible
+)
+
+func (f entityFlags) OnFire() bool    { return f&flagOnFire != 0 }
+func (f entityFlags) Sneaking() bool  { return f&flagSneaking != 0 }
+func (f entityFlags) Sprinting() bool { return f&flagSprinting != 0 }
+func (f entityFlags) UsingItem() bool { return f&flagUsingItem != 0 }
+func (f entityFlags) Invisible() bool { return f&flagInvisible != 0 }
+
+// entityMetadata is the decoded form of an entity's metadata. Only
+// the fields in the entity's schema are ever set.
+type entityMetadata struct {
+	Flags             entityFlags
+	Air               int
+	CustomName        string
+	CustomNameVisible bool
+	Silent            bool
+
+	// Living
+	Health float64
+
+	// Ageable
+	Baby bool
+
+	// Sheep
+	WoolColor int
+	Sheared   bool
+
+	// Player
+	SkinParts  byte
+	Absorption float64
+
+	// Items and item frames
+	Item         *ItemStack
+	ItemRotation int
+
+	itemChanged bool
+}
+
+// metadataField decodes a single value into the metadata, values of
+// the wrong type are ignored.
+type metadataField func(m *entityMetadata, v interface{})
+
+func metaByte(f func(m *entityMetadata, v int8)) metadataField {
+	return func(m *entityMetadata, v interface{}) {
+		if v, ok := v.(int8); ok {
+			f(m, v)
+		}
+	}
+}
+
+func metaShort(f func(m *entityMetadata, v int16)) metadataField {
+	return func(m *entityMetadata, v interface{}) {
+		if v, ok := v.(int16); ok {
+			f(m, v)
+		}
+	}
+}
+
+func metaFloat(f func(m *entityMetadata, v float32)) metadataField {
+	return func(m *entityMetadata, v interface{}) {
+		if v, ok := v.(float32); ok {
+			f(m, v)
+		}
+	}
+}
+
+func metaString(f func(m *entityMetadata, v string)) metadataField {
+	return func(m *entityMetadata, v interface{}) {
+		if v, ok := v.(string); ok {
+			f(m, v)
+		}
+	}
+}
+
+func metaItem(f func(m *entityMetadata, v *ItemStack)) metadataField {
+	return func(m *entityMetadata, v interface{}) {
+		if v, ok := v.(protocol.ItemStack); ok {
+			f(m, ItemStackFromProtocol(v))
+		}
+	}
+}
+
+// metadataSchema maps the indices of an entity type's metadata to
+// the fields they are decoded into. Indices not in the schema are
+// looked up in the parent's.
+type metadataSchema struct {
+	parent *metadataSchema
+	fields map[int]metadataField
+}
+
+func (s *metadataSchema) field(index int) metadataField {
+	for ; s != nil; s = s.parent {
+		if f, ok := s.fields[index]; ok {
+			return f
+		}
+	}
+	return nil
+}
+
+// decode applies the raw metadata to m.
+func (s *metadataSchema) decode(raw protocol.Metadata, m *entityMetadata) {
+	for index, v := range raw {
+		if f := s.field(index); f != nil {
+			f(m, v)
+		}
+	}
+}
+
+var entitySchema = &metadataSchema{
+	fields: map[int]metadataField{
+		0: metaByte(func(m *entityMetadata, v int8) { m.Flags = entityFlags(v) }),
+		1: metaShort(func(m *entityMetadata, v int16) { m.Air = int(v) }),
+		2: metaString(func(m *entityMetadata, v string) { m.CustomName = v }),
+		3: metaByte(func(m *entityMetadata, v int8) { m.CustomNameVisible = v != 0 }),
+		4: metaByte(func(m *entityMetadata, v int8) { m.Silent = v != 0 }),
+	},
+}
+
+var livingSchema = &metadataSchema{
+	parent: entitySchema,
+	fields: map[int]metadataField{
+		6: metaFloat(func(m *entityMetadata, v float32) { m.Health = float64(v) }),
+	},
+}
+
+var ageableSchema = &metadataSchema{
+	parent: livingSchema,
+	fields: map[int]metadataField{
+		// The age counts up to zero when growing up
+		12: metaByte(func(m *entityMetadata, v int8) { m.Baby = v < 0 }),
+	},
+}
+
+var zombieSchema = &metadataSchema{
+	parent: livingSchema,
+	fields: map[int]metadataField{
+		12: metaByte(func(m *entityMetadata, v int8) { m.Baby = v == 1 }),
+	},
+}
+
+var sheepSchema = &metadataSchema{
+	parent: ageableSchema,
+	fields: map[int]metadataField{
+		16: metaByte(func(m *entityMetadata, v int8) {
+			m.WoolColor = int(v & 0xF)
+			m.Sheared = v&0x10 != 0
+		}),
+	},
+}
+
+var playerSchema = &metadataSchema{
+	parent: livingSchema,
+	fields: map[int]metadataField{
+		10: metaByte(func(m *entityMetadata, v int8) { m.SkinParts = byte(v) }),
+		17: metaFloat(func(m *entityMetadata, v float32) { m.Absorption = float64(v) }),
+	},
+}
+
+var itemSchema = &metadataSchema{
+	parent: entitySchema,
+	fields: map[int]metadataField{
+		10: metaItem(func(m *entityMetadata, v *ItemStack) {
+			m.Item = v
+			m.itemChanged = true
+		}),
+	},
+}
+
+var itemFrameSchema = &metadataSchema{
+	parent: entitySchema,
+	fields: map[int]metadataField{
+		8: metaItem(func(m *entityMetadata, v *ItemStack) {
+			m.Item = v
+			m.itemChanged = true
+		}),
+		9: metaByte(func(m *entityMetadata, v int8) { m.ItemRotation = int(v) }),
+	},
+}
+
+// Metadata
+
+type metadataComponent struct {
+	schema *metadataSchema
+	meta   entityMetadata
+}
+
+func (m *metadataComponent) Metadata() *entityMetadata { return &m.meta }
+func (m *metadataComponent) UpdateMetadata(raw protocol.Metadata) {
+	m.schema.decode(raw, &m.meta)
+}
+
+type MetadataComponent interface {
+	Metadata() *entityMetadata
+	UpdateMetadata(raw protocol.Metadata)
+}
+
+// Copies the item from the metadata into the entity's itemComponent
+// when it changes.
+func esMetadataItemTick(m *metadataComponent, i *itemComponent) {
+	if m.meta.itemChanged {
+		m.meta.itemChanged = false
+		i.SetItem(m.meta.Item)
+	}
+}
+
+// Ageable
+
+// ageableComponent shrinks the entity while it is a baby.
+type ageableComponent struct {
+	adult vmath.AABB
+	baby  bool
+}
+
+func esAgeableAdd(a *ageableComponent, s *sizeComponent) {
+	a.adult = s.bounds
+}
+
+func esAgeableTick(a *ageableComponent, s *sizeComponent, m *metadataComponent) {
+	if a.baby == m.meta.Baby {
+		return
+	}
+	a.baby = m.meta.Baby
+	s.bounds = a.adult
+	if a.baby {
+		// The bounds are centered on the entity's feet so
+		// this keeps them on the ground
+		s.bounds.Min = s.bounds.Min.Mul(0.5)
+		s.bounds.Max = s.bounds.Max.Mul(0.5)
+	}
+}
+
+// Wool
+
+// woolColors is the color of a sheep's wool for each dye color.
+var woolColors = [16][3]byte{
+	{255, 255, 255},
+	{216, 127, 51},
+	{178, 76, 216},
+	{102, 153, 216},
+	{229, 229, 51},
+	{127, 204, 25},
+	{242, 127, 165},
+	{76, 76, 76},
+	{153, 153, 153},
+	{76, 127, 153},
+	{127, 63, 178},
+	{51, 76, 178},
+	{102, 76, 51},
+	{102, 127, 51},
+	{153, 51, 51},
+	{25, 25, 25},
+}
+
+// woolComponent colors the entity with the wool color from its
+// metadata.
+type woolComponent struct {
+	// The color shown once sheared
+	skin [3]byte
+}
+
+func esWoolTick(w *woolComponent, d *debugComponent, m *metadataComponent) {
+	c := woolColors[m.meta.WoolColor&0xF]
+	if m.meta.Sheared {
+		c = w.skin
+	}
+	d.R, d.G, d.B = c[0], c[1], c[2]
+}
+
+// On fire
+
+func esOnFireTick(m *metadataComponent, p PositionComponent, s SizeComponent) {
+	if !m.meta.Flags.OnFire() {
+		return
+	}
+	// Roughly one flame a tick spread over the entity, delta
+	// is in 60ths of a second so a tick is 3
+	if rand.Float64() > Client.delta/3 {
+		return
+	}
+	x, y, z := p.Position()
+	b := s.Bounds()
+	size := b.Max.Sub(b.Min)
+	spawnParticle(particleFlame,
+		x+float64(b.Min.X()+size.X()*rand.Float32()),
+		y+float64(b.Min.Y()+size.Y()*rand.Float32()),
+		z+float64(b.Min.Z()+size.Z()*rand.Float32()),
+		0, 0, 0, nil,
+	)
+}
+
+// Custom name
+
+// The distance a custom name can be seen from when the server
+// doesn't ask for it to always be shown.
+const customNameDistance = 8
+
+// customNameComponent draws the custom name of an entity above it.
+type customNameComponent struct {
+	nameTag *render.StaticModel
+	text    string
+}
+
+func esCustomNameTick(c *customNameComponent, m *metadataComponent, p PositionComponent, s SizeComponent) {
+	x, y, z := p.Position()
+	text := m.meta.CustomName
+	if m.meta.Flags.Invisible() {
+		// Like the model, the name is hidden
+		text = ""
+	} else if !m.meta.CustomNameVisible {
+		dx, dy, dz := x-render.Camera.X, y-render.Camera.Y, z-render.Camera.Z
+		if dx*dx+dy*dy+dz*dz > customNameDistance*customNameDistance {
+			text = ""
+		}
+	}
+	if text != c.text {
+		c.text = text
+		if c.nameTag != nil {
+			c.nameTag.Free()
+			c.nameTag = nil
+		}
+		if text != "" {
+			c.nameTag = render.NewStaticModel([][]*render.StaticVertex{
+				createNameTag(text),
+			})
+			c.nameTag.Radius = 3
+		}
+	}
+	if c.nameTag == nil {
+		return
+	}
+	height := s.Bounds().Max.Y() + 0.5
+	val := math.Atan2(x-render.Camera.X, z-render.Camera.Z)
+	c.nameTag.X, c.nameTag.Y, c.nameTag.Z = -float32(x), -float32(y), float32(z)
+	c.nameTag.Matrix[0] = mgl32.Translate3D(float32(x), -float32(y)-height, float32(z)).
+		Mul4(mgl32.Rotate3DY(float32(val)).Mat4())
+}
+
+func esCustomNameRemove(c *customNameComponent) {
+	if c.nameTag != nil {
+		c.nameTag.Free()
+	}
+}
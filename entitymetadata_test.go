@@ -0,0 +1,57 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steven
+
+import (
+	"testing"
+
+	"github.com/thinkofdeath/steven/protocol"
+)
+
+func TestMetadataDecode(t *testing.T) {
+	var m entityMetadata
+	zombieSchema.decode(protocol.Metadata{
+		0:  int8(flagOnFire | flagInvisible),
+		2:  "Steve",
+		3:  int8(1),
+		6:  float32(15),
+		12: int8(1),
+		// Not in the schema
+		20: int8(1),
+	}, &m)
+
+	if !m.Flags.OnFire() || !m.Flags.Invisible() || m.Flags.Sneaking() {
+		t.Errorf("wrong flags %08b", m.Flags)
+	}
+	if m.CustomName != "Steve" || !m.CustomNameVisible {
+		t.Errorf("wrong custom name %q (visible %t)", m.CustomName, m.CustomNameVisible)
+	}
+	if m.Health != 15 || !m.Baby {
+		t.Errorf("wrong living fields, health %f baby %t", m.Health, m.Baby)
+	}
+
+	// Values of the wrong type are ignored and fields that
+	// aren't sent keep their value
+	zombieSchema.decode(protocol.Metadata{
+		0: "bogus",
+		2: "",
+	}, &m)
+	if !m.Flags.Invisible() {
+		t.Error("flags changed by a bad value")
+	}
+	if m.CustomName != "" || !m.CustomNameVisible {
+		t.Errorf("wrong custom name %q (visible %t)", m.CustomName, m.CustomNameVisible)
+	}
+}
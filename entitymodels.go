@@ -18,6 +18,7 @@ import (
 	"math"
 
 	"github.com/go-gl/mathgl/mgl32"
+	"github.com/thinkofdeath/steven/chat"
 	"github.com/thinkofdeath/steven/entitysys"
 	"github.com/thinkofdeath/steven/render"
 	"github.com/thinkofdeath/steven/type/direction"
@@ -25,6 +26,8 @@ import (
 
 func init() {
 	addSystem(entitysys.Add, esPlayerModelAdd)
+	addSystem(entitysys.Tick, esPlayerModelMetadata)
+	addSystem(entitysys.Tick, esPlayerModelRiding)
 	addSystem(entitysys.Tick, esPlayerModelTick)
 	addSystem(entitysys.Remove, esPlayerModelRemove)
 
@@ -82,6 +85,10 @@ type playerModelComponent struct {
 	hasNameTag    bool
 	isFirstPerson bool
 
+	name        string
+	nameTag     *render.StaticModel
+	nameTagText string
+
 	dir        float64
 	time       float64
 	idleTime   float64
@@ -90,6 +97,10 @@ type playerModelComponent struct {
 
 	armTime float64
 
+	sneaking  bool
+	invisible bool
+	riding    bool
+
 	heldModel *render.StaticModel
 	heldMat   mgl32.Mat4
 }
@@ -103,27 +114,16 @@ func (p *playerModelComponent) SwingArm() {
 func (p *playerModelComponent) SetCurrentItem(item *ItemStack) {
 	if p.heldModel != nil {
 		p.heldModel.Free()
+		p.heldModel = nil
 	}
 	if item == nil {
 		return
 	}
-	mdl := getModel(item.Type.Name())
-	if mdl == nil {
+	out, mat, ok := itemStaticModel(item, "thirdperson")
+	if !ok {
 		return
 	}
-
-	var blk Block
-	if bt, ok := item.Type.(*blockItem); ok {
-		blk = bt.block
-	}
-	mode := "thirdperson"
-
-	var out []*render.StaticVertex
-	if mdl.builtIn == builtInGenerated {
-		out, p.heldMat = genStaticModelFromItem(mdl, blk, mode)
-	} else if mdl.builtIn == builtInFalse {
-		out, p.heldMat = staticModelFromItem(mdl, blk, mode)
-	}
+	p.heldMat = mat
 
 	p.heldModel = render.NewStaticModel([][]*render.StaticVertex{
 		out,
@@ -143,7 +143,6 @@ const (
 	playerModelLegRight
 	playerModelArmLeft
 	playerModelArmRight
-	playerModelNameTag
 )
 
 func esPlayerModelAdd(p *playerModelComponent, pl PlayerComponent) {
@@ -158,8 +157,19 @@ func esPlayerModelAdd(p *playerModelComponent, pl PlayerComponent) {
 		render.RefSkin(p.skin)
 	}
 
+	p.name = info.name
+
+	model := render.NewStaticModel(playerModelParts(skin, p.hasHead))
+	p.model = model
+	model.Radius = 3
+}
+
+// playerModelParts returns the vertices of each part of a player
+// with the skin, indexed by the playerModel* constants. Each part is
+// relative to the joint it rotates around.
+func playerModelParts(skin render.TextureInfo, hasHead bool) [][]*render.StaticVertex {
 	var hverts []*render.StaticVertex
-	if p.hasHead {
+	if hasHead {
 		hverts = appendBox(hverts, -4/16.0, 0, -4/16.0, 8/16.0, 8/16.0, 8/16.0, [6]render.TextureInfo{
 			direction.North: skin.Sub(8, 8, 8, 8),
 			direction.South: skin.Sub(24, 8, 8, 8),
@@ -223,26 +233,42 @@ func esPlayerModelAdd(p *playerModelComponent, pl PlayerComponent) {
 		})
 	}
 
-	var nverts []*render.StaticVertex
-	if p.hasNameTag {
-		nverts = createNameTag(info.name)
-	}
-
-	model := render.NewStaticModel([][]*render.StaticVertex{
+	return [][]*render.StaticVertex{
 		playerModelHead:     hverts,
 		playerModelBody:     bverts,
 		playerModelLegRight: lverts[0],
 		playerModelLegLeft:  lverts[1],
 		playerModelArmRight: lverts[2],
 		playerModelArmLeft:  lverts[3],
-		playerModelNameTag:  nverts,
+	}
+}
+
+// updateNameTag rebuilds the player's name tag if the text (which
+// depends on their team) has changed.
+func (p *playerModelComponent) updateNameTag() {
+	text := Client.teams.nameTag(p.name)
+	if text == p.nameTagText && (p.nameTag != nil || text == "") {
+		return
+	}
+	p.nameTagText = text
+	if p.nameTag != nil {
+		p.nameTag.Free()
+		p.nameTag = nil
+	}
+	if text == "" {
+		return
+	}
+	p.nameTag = render.NewStaticModel([][]*render.StaticVertex{
+		createNameTag(text),
 	})
-	p.model = model
-	model.Radius = 3
+	p.nameTag.Radius = 3
 }
 
-func createNameTag(name string) (verts []*render.StaticVertex) {
-	width := render.SizeOfString(name) + 4
+// createNameTag creates the vertices for a name tag displaying the
+// text, which may contain legacy formatting codes.
+func createNameTag(text string) (verts []*render.StaticVertex) {
+	name := legacyComponent(text)
+	width := render.SizeOfString(name.String()) + 4
 	tex := render.GetTexture("solid")
 	for _, v := range faceVertices[direction.North].verts {
 		vert := &render.StaticVertex{
@@ -259,32 +285,63 @@ func createNameTag(name string) (verts []*render.StaticVertex) {
 		verts = append(verts, vert)
 	}
 	offset := -(width/2)*0.01 + (2 * 0.01)
-	for _, r := range name {
-		tex := render.CharacterTexture(r)
-		if tex == nil {
-			continue
-		}
-		s := render.SizeOfCharacter(r)
-		for _, v := range faceVertices[direction.North].verts {
-			vert := &render.StaticVertex{
-				X:        float32(v.X)*float32(s*0.01) - float32(offset+s*0.01),
-				Y:        float32(v.Y)*0.16 - 0.08,
-				Z:        -0.01,
-				Texture:  tex,
-				TextureX: float64(v.TOffsetX),
-				TextureY: float64(v.TOffsetY),
-				R:        255,
-				G:        255,
-				B:        255,
-				A:        255,
+	forEachText(name, chat.White, func(text string, color chat.Color) {
+		cr, cg, cb := chatColorRGB(color)
+		for _, r := range text {
+			tex := render.CharacterTexture(r)
+			if tex == nil {
+				continue
 			}
-			verts = append(verts, vert)
+			s := render.SizeOfCharacter(r)
+			for _, v := range faceVertices[direction.North].verts {
+				vert := &render.StaticVertex{
+					X:        float32(v.X)*float32(s*0.01) - float32(offset+s*0.01),
+					Y:        float32(v.Y)*0.16 - 0.08,
+					Z:        -0.01,
+					Texture:  tex,
+					TextureX: float64(v.TOffsetX),
+					TextureY: float64(v.TOffsetY),
+					R:        byte(cr),
+					G:        byte(cg),
+					B:        byte(cb),
+					A:        255,
+				}
+				verts = append(verts, vert)
+			}
+			offset += (s + 2) * 0.01
 		}
-		offset += (s + 2) * 0.01
-	}
+	})
 	return verts
 }
 
+// forEachText calls f with each piece of text in the component
+// along with the color it should be drawn in.
+func forEachText(c chat.AnyComponent, color chat.Color, f func(text string, color chat.Color)) {
+	tc, ok := c.Value.(*chat.TextComponent)
+	if !ok {
+		f(c.String(), color)
+		return
+	}
+	if tc.Color != "" {
+		color = tc.Color
+	}
+	f(tc.Text, color)
+	for _, e := range tc.Extra {
+		forEachText(e, color, f)
+	}
+}
+
+// Copies the flags that change how the player is drawn from their
+// metadata.
+func esPlayerModelMetadata(p *playerModelComponent, m *metadataComponent) {
+	p.sneaking = m.meta.Flags.Sneaking()
+	p.invisible = m.meta.Flags.Invisible()
+}
+
+func esPlayerModelRiding(p *playerModelComponent, r *passengerComponent) {
+	_, p.riding = r.Vehicle()
+}
+
 func esPlayerModelRemove(p *playerModelComponent) {
 	if p.skin != "" {
 		render.FreeSkin(p.skin)
@@ -292,6 +349,9 @@ func esPlayerModelRemove(p *playerModelComponent) {
 	if p.heldModel != nil {
 		p.heldModel.Free()
 	}
+	if p.nameTag != nil {
+		p.nameTag.Free()
+	}
 }
 
 func esModelRemove(p interface {
@@ -310,25 +370,42 @@ func esPlayerModelTick(p *playerModelComponent,
 	model := p.model
 
 	model.X, model.Y, model.Z = -float32(x), -float32(y), float32(z)
+	model.Hidden = p.invisible
 	if p.heldModel != nil {
 		p.heldModel.X, p.heldModel.Y, p.heldModel.Z = -float32(x), -float32(y), float32(z)
 		p.heldModel.Colors[0] = model.Colors[0]
+		p.heldModel.Hidden = p.invisible
+	}
+
+	// Sneaking lowers the player, leans their body forward and
+	// moves their legs back to stay under them
+	var sneakDrop, headDrop, legRaise, legBack, lean, armLean float32
+	if p.sneaking {
+		sneakDrop, headDrop = 2/16.0, 1/16.0
+		legRaise, legBack = 3/16.0, 4/16.0
+		lean, armLean = 0.5, 0.4
 	}
 
-	offMat := mgl32.Translate3D(float32(x), -float32(y), float32(z)).
+	offMat := mgl32.Translate3D(float32(x), -float32(y)+sneakDrop, float32(z)).
 		Mul4(mgl32.Rotate3DY(math.Pi - float32(r.Yaw())).Mat4())
 
 	// TODO This isn't the most optimal way of doing this
 	if p.hasNameTag {
+		p.updateNameTag()
+	}
+	if p.nameTag != nil {
 		val := math.Atan2(x-render.Camera.X, z-render.Camera.Z)
-		model.Matrix[playerModelNameTag] = mgl32.Translate3D(float32(x), -float32(y), float32(z)).
+		p.nameTag.X, p.nameTag.Y, p.nameTag.Z = -float32(x), -float32(y), float32(z)
+		p.nameTag.Colors[0] = model.Colors[0]
+		p.nameTag.Matrix[0] = mgl32.Translate3D(float32(x), -float32(y)+sneakDrop, float32(z)).
 			Mul4(mgl32.Translate3D(0, -12/16.0-12/16.0-0.6, 0)).
 			Mul4(mgl32.Rotate3DY(float32(val)).Mat4())
 	}
 
-	model.Matrix[playerModelHead] = offMat.Mul4(mgl32.Translate3D(0, -12/16.0-12/16.0, 0)).
+	model.Matrix[playerModelHead] = offMat.Mul4(mgl32.Translate3D(0, -12/16.0-12/16.0+headDrop, 0)).
 		Mul4(mgl32.Rotate3DX(float32(r.Pitch())).Mat4())
-	model.Matrix[playerModelBody] = offMat.Mul4(mgl32.Translate3D(0, -12/16.0-6/16.0, 0))
+	model.Matrix[playerModelBody] = offMat.Mul4(mgl32.Translate3D(0, -12/16.0-6/16.0, 0)).
+		Mul4(mgl32.Rotate3DX(lean).Mat4())
 
 	time := p.time
 	dir := p.dir
@@ -338,10 +415,18 @@ func esPlayerModelTick(p *playerModelComponent,
 	}
 	ang := ((time / 15) - 1) * (math.Pi / 4)
 
-	model.Matrix[playerModelLegRight] = offMat.Mul4(mgl32.Translate3D(2/16.0, -12/16.0, 0)).
-		Mul4(mgl32.Rotate3DX(float32(ang)).Mat4())
-	model.Matrix[playerModelLegLeft] = offMat.Mul4(mgl32.Translate3D(-2/16.0, -12/16.0, 0)).
-		Mul4(mgl32.Rotate3DX(-float32(ang)).Mat4())
+	// Riding sits the player down with their legs and arms
+	// held forward
+	var legRide, armRide float32
+	if p.riding {
+		ang = 0
+		legRide, armRide = math.Pi*2/5, math.Pi/5
+	}
+
+	model.Matrix[playerModelLegRight] = offMat.Mul4(mgl32.Translate3D(2/16.0, -12/16.0-legRaise, legBack)).
+		Mul4(mgl32.Rotate3DX(float32(ang) - legRide).Mat4())
+	model.Matrix[playerModelLegLeft] = offMat.Mul4(mgl32.Translate3D(-2/16.0, -12/16.0-legRaise, legBack)).
+		Mul4(mgl32.Rotate3DX(-float32(ang) - legRide).Mat4())
 
 	iTime := p.idleTime
 	iTime += Client.delta * 0.02
@@ -356,15 +441,15 @@ func esPlayerModelTick(p *playerModelComponent,
 		p.armTime -= Client.delta
 	}
 
-	model.Matrix[playerModelArmRight] = offMat.Mul4(mgl32.Translate3D(6/16.0, -12/16.0-12/16.0, 0))
+	model.Matrix[playerModelArmRight] = offMat.Mul4(mgl32.Translate3D(6/16.0, -12/16.0-12/16.0+headDrop, 0))
 	model.Matrix[playerModelArmRight] = model.Matrix[playerModelArmRight].
-		Mul4(mgl32.Rotate3DX(-float32(ang * 0.75)).Mat4()).
+		Mul4(mgl32.Rotate3DX(-float32(ang*0.75) - armLean - armRide).Mat4()).
 		Mul4(mgl32.Rotate3DZ(float32(math.Cos(iTime)*0.06) - 0.06).Mat4()).
 		Mul4(mgl32.Rotate3DX(float32(math.Sin(iTime)*0.06) - float32((7.5-math.Abs(p.armTime-7.5))/7.5)).Mat4())
 
 	if p.heldModel != nil {
-		p.heldModel.Matrix[0] = offMat.Mul4(mgl32.Translate3D(6/16.0, -12/16.0-12/16.0, 0.0)).
-			Mul4(mgl32.Rotate3DX(-float32(ang * 0.75)).Mat4()).
+		p.heldModel.Matrix[0] = offMat.Mul4(mgl32.Translate3D(6/16.0, -12/16.0-12/16.0+headDrop, 0.0)).
+			Mul4(mgl32.Rotate3DX(-float32(ang*0.75) - armLean - armRide).Mat4()).
 			Mul4(mgl32.Rotate3DZ(float32(math.Cos(iTime)*0.06) - 0.06).Mat4()).
 			Mul4(mgl32.Rotate3DX(float32(math.Sin(iTime)*0.06) - float32((7.5-math.Abs(p.armTime-7.5))/7.5)).Mat4()).
 			Mul4(mgl32.Translate3D(0, 11/16.0, -5/16.0)).
@@ -372,8 +457,8 @@ func esPlayerModelTick(p *playerModelComponent,
 			Mul4(p.heldMat)
 	}
 
-	model.Matrix[playerModelArmLeft] = offMat.Mul4(mgl32.Translate3D(-6/16.0, -12/16.0-12/16.0, 0)).
-		Mul4(mgl32.Rotate3DX(float32(ang * 0.75)).Mat4()).
+	model.Matrix[playerModelArmLeft] = offMat.Mul4(mgl32.Translate3D(-6/16.0, -12/16.0-12/16.0+headDrop, 0)).
+		Mul4(mgl32.Rotate3DX(float32(ang*0.75) - armLean - armRide).Mat4()).
 		Mul4(mgl32.Rotate3DZ(-float32(math.Cos(iTime)*0.06) + 0.06).Mat4()).
 		Mul4(mgl32.Rotate3DX(-float32(math.Sin(iTime) * 0.06)).Mat4())
 
@@ -0,0 +1,627 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steven
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/thinkofdeath/steven/entitysys"
+	"github.com/thinkofdeath/steven/render"
+	"github.com/thinkofdeath/steven/type/direction"
+)
+
+func init() {
+	// Must be before the models so they see the final position
+	addSystem(entitysys.Add, esHangingAdd)
+
+	addSystem(entitysys.Add, esObjectModelAdd)
+	addSystem(entitysys.Tick, esObjectModelTick)
+	addSystem(entitysys.Add, esItemModelAdd)
+	addSystem(entitysys.Tick, esItemModelTick)
+	addSystem(entitysys.Add, esBlockModelAdd)
+	addSystem(entitysys.Tick, esBlockModelTick)
+	addSystem(entitysys.Tick, esItemFrameTick)
+	addSystem(entitysys.Remove, esItemFrameRemove)
+	addSystem(entitysys.Add, esPaintingAdd)
+	addSystem(entitysys.Tick, esPaintingTick)
+}
+
+// Hanging
+
+// Moves hanging entities from the block they were spawned in to
+// the center of where they are drawn, against the block behind.
+func esHangingAdd(h HangingComponent, p PositionComponent) {
+	x, y, z := p.Position()
+	ox, _, oz := h.Facing().Offset()
+	p.SetPosition(
+		x+0.5-float64(ox)*0.46875,
+		y+0.5,
+		z+0.5-float64(oz)*0.46875,
+	)
+}
+
+// hangingAngle returns the rotation around the y axis that turns a
+// model facing north to face the direction.
+func hangingAngle(d direction.Type) float32 {
+	switch d {
+	case direction.West:
+		return math.Pi / 2
+	case direction.South:
+		return math.Pi
+	case direction.East:
+		return -math.Pi / 2
+	}
+	return 0
+}
+
+// Object
+
+// objectModelComponent is a model that is built once and moved with
+// the entity.
+type objectModelComponent struct {
+	model *render.StaticModel
+	build func() []*render.StaticVertex
+
+	// Turns the model to face the camera instead of following
+	// the entity's rotation
+	faceCamera bool
+	// The model points along +x and should be turned to the
+	// direction the entity is moving in instead of the direction
+	// it faces
+	pointsForward bool
+}
+
+func (o *objectModelComponent) Model() *render.StaticModel { return o.model }
+
+func esObjectModelAdd(o *objectModelComponent) {
+	o.model = render.NewStaticModel([][]*render.StaticVertex{
+		o.build(),
+	})
+	o.model.Radius = 2
+}
+
+func esObjectModelTick(o *objectModelComponent, p PositionComponent, r RotationComponent) {
+	x, y, z := p.Position()
+	model := o.model
+	model.X, model.Y, model.Z = -float32(x), -float32(y), float32(z)
+
+	mat := mgl32.Translate3D(float32(x), -float32(y), float32(z))
+	switch {
+	case o.faceCamera:
+		val := math.Atan2(x-render.Camera.X, z-render.Camera.Z)
+		mat = mat.Mul4(mgl32.Rotate3DY(float32(val)).Mat4())
+	case o.pointsForward:
+		mat = mat.Mul4(mgl32.Rotate3DY(float32(r.Yaw()) - math.Pi/2).Mat4()).
+			Mul4(mgl32.Rotate3DZ(-float32(r.Pitch())).Mat4())
+	default:
+		mat = mat.Mul4(mgl32.Rotate3DY(math.Pi - float32(r.Yaw())).Mat4())
+	}
+	model.Matrix[0] = mat
+}
+
+func minecartVertices(contents Block) (verts []*render.StaticVertex) {
+	tex := render.RelativeTexture(render.GetTexture("entity/minecart"), 64, 32)
+	// Floor
+	verts = appendBox(verts, -10/16.0, 2/16.0, -8/16.0, 20/16.0, 2/16.0, 16/16.0, [6]render.TextureInfo{
+		direction.North: tex.Sub(2, 10, 20, 2),
+		direction.South: tex.Sub(2, 10, 20, 2),
+		direction.West:  tex.Sub(2, 10, 16, 2),
+		direction.East:  tex.Sub(2, 10, 16, 2),
+		direction.Up:    tex.Sub(2, 12, 20, 16),
+		direction.Down:  tex.Sub(2, 12, 20, 16),
+	})
+	// Long sides
+	for _, z := range []float32{-8 / 16.0, 6 / 16.0} {
+		verts = appendBox(verts, -10/16.0, 4/16.0, z, 20/16.0, 8/16.0, 2/16.0, [6]render.TextureInfo{
+			direction.North: tex.Sub(2, 2, 16, 8),
+			direction.South: tex.Sub(2, 2, 16, 8),
+			direction.West:  tex.Sub(0, 2, 2, 8),
+			direction.East:  tex.Sub(0, 2, 2, 8),
+			direction.Up:    tex.Sub(2, 0, 16, 2),
+			direction.Down:  tex.Sub(2, 0, 16, 2),
+		})
+	}
+	// Ends
+	for _, x := range []float32{-10 / 16.0, 8 / 16.0} {
+		verts = appendBox(verts, x, 4/16.0, -6/16.0, 2/16.0, 8/16.0, 12/16.0, [6]render.TextureInfo{
+			direction.North: tex.Sub(0, 2, 2, 8),
+			direction.South: tex.Sub(0, 2, 2, 8),
+			direction.West:  tex.Sub(2, 2, 12, 8),
+			direction.East:  tex.Sub(2, 2, 12, 8),
+			direction.Up:    tex.Sub(2, 0, 2, 12),
+			direction.Down:  tex.Sub(2, 0, 2, 12),
+		})
+	}
+
+	if contents == nil || len(contents.Models()) == 0 {
+		return verts
+	}
+	// The block sits on the floor at three quarters of its size
+	for _, v := range staticModelFromProcessed(contents.Models().selectModel(0), contents) {
+		v.X *= 0.75
+		v.Y = v.Y*0.75 + 4/16.0 + 0.375
+		v.Z *= 0.75
+		verts = append(verts, v)
+	}
+	return verts
+}
+
+func boatVertices() (verts []*render.StaticVertex) {
+	tex := render.RelativeTexture(render.GetTexture("entity/boat"), 64, 32)
+	// Bottom
+	verts = appendBox(verts, -12/16.0, 0, -8/16.0, 24/16.0, 3/16.0, 16/16.0, [6]render.TextureInfo{
+		direction.North: tex.Sub(4, 8, 24, 3),
+		direction.South: tex.Sub(4, 8, 24, 3),
+		direction.West:  tex.Sub(4, 8, 16, 3),
+		direction.East:  tex.Sub(4, 8, 16, 3),
+		direction.Up:    tex.Sub(4, 12, 24, 16),
+		direction.Down:  tex.Sub(4, 12, 24, 16),
+	})
+	// Long sides
+	for _, z := range []float32{-8 / 16.0, 6 / 16.0} {
+		verts = appendBox(verts, -12/16.0, 3/16.0, z, 24/16.0, 6/16.0, 2/16.0, [6]render.TextureInfo{
+			direction.North: tex.Sub(2, 2, 22, 6),
+			direction.South: tex.Sub(2, 2, 22, 6),
+			direction.West:  tex.Sub(0, 2, 2, 6),
+			direction.East:  tex.Sub(0, 2, 2, 6),
+			direction.Up:    tex.Sub(2, 0, 22, 2),
+			direction.Down:  tex.Sub(2, 0, 22, 2),
+		})
+	}
+	// Ends
+	for _, x := range []float32{-12 / 16.0, 10 / 16.0} {
+		verts = appendBox(verts, x, 3/16.0, -6/16.0, 2/16.0, 6/16.0, 12/16.0, [6]render.TextureInfo{
+			direction.North: tex.Sub(0, 2, 2, 6),
+			direction.South: tex.Sub(0, 2, 2, 6),
+			direction.West:  tex.Sub(2, 2, 16, 6),
+			direction.East:  tex.Sub(2, 2, 16, 6),
+			direction.Up:    tex.Sub(2, 0, 2, 16),
+			direction.Down:  tex.Sub(2, 0, 2, 16),
+		})
+	}
+	return verts
+}
+
+// arrowVertices returns an arrow pointing along +x made from two
+// crossed planes.
+func arrowVertices() (verts []*render.StaticVertex) {
+	tex := render.RelativeTexture(render.GetTexture("entity/arrow"), 32, 32)
+	shaft := tex.Sub(0, 0, 16, 5)
+	const length, width = 0.9, 0.28
+	for _, face := range []direction.Type{direction.South, direction.Up} {
+		// Each plane is drawn a second time turned around the x
+		// axis so that it can be seen from both sides without
+		// mirroring the texture
+		for _, flip := range []float32{1, -1} {
+			for _, v := range faceVertices[face].verts {
+				x := (float32(v.X) - 0.5) * length
+				var y, z float32
+				if face == direction.South {
+					y = (float32(v.Y) - 0.5) * width
+				} else {
+					z = (float32(v.Z) - 0.5) * width
+				}
+				verts = append(verts, &render.StaticVertex{
+					X:        x,
+					Y:        y * flip,
+					Z:        z * flip,
+					Texture:  shaft,
+					TextureX: float64(v.TOffsetX),
+					TextureY: float64(v.TOffsetY),
+					R:        255,
+					G:        255,
+					B:        255,
+					A:        255,
+				})
+			}
+		}
+	}
+	return verts
+}
+
+// experienceOrbVertices returns a flat orb facing north whose size
+// depends on the amount of experience it holds.
+func experienceOrbVertices(count int) (verts []*render.StaticVertex) {
+	index := 0
+	for i, min := range []int{3, 7, 17, 37, 73, 149, 307, 617, 1237, 2477} {
+		if count >= min {
+			index = i + 1
+		}
+	}
+	tex := render.RelativeTexture(render.GetTexture("entity/experience_orb"), 64, 64).
+		Sub((index%4)*16, (index/4)*16, 16, 16)
+	const size = 0.3
+	for _, v := range faceVertices[direction.North].verts {
+		verts = append(verts, &render.StaticVertex{
+			X:        (float32(v.X) - 0.5) * size,
+			Y:        float32(v.Y)*size + 0.1,
+			Texture:  tex,
+			TextureX: float64(v.TOffsetX),
+			TextureY: float64(v.TOffsetY),
+			R:        178,
+			G:        255,
+			B:        76,
+			A:        255,
+		})
+	}
+	return verts
+}
+
+// Item
+
+// itemModelComponent draws the item of an entity's itemComponent.
+type itemModelComponent struct {
+	model *render.StaticModel
+	mat   mgl32.Mat4
+	scale float32
+
+	// Items on the ground spin and bob up and down, others
+	// face the camera
+	dropped bool
+	age     float64
+	offset  float64
+}
+
+func (i *itemModelComponent) Model() *render.StaticModel { return i.model }
+
+func esItemModelAdd(m *itemModelComponent) {
+	m.offset = rand.Float64() * math.Pi * 2
+}
+
+func esItemModelTick(m *itemModelComponent, i *itemComponent, p PositionComponent, s SizeComponent) {
+	if i.changed {
+		i.changed = false
+		m.rebuild(i.item, s)
+	}
+	if m.model == nil {
+		return
+	}
+	m.age += Client.delta
+
+	x, y, z := p.Position()
+	model := m.model
+	model.X, model.Y, model.Z = -float32(x), -float32(y), float32(z)
+
+	mat := mgl32.Translate3D(float32(x), -float32(y), float32(z))
+	if m.dropped {
+		bob := math.Sin(m.age/10+m.offset)*0.1 + 0.1
+		mat = mat.Mul4(mgl32.Translate3D(0, -float32(bob)-m.scale/2, 0)).
+			Mul4(mgl32.Rotate3DY(float32(m.age/20 + m.offset)).Mat4())
+	} else {
+		val := math.Atan2(x-render.Camera.X, z-render.Camera.Z)
+		mat = mat.Mul4(mgl32.Translate3D(0, -m.scale/2, 0)).
+			Mul4(mgl32.Rotate3DY(float32(val)).Mat4())
+	}
+	model.Matrix[0] = mat.
+		Mul4(mgl32.Scale3D(m.scale, m.scale, m.scale)).
+		Mul4(m.mat)
+}
+
+func (m *itemModelComponent) rebuild(item *ItemStack, s SizeComponent) {
+	if m.model != nil {
+		m.model.Free()
+		m.model = nil
+	}
+	if item == nil {
+		return
+	}
+	out, mat, ok := itemStaticModel(item, "ground")
+	if !ok {
+		return
+	}
+	m.mat = mat
+	if m.dropped {
+		// Blocks are drawn at a quarter of their size, flat
+		// items at half
+		m.scale = 0.5
+		if mdl := getModel(item.Type.Name()); mdl.builtIn != builtInGenerated {
+			m.scale = 0.25
+		}
+	} else {
+		b := s.Bounds()
+		m.scale = (b.Max.X() - b.Min.X()) * 2
+	}
+	m.model = render.NewStaticModel([][]*render.StaticVertex{
+		out,
+	})
+	m.model.Radius = 1
+}
+
+// Block
+
+// The number of ticks primed tnt takes to explode
+const tntFuse = 80
+
+// blockModelComponent draws a block at the entity's position.
+type blockModelComponent struct {
+	model *render.StaticModel
+	block Block
+	// Ticks until a primed block explodes, zero if it isn't
+	// primed
+	fuse float64
+}
+
+func (b *blockModelComponent) Model() *render.StaticModel { return b.model }
+
+func esBlockModelAdd(b *blockModelComponent) {
+	models := b.block.Models()
+	if len(models) == 0 {
+		return
+	}
+	b.model = render.NewStaticModel([][]*render.StaticVertex{
+		staticModelFromProcessed(models.selectModel(0), b.block),
+	})
+	b.model.Radius = 1
+}
+
+func esBlockModelTick(b *blockModelComponent, p PositionComponent) {
+	if b.model == nil {
+		return
+	}
+	x, y, z := p.Position()
+	model := b.model
+	model.X, model.Y, model.Z = -float32(x), -float32(y), float32(z)
+
+	scale := float32(1.0)
+	if b.fuse > 0 {
+		b.fuse = math.Max(0, b.fuse-Client.delta)
+		// Swells just before exploding
+		if b.fuse < 10 {
+			f := float32(1 - b.fuse/10)
+			f *= f
+			f *= f
+			scale += f * 0.3
+		}
+	}
+	model.Matrix[0] = mgl32.Translate3D(float32(x), -float32(y)-0.5, float32(z)).
+		Mul4(mgl32.Scale3D(scale, scale, scale))
+}
+
+// Item frame
+
+type itemFrameComponent struct {
+	model     *render.StaticModel
+	itemModel *render.StaticModel
+	itemMat   mgl32.Mat4
+	isMap     bool
+	// Set when the frame holds a map that hasn't been
+	// sent yet, mapsAdded is the number of maps that had
+	// been added when the frame last looked for it
+	waitingMap bool
+	mapsAdded  int
+}
+
+func (f *itemFrameComponent) Model() *render.StaticModel { return f.model }
+
+func esItemFrameTick(f *itemFrameComponent, i *itemComponent, m *metadataComponent,
+	h HangingComponent, p PositionComponent) {
+	if i.changed || f.model == nil || (f.waitingMap && f.mapsAdded != Client.maps.added) {
+		i.changed = false
+		f.rebuild(i.item)
+	}
+
+	rotation := m.meta.ItemRotation
+	x, y, z := p.Position()
+	model := f.model
+	model.X, model.Y, model.Z = -float32(x), -float32(y), float32(z)
+
+	base := mgl32.Translate3D(float32(x), -float32(y), float32(z)).
+		Mul4(mgl32.Rotate3DY(hangingAngle(h.Facing())).Mat4())
+	if f.isMap {
+		// Maps are part of the frame so the whole frame turns
+		// in quarters
+		model.Matrix[0] = base.Mul4(mgl32.Rotate3DZ(-float32(rotation%4) * (math.Pi / 2)).Mat4())
+	} else {
+		model.Matrix[0] = base
+	}
+	if f.itemModel != nil {
+		f.itemModel.X, f.itemModel.Y, f.itemModel.Z = model.X, model.Y, model.Z
+		f.itemModel.Colors[0] = model.Colors[0]
+		f.itemModel.Matrix[0] = base.Mul4(mgl32.Translate3D(0, 0, -1/16.0)).
+			Mul4(mgl32.Rotate3DZ(-float32(rotation) * (math.Pi / 4)).Mat4()).
+			Mul4(mgl32.Scale3D(0.5, 0.5, 0.5)).
+			Mul4(f.itemMat)
+	}
+}
+
+func (f *itemFrameComponent) rebuild(item *ItemStack) {
+	f.free()
+	f.isMap = item != nil && item.Type.Name() == "filled_map"
+	f.waitingMap = false
+
+	size := 12
+	var mapTex render.TextureInfo
+	if f.isMap {
+		// Maps fill the whole block
+		size = 16
+		mapTex = Client.maps.texture(int(item.rawDamage))
+		f.waitingMap = mapTex == nil
+		f.mapsAdded = Client.maps.added
+	} else if item != nil {
+		if out, mat, ok := itemStaticModel(item, "fixed"); ok {
+			f.itemMat = mat
+			f.itemModel = render.NewStaticModel([][]*render.StaticVertex{
+				out,
+			})
+			f.itemModel.Radius = 1
+		}
+	}
+	f.model = render.NewStaticModel([][]*render.StaticVertex{
+		itemFrameVertices(size, mapTex),
+	})
+	f.model.Radius = 1
+}
+
+func (f *itemFrameComponent) free() {
+	if f.model != nil {
+		f.model.Free()
+		f.model = nil
+	}
+	if f.itemModel != nil {
+		f.itemModel.Free()
+		f.itemModel = nil
+	}
+}
+
+func esItemFrameRemove(f *itemFrameComponent) {
+	// The frame's model is freed by the generic removal
+	if f.itemModel != nil {
+		f.itemModel.Free()
+	}
+}
+
+// itemFrameVertices returns a frame facing north that is size
+// pixels wide, with the map texture inside of it if not nil.
+func itemFrameVertices(size int, mapTex render.TextureInfo) (verts []*render.StaticVertex) {
+	wood := render.RelativeTexture(render.GetTexture("blocks/planks_birch"), 16, 16)
+	back := render.RelativeTexture(render.GetTexture("blocks/itemframe_background"), 16, 16)
+
+	s := float32(size) / 16
+	inner := size - 2
+	// Background, set back by half a pixel
+	bg := back.Sub((16-inner)/2, (16-inner)/2, inner, inner)
+	verts = appendBox(verts, -s/2+1/16.0, -s/2+1/16.0, 0, s-2/16.0, s-2/16.0, 0.5/16.0, [6]render.TextureInfo{
+		direction.North: bg,
+		direction.South: bg,
+	})
+	// Border
+	for _, b := range [][4]int{
+		{0, size - 1, size, 1},
+		{0, 0, size, 1},
+		{0, 1, 1, size - 2},
+		{size - 1, 1, 1, size - 2},
+	} {
+		x, y, w, h := b[0], b[1], b[2], b[3]
+		verts = appendBox(verts,
+			float32(x)/16-s/2, float32(y)/16-s/2, -0.5/16.0,
+			float32(w)/16, float32(h)/16, 1/16.0,
+			[6]render.TextureInfo{
+				direction.North: wood.Sub(x, y, w, h),
+				direction.South: wood.Sub(x, y, w, h),
+				direction.West:  wood.Sub(x, y, 1, h),
+				direction.East:  wood.Sub(x, y, 1, h),
+				direction.Up:    wood.Sub(x, y, w, 1),
+				direction.Down:  wood.Sub(x, y, w, 1),
+			},
+		)
+	}
+
+	if mapTex == nil {
+		return verts
+	}
+	ms := s - 2/16.0
+	for _, v := range faceVertices[direction.North].verts {
+		verts = append(verts, &render.StaticVertex{
+			X:        (float32(v.X) - 0.5) * ms,
+			Y:        (float32(v.Y) - 0.5) * ms,
+			Z:        -0.1 / 16.0,
+			Texture:  mapTex,
+			TextureX: float64(v.TOffsetX),
+			TextureY: float64(v.TOffsetY),
+			R:        255,
+			G:        255,
+			B:        255,
+			A:        255,
+		})
+	}
+	return verts
+}
+
+// Painting
+
+// paintingArt is the location of a painting in the paintings
+// texture, in pixels.
+type paintingArt struct {
+	x, y, w, h int
+}
+
+var paintingArts = map[string]paintingArt{
+	"Kebab":         {0, 0, 16, 16},
+	"Aztec":         {16, 0, 16, 16},
+	"Alban":         {32, 0, 16, 16},
+	"Aztec2":        {48, 0, 16, 16},
+	"Bomb":          {64, 0, 16, 16},
+	"Plant":         {80, 0, 16, 16},
+	"Wasteland":     {96, 0, 16, 16},
+	"Pool":          {0, 32, 32, 16},
+	"Courbet":       {32, 32, 32, 16},
+	"Sea":           {64, 32, 32, 16},
+	"Sunset":        {96, 32, 32, 16},
+	"Creebet":       {128, 32, 32, 16},
+	"Wanderer":      {0, 64, 16, 32},
+	"Graham":        {16, 64, 16, 32},
+	"Match":         {0, 128, 32, 32},
+	"Bust":          {32, 128, 32, 32},
+	"Stage":         {64, 128, 32, 32},
+	"Void":          {96, 128, 32, 32},
+	"SkullAndRoses": {128, 128, 32, 32},
+	"Wither":        {160, 128, 32, 32},
+	"Fighters":      {0, 96, 64, 32},
+	"Pointer":       {0, 192, 64, 64},
+	"Pigscene":      {64, 192, 64, 64},
+	"BurningSkull":  {128, 192, 64, 64},
+	"Skeleton":      {192, 64, 64, 48},
+	"DonkeyKong":    {192, 112, 64, 48},
+}
+
+type paintingComponent struct {
+	model *render.StaticModel
+	art   paintingArt
+}
+
+func (p *paintingComponent) Model() *render.StaticModel { return p.model }
+
+func esPaintingAdd(pc *paintingComponent, h HangingComponent, p PositionComponent) {
+	// Paintings with an even size are centered on the edge
+	// between two blocks
+	offset := func(size int) float64 {
+		if size == 32 || size == 64 {
+			return 0.5
+		}
+		return 0
+	}
+	x, y, z := p.Position()
+	ox, _, oz := h.Facing().CounterClockwise().Offset()
+	p.SetPosition(
+		x+offset(pc.art.w)*float64(ox),
+		y+offset(pc.art.h),
+		z+offset(pc.art.w)*float64(oz),
+	)
+
+	tex := render.RelativeTexture(render.GetTexture("painting/paintings_kristoffer_zetterstrand"), 256, 256)
+	art := pc.art
+	w, ht := float32(art.w)/16, float32(art.h)/16
+	pc.model = render.NewStaticModel([][]*render.StaticVertex{
+		appendBox(nil, -w/2, -ht/2, -0.5/16.0, w, ht, 1/16.0, [6]render.TextureInfo{
+			direction.North: tex.Sub(art.x, art.y, art.w, art.h),
+			direction.South: tex.Sub(192, 0, 16, 16),
+			direction.West:  tex.Sub(208, 0, 1, 16),
+			direction.East:  tex.Sub(208, 0, 1, 16),
+			direction.Up:    tex.Sub(192, 0, 16, 1),
+			direction.Down:  tex.Sub(192, 0, 16, 1),
+		}),
+	})
+	pc.model.Radius = float32(math.Max(float64(w), float64(ht)))
+}
+
+func esPaintingTick(pc *paintingComponent, h HangingComponent, p PositionComponent) {
+	x, y, z := p.Position()
+	model := pc.model
+	model.X, model.Y, model.Z = -float32(x), -float32(y), float32(z)
+	model.Matrix[0] = mgl32.Translate3D(float32(x), -float32(y), float32(z)).
+		Mul4(mgl32.Rotate3DY(hangingAngle(h.Facing())).Mat4())
+}
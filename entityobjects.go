@@ -0,0 +1,433 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steven
+
+import (
+	"github.com/thinkofdeath/steven/protocol"
+	"github.com/thinkofdeath/steven/render"
+	"github.com/thinkofdeath/steven/type/direction"
+	"github.com/thinkofdeath/steven/type/vmath"
+)
+
+// objectTypes maps the type ids used by SpawnObject to a constructor
+// for the object. The constructor is passed the object's data field
+// whose meaning depends on the type.
+var objectTypes = map[int]func(data int) Entity{
+	1:  newBoat,
+	2:  newDroppedItem,
+	10: newMinecart,
+	50: newPrimedTNT,
+	51: newEnderCrystal,
+	60: newArrow,
+	61: newThrownItem(61, 332, 0.25),
+	62: newThrownItem(62, 344, 0.25),
+	63: newThrownItem(63, 385, 1.0),
+	64: newThrownItem(64, 385, 0.3125),
+	65: newThrownItem(65, 368, 0.25),
+	66: newWitherSkull,
+	70: newFallingBlock,
+	71: newItemFrame,
+	72: newThrownItem(72, 381, 0.25),
+	73: newThrownPotion,
+	75: newThrownItem(75, 384, 0.25),
+	76: newThrownItem(76, 401, 0.25),
+	77: newLeashKnot,
+	78: newArmorStand,
+	90: newFishingHook,
+}
+
+// horizontalFacing converts the horizontal index used by the
+// protocol for hanging entities into a direction.
+func horizontalFacing(i int) direction.Type {
+	switch i & 3 {
+	case 0:
+		return direction.South
+	case 1:
+		return direction.West
+	case 2:
+		return direction.North
+	}
+	return direction.East
+}
+
+func newBoat(data int) Entity {
+	type boat struct {
+		networkComponent
+		positionComponent
+		rotationComponent
+		targetRotationComponent
+		targetPositionComponent
+		velocityComponent
+		passengerComponent
+		sizeComponent
+
+		mountComponent
+		objectModelComponent
+	}
+	b := &boat{}
+	b.NetworkID = 1
+	b.bounds = vmath.NewAABB(-0.75, 0, -0.75, 1.5, 0.6, 1.5)
+	b.drag = 0.99
+	b.mountedOffset = -0.3
+	b.build = boatVertices
+	return b
+}
+
+func newDroppedItem(data int) Entity {
+	type droppedItem struct {
+		networkComponent
+		positionComponent
+		rotationComponent
+		targetRotationComponent
+		targetPositionComponent
+		velocityComponent
+		passengerComponent
+		sizeComponent
+
+		metadataComponent
+		itemComponent
+		itemModelComponent
+	}
+	d := &droppedItem{}
+	d.NetworkID = 2
+	d.bounds = vmath.NewAABB(-0.125, 0, -0.125, 0.25, 0.25, 0.25)
+	d.schema = itemSchema
+	d.gravity, d.drag = 0.04, 0.98
+	d.dropped = true
+	return d
+}
+
+func newMinecart(data int) Entity {
+	type minecart struct {
+		networkComponent
+		positionComponent
+		rotationComponent
+		targetRotationComponent
+		targetPositionComponent
+		velocityComponent
+		passengerComponent
+		sizeComponent
+
+		mountComponent
+		objectModelComponent
+	}
+	m := &minecart{}
+	m.NetworkID = 10
+	m.bounds = vmath.NewAABB(-0.49, 0, -0.49, 0.98, 0.7, 0.98)
+	m.gravity, m.drag = 0.04, 0.96
+	// Passengers sit inside the minecart
+	m.mountedOffset = 0
+	m.build = func() []*render.StaticVertex {
+		return minecartVertices(minecartContents(data))
+	}
+	return m
+}
+
+// minecartContents returns the block displayed inside a minecart of
+// the type, nil for empty minecarts.
+func minecartContents(ty int) Block {
+	switch ty {
+	case 1:
+		return Blocks.Chest.Base
+	case 2:
+		return Blocks.Furnace.Base
+	case 3:
+		return Blocks.TNT.Base
+	case 4:
+		return Blocks.MobSpawner.Base
+	case 5:
+		return Blocks.Hopper.Base
+	case 6:
+		return Blocks.CommandBlock.Base
+	}
+	return nil
+}
+
+func newPrimedTNT(data int) Entity {
+	type primedTNT struct {
+		networkComponent
+		positionComponent
+		rotationComponent
+		targetRotationComponent
+		targetPositionComponent
+		velocityComponent
+		passengerComponent
+		sizeComponent
+
+		blockModelComponent
+	}
+	t := &primedTNT{}
+	t.NetworkID = 50
+	t.bounds = vmath.NewAABB(-0.49, 0, -0.49, 0.98, 0.98, 0.98)
+	t.block = Blocks.TNT.Base
+	t.fuse = tntFuse
+	t.gravity, t.drag = 0.04, 0.98
+	return t
+}
+
+func newEnderCrystal(data int) Entity {
+	type enderCrystal struct {
+		networkComponent
+		positionComponent
+		rotationComponent
+		targetRotationComponent
+		targetPositionComponent
+		velocityComponent
+		passengerComponent
+		sizeComponent
+
+		debugComponent
+	}
+	e := &enderCrystal{
+		debugComponent: debugComponent{211, 94, 255},
+	}
+	e.NetworkID = 51
+	e.bounds = vmath.NewAABB(-1, 0, -1, 2, 2, 2)
+	e.drag = 1
+	return e
+}
+
+func newArrow(data int) Entity {
+	type arrow struct {
+		networkComponent
+		positionComponent
+		rotationComponent
+		targetRotationComponent
+		targetPositionComponent
+		velocityComponent
+		passengerComponent
+		sizeComponent
+
+		objectModelComponent
+	}
+	a := &arrow{}
+	a.NetworkID = 60
+	a.bounds = vmath.NewAABB(-0.25, 0, -0.25, 0.5, 0.5, 0.5)
+	a.build = arrowVertices
+	a.pointsForward = true
+	a.gravity, a.drag = 0.05, 0.99
+	return a
+}
+
+// newThrownItem returns a constructor for a projectile that is
+// drawn as the item with the id.
+func newThrownItem(networkID, id int, size float64) func(data int) Entity {
+	return func(data int) Entity {
+		return newProjectileItem(networkID, id, 0, size, 0.03)
+	}
+}
+
+func newThrownPotion(data int) Entity {
+	// The data is the potion's damage value. Potions fall
+	// quicker than other thrown items
+	return newProjectileItem(73, 373, data, 0.25, 0.05)
+}
+
+func newProjectileItem(networkID, id, damage int, size, gravity float64) Entity {
+	type projectile struct {
+		networkComponent
+		positionComponent
+		rotationComponent
+		targetRotationComponent
+		targetPositionComponent
+		velocityComponent
+		passengerComponent
+		sizeComponent
+
+		itemComponent
+		itemModelComponent
+	}
+	p := &projectile{}
+	p.NetworkID = networkID
+	p.gravity, p.drag = gravity, 0.99
+	p.bounds = vmath.NewAABB(
+		-float32(size)/2, 0, -float32(size)/2,
+		float32(size), float32(size), float32(size),
+	)
+	p.SetItem(ItemStackFromProtocol(protocol.ItemStack{
+		ID:     int16(id),
+		Count:  1,
+		Damage: int16(damage),
+	}))
+	return p
+}
+
+func newWitherSkull(data int) Entity {
+	type witherSkull struct {
+		networkComponent
+		positionComponent
+		rotationComponent
+		targetRotationComponent
+		targetPositionComponent
+		velocityComponent
+		passengerComponent
+		sizeComponent
+
+		debugComponent
+	}
+	w := &witherSkull{
+		debugComponent: debugComponent{40, 40, 40},
+	}
+	w.NetworkID = 66
+	// Skulls aren't affected by gravity
+	w.drag = 0.95
+	w.bounds = vmath.NewAABB(-0.15625, 0, -0.15625, 0.3125, 0.3125, 0.3125)
+	return w
+}
+
+func newFallingBlock(data int) Entity {
+	type fallingBlock struct {
+		networkComponent
+		positionComponent
+		rotationComponent
+		targetRotationComponent
+		targetPositionComponent
+		velocityComponent
+		passengerComponent
+		sizeComponent
+
+		blockModelComponent
+	}
+	f := &fallingBlock{}
+	f.NetworkID = 70
+	f.bounds = vmath.NewAABB(-0.49, 0, -0.49, 0.98, 0.98, 0.98)
+	f.block = GetBlockByStateID(data)
+	f.gravity, f.drag = 0.04, 0.98
+	return f
+}
+
+func newItemFrame(data int) Entity {
+	type itemFrame struct {
+		networkComponent
+		positionComponent
+		rotationComponent
+		sizeComponent
+
+		hangingComponent
+		metadataComponent
+		itemComponent
+		itemFrameComponent
+	}
+	f := &itemFrame{}
+	f.NetworkID = 71
+	f.bounds = vmath.NewAABB(-0.375, -0.375, -0.375, 0.75, 0.75, 0.75)
+	f.facing = horizontalFacing(data)
+	f.schema = itemFrameSchema
+	return f
+}
+
+func newLeashKnot(data int) Entity {
+	type leashKnot struct {
+		networkComponent
+		positionComponent
+		rotationComponent
+		sizeComponent
+
+		debugComponent
+	}
+	l := &leashKnot{
+		debugComponent: debugComponent{130, 95, 55},
+	}
+	l.NetworkID = 77
+	l.bounds = vmath.NewAABB(-0.1875, 0, -0.1875, 0.375, 0.5, 0.375)
+	return l
+}
+
+func newArmorStand(data int) Entity {
+	type armorStand struct {
+		networkComponent
+		positionComponent
+		rotationComponent
+		targetRotationComponent
+		targetPositionComponent
+		velocityComponent
+		passengerComponent
+		sizeComponent
+
+		debugComponent
+	}
+	a := &armorStand{
+		debugComponent: debugComponent{160, 130, 90},
+	}
+	a.NetworkID = 78
+	a.bounds = vmath.NewAABB(-0.25, 0, -0.25, 0.5, 1.975, 0.5)
+	return a
+}
+
+func newFishingHook(data int) Entity {
+	type fishingHook struct {
+		networkComponent
+		positionComponent
+		rotationComponent
+		targetRotationComponent
+		targetPositionComponent
+		velocityComponent
+		passengerComponent
+		sizeComponent
+
+		debugComponent
+	}
+	f := &fishingHook{
+		debugComponent: debugComponent{200, 200, 200},
+	}
+	f.NetworkID = 90
+	f.bounds = vmath.NewAABB(-0.125, 0, -0.125, 0.25, 0.25, 0.25)
+	f.gravity, f.drag = 0.04, 0.92
+	return f
+}
+
+func newPainting(title string, facing direction.Type) Entity {
+	type painting struct {
+		networkComponent
+		positionComponent
+		rotationComponent
+		sizeComponent
+
+		hangingComponent
+		paintingComponent
+	}
+	p := &painting{}
+	art, ok := paintingArts[title]
+	if !ok {
+		art = paintingArts["Kebab"]
+	}
+	p.art = art
+	p.facing = facing
+	w, h := float32(p.art.w)/16, float32(p.art.h)/16
+	p.bounds = vmath.NewAABB(-w/2, -h/2, -w/2, w, h, w)
+	return p
+}
+
+func newExperienceOrb(count int) Entity {
+	type experienceOrb struct {
+		networkComponent
+		positionComponent
+		rotationComponent
+		targetPositionComponent
+		velocityComponent
+		passengerComponent
+		sizeComponent
+
+		objectModelComponent
+	}
+	e := &experienceOrb{}
+	e.bounds = vmath.NewAABB(-0.25, 0, -0.25, 0.5, 0.5, 0.5)
+	e.build = func() []*render.StaticVertex {
+		return experienceOrbVertices(count)
+	}
+	e.faceCamera = true
+	e.gravity, e.drag = 0.03, 0.98
+	return e
+}
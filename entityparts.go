@@ -18,6 +18,7 @@ import (
 	"math"
 
 	"github.com/thinkofdeath/steven/protocol"
+	"github.com/thinkofdeath/steven/type/direction"
 	"github.com/thinkofdeath/steven/type/vmath"
 )
 
@@ -65,6 +66,12 @@ type targetPositionComponent struct {
 	stillTime  float64
 	pX, pY, pZ float64
 	sX, sY, sZ float64
+
+	// Movement predicted from the entity's velocity since the
+	// target was last changed and the part of it included in
+	// the current position
+	predX, predY, predZ float64
+	aX, aY, aZ          float64
 }
 
 func (p *targetPositionComponent) TargetPosition() (x, y, z float64) {
@@ -80,6 +87,65 @@ type TargetPositionComponent interface {
 	SetTargetPosition(x, y, z float64)
 }
 
+// Velocity
+
+type velocityComponent struct {
+	// In blocks per tick
+	VX, VY, VZ float64
+	// Per tick, zero uses the values for living entities
+	gravity, drag float64
+}
+
+func (v *velocityComponent) Velocity() (x, y, z float64) {
+	return v.VX, v.VY, v.VZ
+}
+
+func (v *velocityComponent) SetVelocity(x, y, z float64) {
+	v.VX, v.VY, v.VZ = x, y, z
+}
+
+type VelocityComponent interface {
+	Velocity() (x, y, z float64)
+	SetVelocity(x, y, z float64)
+}
+
+// Passenger
+
+type passengerComponent struct {
+	vehicleID int
+	riding    bool
+}
+
+func (p *passengerComponent) Vehicle() (id int, ok bool) {
+	return p.vehicleID, p.riding
+}
+
+// SetVehicle mounts the entity on the vehicle with the network id,
+// -1 dismounts it.
+func (p *passengerComponent) SetVehicle(id int) {
+	p.vehicleID = id
+	p.riding = id != -1
+}
+
+type PassengerComponent interface {
+	Vehicle() (id int, ok bool)
+	SetVehicle(id int)
+}
+
+// Mount
+
+// mountComponent overrides the height passengers sit at for
+// vehicles that don't seat them on top.
+type mountComponent struct {
+	mountedOffset float64
+}
+
+func (m *mountComponent) MountedOffset() float64 { return m.mountedOffset }
+
+type MountComponent interface {
+	MountedOffset() float64
+}
+
 // Rotation
 
 type rotationComponent struct {
@@ -158,6 +224,38 @@ type PlayerComponent interface {
 	UUID() protocol.UUID
 }
 
+// Item
+
+type itemComponent struct {
+	item    *ItemStack
+	changed bool
+}
+
+func (i *itemComponent) Item() *ItemStack { return i.item }
+func (i *itemComponent) SetItem(item *ItemStack) {
+	i.item = item
+	i.changed = true
+}
+
+type ItemComponent interface {
+	Item() *ItemStack
+	SetItem(item *ItemStack)
+}
+
+// Hanging
+
+type hangingComponent struct {
+	// The direction the entity faces, away from the
+	// block it hangs on
+	facing direction.Type
+}
+
+func (h *hangingComponent) Facing() direction.Type { return h.facing }
+
+type HangingComponent interface {
+	Facing() direction.Type
+}
+
 // Debug
 
 type debugComponent struct {
@@ -23,9 +23,12 @@ import (
 )
 
 func init() {
+	addSystem(entitysys.Tick, esVelocity)
 	addSystem(entitysys.Tick, esMoveToTarget)
+	addSystem(entitysys.Tick, esRide)
 	addSystem(entitysys.Tick, esRotateToTarget)
-	addSystem(entitysys.Tick, esDrawOutline)
+	addSystem(entitysys.Tick, esDrawOutline, entitysys.Not(entitysys.Type((*MetadataComponent)(nil))))
+	addSystem(entitysys.Tick, esDrawMobOutline)
 	addSystem(entitysys.Tick, esLightModel)
 	addSystem(entitysys.Tick, esMoveChunk)
 }
@@ -46,6 +49,14 @@ func esDrawOutline(p PositionComponent, s SizeComponent, d DebugComponent) {
 	)
 }
 
+// Mobs aren't drawn while they are invisible
+func esDrawMobOutline(p PositionComponent, s SizeComponent, d DebugComponent, m MetadataComponent) {
+	if m.Metadata().Flags.Invisible() {
+		return
+	}
+	esDrawOutline(p, s, d)
+}
+
 // updates the Colors of the model to fake lighting
 func esLightModel(p PositionComponent, s SizeComponent, m interface {
 	Model() *render.StaticModel
@@ -66,7 +77,7 @@ func esLightModel(p PositionComponent, s SizeComponent, m interface {
 			for x := bounds.Min.X() - 1; x <= bounds.Max.X()+1; x++ {
 				bx, by, bz := int(math.Floor(float64(x))), int(math.Floor(float64(y))), int(math.Floor(float64(z)))
 				bl := float64(chunkMap.BlockLight(bx, by, bz)) / 16
-				sl := float64(chunkMap.SkyLight(bx, by, bz)) / 16
+				sl := float64(chunkMap.SkyLight(bx, by, bz)) / 16 * float64(render.SkyBrightness)
 
 				dist := float64(c.Sub(mgl32.Vec3{float32(bx) + 0.5, float32(by) + 0.5, float32(bz) + 0.5}).Len())
 
@@ -76,6 +87,8 @@ func esLightModel(p PositionComponent, s SizeComponent, m interface {
 		}
 	}
 	light /= count
+	// Night vision brightens the light but not any tint
+	light += (1 - light) * float64(render.NightVision)
 	model := m.Model()
 	for i := range model.Colors {
 		model.Colors[i] = [4]float32{
@@ -110,12 +123,19 @@ func esMoveToTarget(p PositionComponent, t *targetPositionComponent) {
 	px, py, pz := p.Position()
 	tx, ty, tz := t.TargetPosition()
 
-	if t.pX != tx || t.pY != ty || t.pZ != tz || t.time >= 4 {
+	if t.pX != tx || t.pY != ty || t.pZ != tz {
 		t.sX, t.sY, t.sZ = px, py, pz
 		t.time = 0
 		t.pX = tx
 		t.pY = ty
 		t.pZ = tz
+	} else if t.time >= 4 {
+		// The prediction is added back on below
+		t.sX, t.sY, t.sZ = px-t.aX, py-t.aY, pz-t.aZ
+		t.time = 0
+		t.pX = tx
+		t.pY = ty
+		t.pZ = tz
 	}
 	sx, sy, sz := t.sX, t.sY, t.sZ
 
@@ -126,7 +146,118 @@ func esMoveToTarget(p PositionComponent, t *targetPositionComponent) {
 	px = sx + dx*(1/4.0)*t.time
 	py = sy + dy*(1/4.0)*t.time
 	pz = sz + dz*(1/4.0)*t.time
-	p.SetPosition(px, py, pz)
+	p.SetPosition(px+t.predX, py+t.predY, pz+t.predZ)
+	t.aX, t.aY, t.aZ = t.predX, t.predY, t.predZ
+}
+
+const (
+	livingGravity = 0.08
+	livingDrag    = 0.98
+)
+
+// Predicts where the entity has moved to since the server last sent
+// its position using its velocity, stopping when it hits a block.
+func esVelocity(t *targetPositionComponent, v *velocityComponent) {
+	if t.X != t.pX || t.Y != t.pY || t.Z != t.pZ {
+		// The server has moved the entity so the prediction
+		// starts again from there
+		t.predX, t.predY, t.predZ = 0, 0, 0
+	}
+	if v.VX == 0 && v.VY == 0 && v.VZ == 0 {
+		return
+	}
+	gravity, drag := v.gravity, v.drag
+	if drag == 0 {
+		gravity, drag = livingGravity, livingDrag
+	}
+	// The velocity is per a tick and delta is in 60ths of
+	// a second
+	ticks := Client.delta / 3
+
+	x, y, z := t.X+t.predX, t.Y+t.predY, t.Z+t.predZ
+	block := func(x, y, z float64) Block {
+		return chunkMap.Block(int(math.Floor(x)), int(math.Floor(y)), int(math.Floor(z)))
+	}
+	if block(x, y+v.VY*ticks, z).Collidable() {
+		v.VY = 0
+	} else {
+		t.predY += v.VY * ticks
+		y += v.VY * ticks
+	}
+	if block(x+v.VX*ticks, y, z+v.VZ*ticks).Collidable() {
+		v.VX, v.VZ = 0, 0
+	} else {
+		t.predX += v.VX * ticks
+		t.predZ += v.VZ * ticks
+	}
+
+	horizontal := drag
+	if v.drag == 0 {
+		// Living entities lose their speed quicker on the
+		// ground
+		horizontal = 0.91
+		if block(x, y-0.01, z).Collidable() {
+			horizontal *= 0.6
+		}
+	}
+	f, hf := math.Pow(drag, ticks), math.Pow(horizontal, ticks)
+	v.VY = (v.VY - gravity*ticks) * f
+	v.VX *= hf
+	v.VZ *= hf
+	// Matches the cutoff the server uses
+	if math.Abs(v.VX) < 0.005 {
+		v.VX = 0
+	}
+	if math.Abs(v.VZ) < 0.005 {
+		v.VZ = 0
+	}
+}
+
+// The offset of a player's feet from the seat of the vehicle
+// they are riding.
+const playerRidingOffset = -0.35
+
+// mountedPosition returns the position passengers of the vehicle sit
+// at.
+func mountedPosition(vehicle Entity) (x, y, z float64, ok bool) {
+	p, ok := vehicle.(PositionComponent)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	x, y, z = p.Position()
+	if m, ok := vehicle.(MountComponent); ok {
+		y += m.MountedOffset()
+	} else if s, ok := vehicle.(SizeComponent); ok {
+		y += float64(s.Bounds().Max.Y()) * 0.75
+	}
+	return x, y, z, true
+}
+
+// Keeps the entity on the vehicle it is riding. The server doesn't
+// send the position of passengers so this is the only thing moving
+// them.
+func esRide(e Entity, p PositionComponent, t *targetPositionComponent, r *passengerComponent) {
+	id, ok := r.Vehicle()
+	if !ok {
+		return
+	}
+	vehicle, ok := Client.entities.entities[id]
+	if !ok {
+		// The vehicle has been removed
+		r.SetVehicle(-1)
+		return
+	}
+	x, y, z, ok := mountedPosition(vehicle)
+	if !ok {
+		return
+	}
+	if _, ok := e.(PlayerComponent); ok {
+		y += playerRidingOffset
+	}
+	p.SetPosition(x, y, z)
+	t.SetTargetPosition(x, y, z)
+	t.pX, t.pY, t.pZ = x, y, z
+	t.predX, t.predY, t.predZ = 0, 0, 0
 }
 
 // Smoothly rotates the entity from its current rotation to the target
@@ -64,23 +64,40 @@ func (h handler) Handle(packet interface{}) {
 }
 
 func (handler) ServerMessage(msg *protocol.ServerMessage) {
+	Client.scoreboard.resolveScores(msg.Message)
 	log.Printf("MSG(%d): %s\n", msg.Type, msg.Message.Value)
 	Client.chat.Add(msg.Message)
+	if bot != nil {
+		bot.message(msg.Message)
+	}
 }
 
 func (handler) JoinGame(j *protocol.JoinGame) {
 	clearChunks()
+	Client.chunkCache.setDimension(int(j.Dimension))
 	sendPluginMessage(&pmMinecraftBrand{
 		Brand: "Steven",
 	})
 	Client.GameMode = gameMode(j.Gamemode & 0x7)
 	Client.HardCore = j.Gamemode&0x8 != 0
+	Client.Dimension = int(j.Dimension)
+	Client.entityID = int(j.EntityID)
 }
 
 func (handler) Respawn(r *protocol.Respawn) {
 	clearChunks()
+	Client.chunkCache.setDimension(int(r.Dimension))
 	Client.GameMode = gameMode(r.Gamemode & 0x7)
 	Client.HardCore = r.Gamemode&0x8 != 0
+	Client.Dimension = int(r.Dimension)
+	Client.vehicleID = -1
+	// The server sends the effects again for the new player
+	Client.effects = effectsComponent{}
+	Client.physics = playerPhysics{}
+}
+
+func (handler) TimeUpdate(t *protocol.TimeUpdate) {
+	Client.worldTime.update(t)
 }
 
 func (handler) Disconnect(d *protocol.Disconnect) {
@@ -111,6 +128,19 @@ func (handler) Teleport(t *protocol.TeleportPlayer) {
 	Client.Z = calculateTeleport(teleportRelZ, t.Flags, Client.Z, t.Z)
 	Client.Yaw = calculateTeleport(teleportRelYaw, t.Flags, Client.Yaw, float64(-t.Yaw)*(math.Pi/180))
 	Client.Pitch = calculateTeleport(teleportRelPitch, t.Flags, Client.Pitch, -float64(t.Pitch)*(math.Pi/180)+math.Pi)
+	// Only the relative axes keep their motion
+	p := &Client.physics
+	if t.Flags&byte(teleportRelX) == 0 {
+		p.motionX = 0
+	}
+	if t.Flags&byte(teleportRelY) == 0 {
+		p.motionY = 0
+	}
+	if t.Flags&byte(teleportRelZ) == 0 {
+		p.motionZ = 0
+	}
+	p.lastX, p.lastY, p.lastZ = Client.X, Client.Y, Client.Z
+	p.partial = 1
 	Client.checkGround()
 	Client.network.Write(&protocol.PlayerPositionLook{
 		X:        t.X,
@@ -132,6 +162,7 @@ func (handler) ChunkData(c *protocol.ChunkData) {
 		pos := chunkPosition{int(c.ChunkX), int(c.ChunkZ)}
 		c, ok := chunkMap[pos]
 		if ok {
+			Client.chunkCache.save(c)
 			c.free()
 			delete(chunkMap, pos)
 		}
@@ -281,6 +312,7 @@ func (handler) SpawnPlayer(s *protocol.SpawnPlayer) {
 		r.SetTargetPitch((float64(s.Pitch) / 256) * math.Pi * 2)
 	}
 	e.(PlayerComponent).SetUUID(s.UUID)
+	e.(MetadataComponent).UpdateMetadata(s.Metadata)
 	e.(NetworkComponent).SetEntityID(int(s.EntityID))
 	Client.entities.add(int(s.EntityID), e)
 }
@@ -313,12 +345,84 @@ func (handler) SpawnMob(s *protocol.SpawnMob) {
 		r.SetTargetYaw((float64(s.Yaw) / 256) * math.Pi * 2)
 		r.SetTargetPitch((float64(s.Pitch) / 256) * math.Pi * 2)
 	}
+	setVelocity(e, s.VelocityX, s.VelocityY, s.VelocityZ)
+
+	if m, ok := e.(MetadataComponent); ok {
+		m.UpdateMetadata(s.Metadata)
+	}
+
+	e.(NetworkComponent).SetEntityID(int(s.EntityID))
+
+	Client.entities.add(int(s.EntityID), e)
+}
+
+func (handler) SpawnObject(s *protocol.SpawnObject) {
+	ot, ok := objectTypes[int(s.Type)]
+	if !ok {
+		return
+	}
+	e := ot(int(s.Data))
+	if p, ok := e.(PositionComponent); ok {
+		p.SetPosition(
+			float64(s.X)/32,
+			float64(s.Y)/32,
+			float64(s.Z)/32,
+		)
+	}
+	if p, ok := e.(TargetPositionComponent); ok {
+		p.SetTargetPosition(
+			float64(s.X)/32,
+			float64(s.Y)/32,
+			float64(s.Z)/32,
+		)
+	}
+	if r, ok := e.(RotationComponent); ok {
+		r.SetYaw((float64(s.Yaw) / 256) * math.Pi * 2)
+		r.SetPitch((float64(s.Pitch) / 256) * math.Pi * 2)
+	}
+	if r, ok := e.(TargetRotationComponent); ok {
+		r.SetTargetYaw((float64(s.Yaw) / 256) * math.Pi * 2)
+		r.SetTargetPitch((float64(s.Pitch) / 256) * math.Pi * 2)
+	}
+	setVelocity(e, s.VelocityX, s.VelocityY, s.VelocityZ)
+
+	e.(NetworkComponent).SetEntityID(int(s.EntityID))
+
+	Client.entities.add(int(s.EntityID), e)
+}
 
+func (handler) SpawnPainting(s *protocol.SpawnPainting) {
+	e := newPainting(s.Title, horizontalFacing(int(s.Direction)))
+	e.(PositionComponent).SetPosition(
+		float64(s.Location.X()),
+		float64(s.Location.Y()),
+		float64(s.Location.Z()),
+	)
 	e.(NetworkComponent).SetEntityID(int(s.EntityID))
 
 	Client.entities.add(int(s.EntityID), e)
 }
 
+func (handler) SpawnExperienceOrb(s *protocol.SpawnExperienceOrb) {
+	e := newExperienceOrb(int(s.Count))
+	x, y, z := float64(s.X)/32, float64(s.Y)/32, float64(s.Z)/32
+	e.(PositionComponent).SetPosition(x, y, z)
+	e.(TargetPositionComponent).SetTargetPosition(x, y, z)
+	e.(NetworkComponent).SetEntityID(int(s.EntityID))
+
+	Client.entities.add(int(s.EntityID), e)
+}
+
+func (handler) EntityMetadata(m *protocol.EntityMetadata) {
+	e, ok := Client.entities.entities[int(m.EntityID)]
+	if !ok {
+		return
+	}
+	if mc, ok := e.(MetadataComponent); ok {
+		mc.UpdateMetadata(m.Metadata)
+	}
+}
+
 func (handler) EntityTeleport(t *protocol.EntityTeleport) {
 	e, ok := Client.entities.entities[int(t.EntityID)]
 	if !ok {
@@ -407,6 +511,96 @@ func relMove(e Entity, dx, dy, dz float64) {
 	}
 }
 
+func (handler) EntityVelocity(v *protocol.EntityVelocity) {
+	if int(v.EntityID) == Client.entityID {
+		p := &Client.physics
+		p.motionX = float64(v.VelocityX) / 8000
+		p.motionY = float64(v.VelocityY) / 8000
+		p.motionZ = float64(v.VelocityZ) / 8000
+		return
+	}
+	e, ok := Client.entities.entities[int(v.EntityID)]
+	if !ok {
+		return
+	}
+	setVelocity(e, v.VelocityX, v.VelocityY, v.VelocityZ)
+}
+
+// setVelocity sets the velocity of the entity from the protocol's
+// format of 1/8000 of a block per a tick.
+func setVelocity(e Entity, vx, vy, vz int16) {
+	if v, ok := e.(VelocityComponent); ok {
+		v.SetVelocity(
+			float64(vx)/8000,
+			float64(vy)/8000,
+			float64(vz)/8000,
+		)
+	}
+}
+
+func (handler) EntityAttach(a *protocol.EntityAttach) {
+	if a.Leash {
+		// Leashes aren't drawn
+		return
+	}
+	if int(a.EntityID) == Client.entityID {
+		Client.vehicleID = int(a.Vehicle)
+		return
+	}
+	e, ok := Client.entities.entities[int(a.EntityID)]
+	if !ok {
+		return
+	}
+	if p, ok := e.(PassengerComponent); ok {
+		p.SetVehicle(int(a.Vehicle))
+	}
+}
+
+// entityEffects returns the effects of the entity with the network
+// id, including the player's own.
+func entityEffects(id int) (EffectsComponent, bool) {
+	if id == Client.entityID {
+		return &Client.effects, true
+	}
+	e, ok := Client.entities.entities[id]
+	if !ok {
+		return nil, false
+	}
+	ef, ok := e.(EffectsComponent)
+	return ef, ok
+}
+
+func (handler) EntityEffect(e *protocol.EntityEffect) {
+	ef, ok := entityEffects(int(e.EntityID))
+	if !ok {
+		return
+	}
+	ef.AddEffect(statusEffect{
+		Type:          effectType(e.EffectID),
+		Amplifier:     int(e.Amplifier),
+		Duration:      float64(e.Duration),
+		HideParticles: e.HideParticles,
+	})
+}
+
+func (handler) EntityRemoveEffect(e *protocol.EntityRemoveEffect) {
+	ef, ok := entityEffects(int(e.EntityID))
+	if !ok {
+		return
+	}
+	ef.RemoveEffect(effectType(e.EffectID))
+}
+
+func (handler) EntityProperties(p *protocol.EntityProperties) {
+	ef, ok := entityEffects(int(p.EntityID))
+	if !ok {
+		return
+	}
+	for _, prop := range p.Properties {
+		ef.SetAttribute(prop.Key, prop.Value, prop.Modifiers)
+	}
+}
+
 func (handler) DestroyEntities(e *protocol.EntityDestroy) {
 	for _, id := range e.EntityIDs {
 		Client.entities.remove(int(id))
@@ -519,7 +713,86 @@ func (handler) PlayerListInfo(p *protocol.PlayerInfo) {
 	}
 }
 
+func (handler) ScoreboardObjective(p *protocol.ScoreboardObjective) {
+	Client.scoreboard.updateObjective(p)
+}
+
+func (handler) UpdateScore(p *protocol.UpdateScore) {
+	Client.scoreboard.updateScore(p)
+}
+
+func (handler) ScoreboardDisplay(p *protocol.ScoreboardDisplay) {
+	Client.scoreboard.setDisplay(p)
+}
+
+func (handler) Teams(p *protocol.Teams) {
+	Client.teams.update(p)
+}
+
+func (handler) WorldBorder(p *protocol.WorldBorder) {
+	Client.worldBorder.update(p)
+}
+
+func (handler) Particle(p *protocol.Particle) {
+	handleParticle(p)
+}
+
+func (handler) Effect(p *protocol.Effect) {
+	handleEffect(p)
+}
+
+func (handler) Explosion(p *protocol.Explosion) {
+	handleExplosion(p)
+}
+
+func (handler) SoundEffect(p *protocol.SoundEffect) {
+	handleSoundEffect(p)
+}
+
+func (handler) Maps(p *protocol.Maps) {
+	Client.maps.update(p)
+}
+
+func (handler) Title(p *protocol.Title) {
+	Client.title.update(p)
+}
+
+func (handler) WindowOpen(p *protocol.WindowOpen) {
+	Client.openWindow(p)
+}
+
+func (handler) WindowClose(p *protocol.WindowClose) {
+	if Client.windowByID(p.ID) != nil {
+		Client.closeWindow(false)
+	}
+}
+
+func (handler) WindowProperty(p *protocol.WindowProperty) {
+	if w := Client.windowByID(p.ID); w != nil {
+		w.setProperty(p.Property, p.Value)
+	}
+}
+
+func (handler) ConfirmTransaction(p *protocol.ConfirmTransaction) {
+	if !p.Accepted {
+		// The server waits for this even for clicks we have
+		// stopped tracking or windows that have been closed
+		Client.network.Write(&protocol.ConfirmTransactionServerbound{
+			ID:           p.ID,
+			ActionNumber: p.ActionNumber,
+			Accepted:     true,
+		})
+	}
+	if w := Client.windowByID(p.ID); w != nil {
+		w.confirm(p)
+	}
+}
+
 func (handler) WindowItems(p *protocol.WindowItems) {
+	if w := Client.windowByID(p.ID); w != nil {
+		w.setItems(p.Items)
+		return
+	}
 	var inv *Inventory
 	if p.ID == 0 {
 		inv = Client.playerInventory
@@ -538,6 +811,16 @@ func (handler) WindowItems(p *protocol.WindowItems) {
 }
 
 func (handler) WindowItem(p *protocol.WindowSetSlot) {
+	// Window -1 slot -1 sets the item held by the cursor
+	if p.ID == 0xFF && p.Slot == -1 {
+		Client.cursorItem = ItemStackFromProtocol(p.ItemStack)
+		return
+	}
+	if w := Client.windowByID(p.ID); w != nil {
+		w.setSlot(int(p.Slot), ItemStackFromProtocol(p.ItemStack))
+		Client.playerInventory.Update()
+		return
+	}
 	var inv *Inventory
 	if p.ID == 0 {
 		inv = Client.playerInventory
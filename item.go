@@ -15,6 +15,9 @@
 package steven
 
 import (
+	"strings"
+
+	"github.com/thinkofdeath/steven/chat"
 	"github.com/thinkofdeath/steven/encoding/nbt"
 	"github.com/thinkofdeath/steven/protocol"
 )
@@ -22,6 +25,12 @@ import (
 type ItemStack struct {
 	Type  ItemType
 	Count int
+	// The raw tag the stack was created with, may be nil
+	Tag *nbt.Compound
+
+	// The id and damage value the stack was created with,
+	// needed to send the stack back to the server
+	rawID, rawDamage int16
 }
 
 func ItemStackFromProtocol(p protocol.ItemStack) *ItemStack {
@@ -32,6 +41,10 @@ func ItemStackFromProtocol(p protocol.ItemStack) *ItemStack {
 	i := &ItemStack{
 		Type:  it,
 		Count: int(p.Count),
+		Tag:   p.NBT,
+
+		rawID:     p.ID,
+		rawDamage: p.Damage,
 	}
 	i.Type.ParseDamage(p.Damage)
 	if p.NBT != nil {
@@ -40,6 +53,138 @@ func ItemStackFromProtocol(p protocol.ItemStack) *ItemStack {
 	return i
 }
 
+// protocolItemStack converts the stack into the form used by the
+// protocol, nil stacks become empty slots.
+func protocolItemStack(i *ItemStack) protocol.ItemStack {
+	if i == nil {
+		return protocol.ItemStack{ID: -1}
+	}
+	return protocol.ItemStack{
+		ID:     i.rawID,
+		Count:  byte(i.Count),
+		Damage: i.rawDamage,
+		NBT:    i.Tag,
+	}
+}
+
+// withCount returns a copy of the stack with the count changed,
+// nil if the count is zero or less.
+func (i *ItemStack) withCount(count int) *ItemStack {
+	if count <= 0 {
+		return nil
+	}
+	c := *i
+	c.Count = count
+	return &c
+}
+
+// stacksWith returns whether the two stacks are of the same item
+// and can be combined.
+func (i *ItemStack) stacksWith(o *ItemStack) bool {
+	if i == nil || o == nil {
+		return false
+	}
+	if i.rawID != o.rawID || i.rawDamage != o.rawDamage {
+		return false
+	}
+	if (i.Tag == nil) != (o.Tag == nil) {
+		return false
+	}
+	return i.Tag == nil || i.Tag.String() == o.Tag.String()
+}
+
+// maxStackSize returns the largest count a stack of this item may
+// have.
+func (i *ItemStack) maxStackSize() int {
+	if size, ok := itemStackSizes[i.rawID]; ok {
+		return size
+	}
+	if i.Type.Stackable() {
+		return 64
+	}
+	return 1
+}
+
+// itemStackSizes contains the items that don't stack to 64, keyed
+// by item id.
+var itemStackSizes = map[int16]int{
+	// Stack to 16
+	323: 16, // sign
+	325: 16, // bucket
+	332: 16, // snowball
+	344: 16, // egg
+	368: 16, // ender_pearl
+	387: 16, // written_book
+	416: 16, // armor_stand
+	425: 16, // banner
+
+	// Don't stack
+	// Tools
+	256: 1, 257: 1, 258: 1, 259: 1, 261: 1,
+	269: 1, 270: 1, 271: 1, 273: 1, 274: 1, 275: 1,
+	277: 1, 278: 1, 279: 1, 284: 1, 285: 1, 286: 1,
+	290: 1, 291: 1, 292: 1, 293: 1, 294: 1,
+	346: 1, 359: 1, 398: 1,
+	// Armor
+	298: 1, 299: 1, 300: 1, 301: 1,
+	302: 1, 303: 1, 304: 1, 305: 1,
+	306: 1, 307: 1, 308: 1, 309: 1,
+	310: 1, 311: 1, 312: 1, 313: 1,
+	314: 1, 315: 1, 316: 1, 317: 1,
+	417: 1, 418: 1, 419: 1,
+	// Vehicles
+	328: 1, 333: 1, 342: 1, 343: 1, 407: 1, 408: 1, 422: 1,
+	// Food, buckets and potions
+	282: 1, 326: 1, 327: 1, 335: 1, 354: 1, 373: 1, 413: 1,
+	// Records
+	2256: 1, 2257: 1, 2258: 1, 2259: 1, 2260: 1, 2261: 1,
+	2262: 1, 2263: 1, 2264: 1, 2265: 1, 2266: 1, 2267: 1,
+	// Everything else
+	329: 1, 355: 1, 386: 1, 403: 1,
+}
+
+// displayName returns the name of the item, either the custom name
+// set by its tag or the item's translated name.
+func (i *ItemStack) displayName() chat.AnyComponent {
+	if di, ok := i.Type.(DisplayTag); ok && di.DisplayName() != "" {
+		return legacyComponent(di.DisplayName())
+	}
+	return chat.AnyComponent{Value: &chat.TranslateComponent{Translate: i.Type.NameLocaleKey()}}
+}
+
+// lore returns the extra lines of text the item's tag adds to its
+// tooltip.
+func (i *ItemStack) lore() []chat.AnyComponent {
+	di, ok := i.Type.(DisplayTag)
+	if !ok {
+		return nil
+	}
+	var out []chat.AnyComponent
+	for _, l := range di.Lore() {
+		c := legacyComponent(l)
+		c.Value.(*chat.TextComponent).Color = chat.DarkPurple
+		out = append(out, c)
+	}
+	return out
+}
+
+var armorSuffixes = [...]string{"_helmet", "_chestplate", "_leggings", "_boots"}
+
+// armorType returns the armor slot (0 for the head to 3 for the
+// feet) the item can be worn in, -1 if it can't be worn.
+func (i *ItemStack) armorType() int {
+	name := i.Type.Name()
+	for n, suffix := range armorSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return n
+		}
+	}
+	if name == "pumpkin" || name == "skull" {
+		return 0
+	}
+	return -1
+}
+
 type ItemType interface {
 	Name() string
 	NameLocaleKey() string
@@ -73,19 +218,16 @@ type displayTag struct {
 }
 
 func (d *displayTag) ParseTag(tag *nbt.Compound) {
-	display, ok := tag.Items["display"].(*nbt.Compound)
-	if !ok {
-		return
-	}
-	d.name, _ = display.Items["Name"].(string)
-	lore, ok := display.Items["Lore"].([]interface{})
-	if !ok {
-		return
-	}
-	d.lore = make([]string, len(lore))
-	for i := range lore {
-		d.lore[i], _ = lore[i].(string)
+	var data struct {
+		Display struct {
+			Name string
+			Lore []string
+		} `nbt:"display"`
 	}
+	// Invalid tags are ignored, whatever could be parsed is used
+	nbt.Unmarshal(tag, &data)
+	d.name = data.Display.Name
+	d.lore = data.Display.Lore
 }
 func (d *displayTag) DisplayName() string { return d.name }
 func (d *displayTag) Lore() []string      { return d.lore }
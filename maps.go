@@ -0,0 +1,252 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steven
+
+import (
+	"fmt"
+	"image"
+	icolor "image/color"
+	idraw "image/draw"
+	"image/png"
+	"math"
+
+	"github.com/thinkofdeath/steven/protocol"
+	"github.com/thinkofdeath/steven/render"
+	"github.com/thinkofdeath/steven/resource"
+	"github.com/thinkofdeath/steven/ui"
+	"github.com/thinkofdeath/steven/ui/scene"
+)
+
+// Maps are 128x128 pixels
+const mapSize = 128
+
+// mapBaseColors is the color of each of the map palette's base
+// colors, each has four shades.
+var mapBaseColors = [...]uint32{
+	0x000000, 0x7FB238, 0xF7E9A3, 0xC7C7C7, 0xFF0000, 0xA0A0FF,
+	0xA7A7A7, 0x007C00, 0xFFFFFF, 0xA4A8B8, 0x976D4D, 0x707070,
+	0x4040FF, 0x8F7748, 0xFFFCF5, 0xD87F33, 0xB24CD8, 0x6699D8,
+	0xE5E533, 0x7FCC19, 0xF27FA5, 0x4C4C4C, 0x999999, 0x4C7F99,
+	0x7F3FB2, 0x334CB2, 0x664C33, 0x667F33, 0x993333, 0x191919,
+	0xFAEE4D, 0x5CDBD5, 0x4A80FF, 0x00D93A, 0x815631, 0x700200,
+}
+
+// Brightness of each shade out of 255
+var mapShades = [...]uint32{180, 220, 255, 135}
+
+// mapColor returns the color of a palette index, the first base
+// color is transparent.
+func mapColor(index byte) icolor.NRGBA {
+	base := int(index / 4)
+	if base == 0 || base >= len(mapBaseColors) {
+		return icolor.NRGBA{}
+	}
+	c, shade := mapBaseColors[base], mapShades[index&3]
+	return icolor.NRGBA{
+		R: byte((c >> 16 & 0xFF) * shade / 255),
+		G: byte((c >> 8 & 0xFF) * shade / 255),
+		B: byte((c & 0xFF) * shade / 255),
+		A: 255,
+	}
+}
+
+// mapData is the contents of a single map as sent by the server.
+type mapData struct {
+	id     int
+	scale  int8
+	colors [mapSize * mapSize]byte
+	icons  []protocol.MapIcon
+
+	// Set when the texture needs to be updated
+	dirty bool
+}
+
+// textureID is the id of the map's texture in the icon store.
+func (m *mapData) textureID() string {
+	return fmt.Sprintf("map:%d", m.id)
+}
+
+// image draws the map and its icons.
+func (m *mapData) image() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, mapSize, mapSize))
+	for i, c := range m.colors {
+		img.SetNRGBA(i%mapSize, i/mapSize, mapColor(c))
+	}
+	icons := mapIconImage()
+	if icons == nil {
+		return img
+	}
+	size := icons.Bounds().Dx() / 4
+	for _, icon := range m.icons {
+		// The type is in the top 4 bits and the direction in
+		// 16ths of a circle in the bottom 4 bits
+		kind := int(icon.DirectionType>>4) & 0xF
+		ang := float64(icon.DirectionType&0xF) * (math.Pi / 8)
+		sin, cos := math.Sin(ang), math.Cos(ang)
+		tx, ty := (kind%4)*size, (kind/4)*size
+		// Positions are in half pixels from the center
+		cx, cy := int(icon.X)/2+mapSize/2, int(icon.Z)/2+mapSize/2
+		// Icons cover 8x8 pixels of the map before being turned
+		// to face their direction, 6 pixels covers the corners
+		// once turned
+		for y := -6; y < 6; y++ {
+			for x := -6; x < 6; x++ {
+				px, py := cx+x, cy+y
+				if px < 0 || py < 0 || px >= mapSize || py >= mapSize {
+					continue
+				}
+				fx, fy := float64(x)+0.5, float64(y)+0.5
+				ox := fx*cos + fy*sin + 4
+				oy := -fx*sin + fy*cos + 4
+				if ox < 0 || oy < 0 || ox >= 8 || oy >= 8 {
+					continue
+				}
+				col := icolor.NRGBAModel.Convert(icons.At(
+					tx+int(ox*float64(size)/8), ty+int(oy*float64(size)/8),
+				)).(icolor.NRGBA)
+				if col.A != 0 {
+					img.SetNRGBA(px, py, col)
+				}
+			}
+		}
+	}
+	return img
+}
+
+var mapIcons image.Image
+
+// mapIconImage returns the image containing the icons drawn on maps,
+// nil if it couldn't be loaded.
+func mapIconImage() image.Image {
+	if mapIcons != nil {
+		return mapIcons
+	}
+	r, err := resource.Open("minecraft", "textures/map/map_icons.png")
+	if err != nil {
+		return nil
+	}
+	defer r.Close()
+	img, err := png.Decode(r)
+	if err != nil {
+		return nil
+	}
+	out := image.NewNRGBA(img.Bounds())
+	idraw.Draw(out, out.Bounds(), img, image.ZP, idraw.Src)
+	mapIcons = out
+	return mapIcons
+}
+
+// clientMaps stores the maps the server has sent and displays the
+// map held by the player.
+type clientMaps struct {
+	maps map[int]*mapData
+
+	scene *scene.Type
+	held  *ui.Image
+	// The id of the map being displayed, -1 if none
+	heldID int
+	// Incremented each time a new map is added, lets things
+	// waiting on a map know when to look for it again
+	added int
+}
+
+func (cm *clientMaps) init() {
+	cm.maps = map[int]*mapData{}
+	cm.scene = scene.New(true)
+	cm.held = ui.NewImage(render.GetTexture("solid"), 0, 100, 256, 256, 0, 0, 1, 1, 255, 255, 255)
+	cm.held.SetDraw(false)
+	cm.scene.AddDrawable(cm.held.Attach(ui.Bottom, ui.Center))
+	cm.heldID = -1
+}
+
+func (cm *clientMaps) free() {
+	cm.scene.Hide()
+	for _, m := range cm.maps {
+		render.FreeIcon(m.textureID())
+	}
+}
+
+// update applies a Maps packet.
+func (cm *clientMaps) update(p *protocol.Maps) {
+	id := int(p.ItemDamage)
+	m, ok := cm.maps[id]
+	if !ok {
+		m = &mapData{id: id}
+		cm.maps[id] = m
+		render.AddIcon(m.textureID(), m.image())
+		cm.added++
+	}
+	m.scale = p.Scale
+	m.icons = p.Icons
+	if p.Columns > 0 {
+		cols, rows := int(p.Columns), int(p.Rows)
+		for x := 0; x < cols; x++ {
+			for z := 0; z < rows; z++ {
+				i := x + z*cols
+				px, pz := int(p.X)+x, int(p.Z)+z
+				if i >= len(p.Data) || px >= mapSize || pz >= mapSize {
+					continue
+				}
+				m.colors[px+pz*mapSize] = p.Data[i]
+			}
+		}
+	}
+	m.dirty = true
+}
+
+// redraw marks every map's texture as needing to be redrawn, used
+// when the textures the icons come from change.
+func (cm *clientMaps) redraw() {
+	for _, m := range cm.maps {
+		m.dirty = true
+	}
+}
+
+// texture returns the texture of the map with the id, nil if the
+// server hasn't sent it.
+func (cm *clientMaps) texture(id int) render.TextureInfo {
+	m, ok := cm.maps[id]
+	if !ok {
+		return nil
+	}
+	return render.Icon(m.textureID())
+}
+
+func (cm *clientMaps) render(delta float64) {
+	for _, m := range cm.maps {
+		if m.dirty {
+			m.dirty = false
+			render.UpdateIcon(m.textureID(), m.image())
+		}
+	}
+
+	id := -1
+	item := Client.playerInventory.Items[invPlayerHotbarOffset+Client.currentHotbarSlot]
+	if item != nil && item.Type.Name() == "filled_map" && currentScreen == nil {
+		id = int(item.rawDamage)
+	}
+	// The map may arrive after it is first held
+	tex := cm.texture(id)
+	if id == cm.heldID && (tex != nil) == cm.held.ShouldDraw() {
+		return
+	}
+	cm.heldID = id
+	if tex == nil {
+		cm.held.SetDraw(false)
+		return
+	}
+	cm.held.SetTexture(tex)
+	cm.held.SetDraw(true)
+}
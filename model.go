@@ -338,6 +338,17 @@ func (bm *model) lookupTexture(name string) render.TextureInfo {
 	return render.GetTexture(name)
 }
 
+// particleTexture returns the texture used for particles created
+// from the model, nil if the model doesn't have one.
+func (bm *model) particleTexture() render.TextureInfo {
+	for _, name := range []string{"particle", "layer0"} {
+		if _, ok := bm.textureVars[name]; ok {
+			return bm.lookupTexture("#" + name)
+		}
+	}
+	return nil
+}
+
 func loadJSON(plugin, name string, target interface{}) error {
 	r, err := resource.Open(plugin, name)
 	if err != nil {
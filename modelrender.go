@@ -26,6 +26,9 @@ import (
 type processedModel struct {
 	faces            []processedFace
 	ambientOcclusion bool
+	// Texture used for the particles created when the block
+	// is broken, may be nil
+	particle render.TextureInfo
 }
 
 type processedFace struct {
@@ -71,6 +74,7 @@ func rotateDirection(val direction.Type, offset int, rots []direction.Type, inva
 func precomputeModel(bm *model) *processedModel {
 	p := &processedModel{}
 	p.ambientOcclusion = bm.ambientOcclusion
+	p.particle = bm.particleTexture()
 	for ei := range bm.elements {
 		// Render the last element first so that
 		// grass's overlay works correctly.
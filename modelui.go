@@ -18,6 +18,7 @@ import (
 	"math"
 
 	"github.com/go-gl/mathgl/mgl32"
+	"github.com/thinkofdeath/steven/render"
 	"github.com/thinkofdeath/steven/type/direction"
 	"github.com/thinkofdeath/steven/ui"
 	"github.com/thinkofdeath/steven/world/biome"
@@ -121,3 +122,50 @@ func modelToUI(mdl *model, block Block) *ui.Model {
 	}
 	return ui.NewModel(0, 0, verts, mat)
 }
+
+// playerModelToUI creates a model of a player with the skin for
+// drawing in the user interface, e.g. the inventory. The yaw and
+// pitch turn the player and their head to face towards a point.
+func playerModelToUI(skin render.TextureInfo, yaw, pitch float64) *ui.Model {
+	parts := playerModelParts(skin, true)
+	// Turns the player to face the viewer
+	base := mgl32.Rotate3DY(math.Pi + float32(yaw)).Mat4()
+	mats := [...]mgl32.Mat4{
+		playerModelHead: base.Mul4(mgl32.Translate3D(0, 24/16.0, 0)).
+			Mul4(mgl32.Rotate3DX(float32(pitch)).Mat4()),
+		playerModelBody:     base.Mul4(mgl32.Translate3D(0, 18/16.0, 0)),
+		playerModelLegRight: base.Mul4(mgl32.Translate3D(2/16.0, 12/16.0, 0)),
+		playerModelLegLeft:  base.Mul4(mgl32.Translate3D(-2/16.0, 12/16.0, 0)),
+		playerModelArmRight: base.Mul4(mgl32.Translate3D(6/16.0, 24/16.0, 0)),
+		playerModelArmLeft:  base.Mul4(mgl32.Translate3D(-6/16.0, 24/16.0, 0)),
+	}
+
+	var verts []*ui.ModelVertex
+	for i, part := range parts {
+		for _, v := range part {
+			// Centered on the player's waist
+			pos := mats[i].Mul4x1(mgl32.Vec4{v.X, v.Y, v.Z, 1}).Vec3().
+				Sub(mgl32.Vec3{0, 1, 0}).
+				Add(mgl32.Vec3{0.5, 0.5, 0.5})
+			rect := v.Texture.Rect()
+			verts = append(verts, &ui.ModelVertex{
+				X:        pos[0],
+				Y:        pos[1],
+				Z:        pos[2],
+				TX:       uint16(rect.X),
+				TY:       uint16(rect.Y),
+				TW:       uint16(rect.Width),
+				TH:       uint16(rect.Height),
+				TOffsetX: int16(16 * float64(rect.Width) * v.TextureX),
+				TOffsetY: int16(16 * float64(rect.Height) * v.TextureY),
+				TAtlas:   int16(v.Texture.Atlas()),
+				R:        v.R,
+				G:        v.G,
+				B:        v.B,
+				A:        v.A,
+			})
+		}
+	}
+	// Two blocks tall is roughly 4 times the model's size
+	return ui.NewModel(0, 0, verts, mgl32.Scale3D(2.1, 2.1, 2.1))
+}
@@ -16,9 +16,15 @@ package steven
 
 import (
 	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/thinkofdeath/steven/protocol"
 	"github.com/thinkofdeath/steven/protocol/mojang"
+	"github.com/thinkofdeath/steven/world/anvil"
 )
 
 type networkManager struct {
@@ -27,6 +33,12 @@ type networkManager struct {
 	readChan  chan protocol.Packet
 	errorChan chan error
 	closeChan chan struct{}
+
+	recordFile *os.File
+	recorder   *protocol.Recorder
+	// Set when packets are produced locally (replays and the
+	// world viewer) instead of by a server
+	offline bool
 }
 
 func (n *networkManager) init() {
@@ -36,14 +48,36 @@ func (n *networkManager) init() {
 	n.closeChan = make(chan struct{}, 1)
 }
 
-func (n *networkManager) Connect(profile mojang.Profile, server string) {
+// Connect logs in to the server using the protocol version it was last
+// seen using (e.g. by the server list), 0 if this isn't known.
+func (n *networkManager) Connect(profile mojang.Profile, server string, version int) {
 	go func() {
+		if !protocol.IsProtocolVersionSupported(version) {
+			// Ping the server first to find out which version of the
+			// protocol it speaks. Servers that can't be pinged are
+			// assumed to use the default version.
+			version = protocol.SupportedProtocolVersion
+			if v, err := serverProtocolVersion(server); err == nil && protocol.IsProtocolVersionSupported(v) {
+				version = v
+			}
+		}
+
 		var err error
 		n.conn, err = protocol.Dial(server)
 		if err != nil {
 			n.SignalClose(err)
 			return
 		}
+		if err := n.conn.SetProtocolVersion(version); err != nil {
+			n.SignalClose(err)
+			return
+		}
+		if path := os.Getenv("STEVEN_RECORD"); path != "" {
+			if err := n.record(path); err != nil {
+				n.SignalClose(err)
+				return
+			}
+		}
 
 		err = n.conn.LoginToServer(profile)
 		if err != nil {
@@ -72,6 +106,11 @@ func (n *networkManager) Connect(profile mojang.Profile, server string) {
 		first := true
 		for {
 			packet, err := n.conn.ReadPacket()
+			if _, ok := err.(*protocol.UnsupportedPacketError); ok {
+				// Packets that changed in the server's version
+				// can't be handled
+				continue
+			}
 			if err != nil {
 				n.SignalClose(err)
 				return
@@ -94,9 +133,190 @@ func (n *networkManager) Connect(profile mojang.Profile, server string) {
 	}()
 }
 
+// serverProtocolVersion returns the protocol version the server
+// reports in its status reply.
+func serverProtocolVersion(server string) (int, error) {
+	conn, err := protocol.Dial(server)
+	if err != nil {
+		return 0, err
+	}
+	resp, _, err := conn.RequestStatus()
+	if err != nil {
+		return 0, err
+	}
+	return resp.Version.Protocol, nil
+}
+
+// record saves every packet sent and received on the connection
+// to the file at path. The recording can be played back later
+// via Replay.
+func (n *networkManager) record(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	n.recorder, err = protocol.NewRecorder(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	n.recordFile = f
+	n.conn.Record(n.recorder)
+	return nil
+}
+
+// Replay plays back the packets the server sent in a recording made
+// by setting STEVEN_RECORD, keeping the original timing. No server is
+// involved, packets written by the client are discarded.
+func (n *networkManager) Replay(path string) {
+	n.offline = true
+	go func() {
+		f, err := os.Open(path)
+		if err != nil {
+			n.SignalClose(err)
+			return
+		}
+		defer f.Close()
+		rp, err := protocol.NewReplay(f)
+		if err != nil {
+			n.SignalClose(err)
+			return
+		}
+		if rp.ProtocolVersion != protocol.SupportedProtocolVersion {
+			n.SignalClose(fmt.Errorf("unsupported recording protocol version %d", rp.ProtocolVersion))
+			return
+		}
+
+		go n.discardWrites()
+
+		start := time.Now()
+		for {
+			r, err := rp.Next()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				n.SignalClose(err)
+				return
+			}
+			if r.Serverbound || r.State != protocol.Play {
+				continue
+			}
+			switch r.Packet.(type) {
+			case *protocol.KeepAliveClientbound, *protocol.SetCompression:
+				continue
+			}
+
+			select {
+			case <-time.After(r.Time - time.Now().Sub(start)):
+			case <-n.closeChan:
+				n.closeChan <- struct{}{} // Keep the closed state
+				return
+			}
+			select {
+			case n.readChan <- r.Packet:
+			case <-n.closeChan:
+				n.closeChan <- struct{}{}
+				return
+			}
+		}
+	}()
+}
+
+// View shows a world saved in the Anvil format (e.g. by the chunk
+// cache) by feeding its chunks to the client as if a server had sent
+// them. The player is placed in spectator mode at the world's spawn.
+func (n *networkManager) View(dir string) {
+	n.offline = true
+	go func() {
+		regions, err := filepath.Glob(filepath.Join(dir, "region", "*.mca"))
+		if err != nil {
+			n.SignalClose(err)
+			return
+		}
+		if len(regions) == 0 {
+			n.SignalClose(fmt.Errorf("no regions found in %s", dir))
+			return
+		}
+
+		go n.discardWrites()
+
+		send := func(packet protocol.Packet) bool {
+			select {
+			case n.readChan <- packet:
+				return true
+			case <-n.closeChan:
+				n.closeChan <- struct{}{} // Keep the closed state
+				return false
+			}
+		}
+		if !send(&protocol.JoinGame{Gamemode: byte(gmSpecator), LevelType: "default"}) {
+			return
+		}
+
+		spawnX, spawnY, spawnZ, err := readLevelSpawn(filepath.Join(dir, "level.dat"))
+		hasSpawn := err == nil
+		for _, path := range regions {
+			r, err := anvil.OpenRegion(path)
+			if err != nil {
+				log.Printf("Failed to open region %s: %s\n", path, err)
+				continue
+			}
+			for z := 0; z < 32; z++ {
+				for x := 0; x < 32; x++ {
+					if !r.HasChunk(x, z) {
+						continue
+					}
+					tag, err := r.ReadChunk(x, z)
+					if err != nil {
+						log.Printf("Failed to read chunk in %s: %s\n", path, err)
+						continue
+					}
+					cd, err := chunkDataFromNBT(tag)
+					if err != nil {
+						log.Printf("Failed to read chunk in %s: %s\n", path, err)
+						continue
+					}
+					if !hasSpawn {
+						// Default to the first chunk found
+						spawnX, spawnY, spawnZ = int(cd.ChunkX<<4+8), 100, int(cd.ChunkZ<<4+8)
+						hasSpawn = true
+					}
+					if !send(cd) {
+						r.Close()
+						return
+					}
+				}
+			}
+			r.Close()
+		}
+
+		send(&protocol.TeleportPlayer{
+			X: float64(spawnX) + 0.5,
+			Y: float64(spawnY),
+			Z: float64(spawnZ) + 0.5,
+		})
+	}()
+}
+
+func (n *networkManager) discardWrites() {
+	for {
+		select {
+		case <-n.writeChan:
+		case <-n.closeChan:
+			n.closeChan <- struct{}{}
+			return
+		}
+	}
+}
+
 func (n *networkManager) writeHandler() {
 	for packet := range n.writeChan {
 		err := n.conn.WritePacket(packet)
+		if _, ok := err.(*protocol.UnsupportedPacketError); ok {
+			log.Println(err)
+			continue
+		}
 		if err != nil {
 			n.SignalClose(err)
 			return
@@ -130,9 +350,15 @@ func (n *networkManager) Write(packet protocol.Packet) {
 }
 
 func (n *networkManager) Close() {
-	if n.conn == nil {
+	if n.conn == nil && !n.offline {
 		return
 	}
 	n.closeChan <- struct{}{}
-	n.conn.Close()
+	if n.conn != nil {
+		n.conn.Close()
+	}
+	if n.recorder != nil {
+		n.recorder.Close()
+		n.recordFile.Close()
+	}
 }
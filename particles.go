@@ -0,0 +1,718 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steven
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/thinkofdeath/steven/protocol"
+	"github.com/thinkofdeath/steven/render"
+)
+
+// Particle types as used by the Particle packet
+const (
+	particleExplode = iota
+	particleLargeExplode
+	particleHugeExplosion
+	particleFireworksSpark
+	particleBubble
+	particleSplash
+	particleWake
+	particleSuspended
+	particleDepthSuspend
+	particleCrit
+	particleMagicCrit
+	particleSmoke
+	particleLargeSmoke
+	particleSpell
+	particleInstantSpell
+	particleMobSpell
+	particleMobSpellAmbient
+	particleWitchMagic
+	particleDripWater
+	particleDripLava
+	particleAngryVillager
+	particleHappyVillager
+	particleTownAura
+	particleNote
+	particlePortal
+	particleEnchantmentTable
+	particleFlame
+	particleLava
+	particleFootstep
+	particleCloud
+	particleRedDust
+	particleSnowballPoof
+	particleSnowShovel
+	particleSlime
+	particleHeart
+	particleBarrier
+	particleIconCrack
+	particleBlockCrack
+	particleBlockDust
+	particleDroplet
+	particleTake
+	particleMobAppearance
+)
+
+// Number of sprites along each side of the particle sheet
+const particleSheetSize = 16
+
+// particleSprite returns the sprite at the index on the particle
+// sheet.
+func particleSprite(index int) render.TextureInfo {
+	tex := render.GetTexture("particle/particles")
+	rect := tex.Rect()
+	w, h := rect.Width/particleSheetSize, rect.Height/particleSheetSize
+	return tex.Sub((index%particleSheetSize)*w, (index/particleSheetSize)*h, w, h)
+}
+
+// particleAnimation returns the sprites of an animation that plays
+// backwards from start+count-1 to start, which is how most of the
+// animations on the particle sheet are laid out.
+func particleAnimation(start, count int) []render.TextureInfo {
+	frames := make([]render.TextureInfo, count)
+	for i := range frames {
+		frames[i] = particleSprite(start + count - 1 - i)
+	}
+	return frames
+}
+
+// particleFragment returns a random quarter sized section of the
+// texture, used for the particles of breaking blocks and items.
+func particleFragment(tex render.TextureInfo) render.TextureInfo {
+	rect := tex.Rect()
+	w, h := rect.Width/4, rect.Height/4
+	return tex.Sub(rand.Intn(rect.Width-w+1), rand.Intn(rect.Height-h+1), w, h)
+}
+
+// blockParticleTexture returns the texture used for particles of
+// the block, nil if it doesn't have one.
+func blockParticleTexture(b Block) render.TextureInfo {
+	models := b.Models()
+	if len(models) == 0 {
+		return nil
+	}
+	if mdl := models.selectModel(0); mdl != nil {
+		return mdl.particle
+	}
+	return nil
+}
+
+// itemParticleTexture returns the texture used for particles of
+// the item, nil if it doesn't have one.
+func itemParticleTexture(id, damage int) render.TextureInfo {
+	it := ItemById(id)
+	if it == nil {
+		return nil
+	}
+	it.ParseDamage(int16(damage))
+	mdl := getModel(it.Name())
+	if mdl == nil {
+		return nil
+	}
+	return mdl.particleTexture()
+}
+
+// newParticle returns a particle at the position using the same
+// default movement, size and lifetime as vanilla's particles.
+func newParticle(x, y, z, vx, vy, vz float64) *render.Particle {
+	p := &render.Particle{
+		X: x, Y: y, Z: z,
+		Size:    (rand.Float64()*0.5 + 0.5) * 0.4,
+		Life:    4 / (rand.Float64()*0.9 + 0.1),
+		R:       1,
+		G:       1,
+		B:       1,
+		A:       1,
+		Collide: true,
+	}
+	// Randomize the direction and speed slightly
+	mx := vx + (rand.Float64()*2-1)*0.4
+	my := vy + (rand.Float64()*2-1)*0.4
+	mz := vz + (rand.Float64()*2-1)*0.4
+	speed := (rand.Float64() + rand.Float64() + 1) * 0.15
+	if l := math.Sqrt(mx*mx + my*my + mz*mz); l > 0 {
+		mx, my, mz = mx/l*speed*0.4, my/l*speed*0.4, mz/l*speed*0.4
+	}
+	p.VX, p.VY, p.VZ = mx, my+0.1, mz
+	return p
+}
+
+// setGray sets the particle's color to the shade of gray.
+func setGray(p *render.Particle, v float64) {
+	p.R, p.G, p.B = float32(v), float32(v), float32(v)
+}
+
+// smokeParticle returns a particle that drifts upwards whilst
+// fading through the smoke animation.
+func smokeParticle(x, y, z, vx, vy, vz, scale float64) *render.Particle {
+	p := newParticle(x, y, z, 0, 0, 0)
+	p.VX, p.VY, p.VZ = p.VX*0.1+vx, p.VY*0.1+vy, p.VZ*0.1+vz
+	setGray(p, rand.Float64()*0.3)
+	p.Size *= 0.75 * scale
+	p.Life = 8 / (rand.Float64()*0.8 + 0.2) * scale
+	p.Frames = particleAnimation(0, 8)
+	p.Gravity = -0.004
+	p.Drag = 0.96
+	return p
+}
+
+// spellParticle returns a particle that rises whilst playing one
+// of the spell animations.
+func spellParticle(x, y, z, vx, vy, vz float64, sprite int) *render.Particle {
+	p := newParticle(x, y, z, vx, vy, vz)
+	p.VY *= 0.2
+	if vx == 0 && vz == 0 {
+		p.VX *= 0.1
+		p.VZ *= 0.1
+	}
+	p.Size *= 0.75
+	p.Life = 8 / (rand.Float64()*0.8 + 0.2)
+	p.Frames = particleAnimation(sprite, 8)
+	p.Gravity = -0.004
+	p.Drag = 0.96
+	p.Collide = false
+	return p
+}
+
+// floatingParticle returns a particle with a single sprite that
+// floats up slowly, e.g. hearts and notes.
+func floatingParticle(x, y, z, vx, vy, vz float64, sprite int) *render.Particle {
+	p := newParticle(x, y, z, 0, 0, 0)
+	p.VX, p.VY, p.VZ = p.VX*0.01+vx, p.VY*0.01+vy+0.1, p.VZ*0.01+vz
+	p.Size *= 0.75 * 2
+	p.Life = 16
+	p.Texture = particleSprite(sprite)
+	p.Drag = 0.86
+	return p
+}
+
+// auraParticle returns a small particle that drifts slowly.
+func auraParticle(x, y, z, vx, vy, vz float64, sprite int) *render.Particle {
+	p := newParticle(x, y, z, vx, vy, vz)
+	p.VX, p.VY, p.VZ = vx, vy, vz
+	p.Size *= rand.Float64()*0.6 + 0.5
+	p.Life = 20 / (rand.Float64()*0.8 + 0.2)
+	p.Texture = particleSprite(sprite)
+	p.Drag = 0.99
+	return p
+}
+
+// fragmentParticle returns a piece of a block or item that falls
+// to the ground, used when things break.
+func fragmentParticle(x, y, z, vx, vy, vz float64, tex render.TextureInfo) *render.Particle {
+	p := newParticle(x, y, z, vx, vy, vz)
+	p.Texture = particleFragment(tex)
+	p.Gravity = 0.04
+	p.Size /= 2
+	return p
+}
+
+// travelParticle returns a particle that moves from the offset
+// back to its starting position, used for portals and enchanting
+// tables.
+func travelParticle(x, y, z, vx, vy, vz float64) *render.Particle {
+	p := newParticle(x+vx, y+vy, z+vz, 0, 0, 0)
+	p.VX, p.VY, p.VZ = 0, 0, 0
+	p.Collide = false
+	p.Update = func(p *render.Particle, ticks float64) {
+		f := 1 - p.Age/p.Life
+		p.X = x + vx*f
+		p.Y = y + vy*f
+		p.Z = z + vz*f
+	}
+	return p
+}
+
+// spawnParticle creates a single particle of the type at the
+// position.
+func spawnParticle(id int, x, y, z, vx, vy, vz float64, data []protocol.VarInt) {
+	var p *render.Particle
+	switch id {
+	case particleExplode:
+		p = newParticle(x, y, z, 0, 0, 0)
+		p.VX = vx + (rand.Float64()*2-1)*0.05
+		p.VY = vy + (rand.Float64()*2-1)*0.05
+		p.VZ = vz + (rand.Float64()*2-1)*0.05
+		setGray(p, rand.Float64()*0.3+0.7)
+		p.Size = (rand.Float64()*rand.Float64()*6 + 1) * 0.2
+		p.Life = 16/(rand.Float64()*0.8+0.2) + 2
+		p.Frames = particleAnimation(0, 8)
+		p.Gravity = -0.004
+		p.Drag = 0.9
+	case particleLargeExplode:
+		p = newParticle(x, y, z, 0, 0, 0)
+		p.VX, p.VY, p.VZ = 0, 0, 0
+		setGray(p, rand.Float64()*0.6+0.4)
+		p.Size = 2 * (1 - vx*0.5)
+		p.Life = float64(6 + rand.Intn(4))
+		tex := render.GetTexture("entity/explosion")
+		rect := tex.Rect()
+		w, h := rect.Width/4, rect.Height/4
+		for i := 0; i < 16; i++ {
+			p.Frames = append(p.Frames, tex.Sub((i%4)*w, (i/4)*h, w, h))
+		}
+		p.Emissive = true
+		p.Collide = false
+	case particleHugeExplosion:
+		// Vanilla uses an emitter that creates explosions over
+		// a few ticks, they are all created at once here instead
+		for i := 0; i < 6; i++ {
+			spawnParticle(particleLargeExplode,
+				x+(rand.Float64()-rand.Float64())*4,
+				y+(rand.Float64()-rand.Float64())*4,
+				z+(rand.Float64()-rand.Float64())*4,
+				float64(i)/6, 0, 0, nil,
+			)
+		}
+		return
+	case particleFireworksSpark:
+		p = newParticle(x, y, z, 0, 0, 0)
+		p.VX, p.VY, p.VZ = vx, vy, vz
+		p.Size *= 0.75
+		p.Life = float64(48 + rand.Intn(12))
+		p.Frames = particleAnimation(160, 8)
+		p.Gravity = 0.004
+		p.Drag = 0.91
+		p.Emissive = true
+	case particleBubble:
+		p = newParticle(x, y, z, 0, 0, 0)
+		p.VX = vx*0.2 + (rand.Float64()*2-1)*0.02
+		p.VY = vy*0.2 + (rand.Float64()*2-1)*0.02
+		p.VZ = vz*0.2 + (rand.Float64()*2-1)*0.02
+		p.Size *= rand.Float64()*0.6 + 0.2
+		p.Life = 8 / (rand.Float64()*0.8 + 0.2)
+		p.Texture = particleSprite(32)
+		p.Gravity = -0.002
+		p.Drag = 0.85
+	case particleSplash, particleDroplet:
+		p = newParticle(x, y, z, 0, 0, 0)
+		p.VX, p.VY, p.VZ = p.VX*0.3, rand.Float64()*0.2+0.1, p.VZ*0.3
+		if id == particleSplash && vy == 0 && (vx != 0 || vz != 0) {
+			p.VX, p.VY, p.VZ = vx, 0.1, vz
+		}
+		p.Life = 8 / (rand.Float64()*0.8 + 0.2)
+		p.Texture = particleSprite(19 + rand.Intn(4))
+		p.Gravity = 0.06
+	case particleWake:
+		p = newParticle(x, y, z, 0, 0, 0)
+		p.VX, p.VY, p.VZ = p.VX*0.3, rand.Float64()*0.2+0.1, p.VZ*0.3
+		p.Life = 8 / (rand.Float64()*0.8 + 0.2)
+		p.Frames = []render.TextureInfo{
+			particleSprite(19), particleSprite(20), particleSprite(21), particleSprite(22),
+		}
+	case particleSuspended:
+		p = newParticle(x, y-0.125, z, 0, 0, 0)
+		p.VX, p.VY, p.VZ = vx*0.01, vy*0.01, vz*0.01
+		p.R, p.G, p.B = 0.4, 0.4, 0.7
+		p.Size *= rand.Float64()*0.6 + 0.2
+		p.Life = 16 / (rand.Float64()*0.8 + 0.2)
+		p.Texture = particleSprite(0)
+		p.Collide = false
+	case particleDepthSuspend, particleTownAura:
+		p = auraParticle(x, y, z, vx, vy, vz, 0)
+		if id == particleDepthSuspend {
+			setGray(p, 0.4)
+		}
+	case particleCrit, particleMagicCrit:
+		p = newParticle(x, y, z, 0, 0, 0)
+		p.VX, p.VY, p.VZ = p.VX*0.1+vx*0.4, p.VY*0.1+vy*0.4, p.VZ*0.1+vz*0.4
+		setGray(p, rand.Float64()*0.3+0.6)
+		if id == particleMagicCrit {
+			p.R *= 0.3
+			p.G *= 0.8
+		}
+		p.Size *= 0.75
+		p.Life = 6 / (rand.Float64()*0.8 + 0.6)
+		p.Texture = particleSprite(65)
+		p.Gravity = 0.02
+		p.Drag = 0.7
+	case particleSmoke:
+		p = smokeParticle(x, y, z, vx, vy, vz, 1)
+	case particleLargeSmoke:
+		p = smokeParticle(x, y, z, vx, vy, vz, 2.5)
+	case particleSpell, particleInstantSpell:
+		sprite := 128
+		if id == particleInstantSpell {
+			sprite = 144
+		}
+		p = spellParticle(x, y, z, vx, vy, vz, sprite)
+	case particleMobSpell, particleMobSpellAmbient:
+		// The velocity is the color of the particle for these
+		p = spellParticle(x, y, z, 0, 0, 0, 128)
+		p.R, p.G, p.B = float32(vx), float32(vy), float32(vz)
+		if id == particleMobSpellAmbient {
+			p.A = 0.15
+		}
+	case particleWitchMagic:
+		p = spellParticle(x, y, z, vx, vy, vz, 144)
+		v := rand.Float32()*0.5 + 0.35
+		p.R, p.G, p.B = v, 0, v
+	case particleDripWater, particleDripLava:
+		p = newParticle(x, y, z, 0, 0, 0)
+		p.VX, p.VY, p.VZ = 0, 0, 0
+		p.R, p.G, p.B = 0, 0, 1
+		if id == particleDripLava {
+			p.R, p.G, p.B = 1, 0.3, 0
+			p.Emissive = true
+		}
+		p.Size *= 0.5
+		p.Life = 64 / (rand.Float64()*0.8 + 0.2)
+		p.Texture = particleSprite(113)
+		p.Gravity = 0.06
+		p.Update = func(p *render.Particle, ticks float64) {
+			// Splash once the drip hits the ground
+			if p.OnGround {
+				p.Texture = particleSprite(114)
+				p.Life = math.Min(p.Life, p.Age+4)
+			}
+		}
+	case particleAngryVillager:
+		p = floatingParticle(x, y+0.5, z, vx, vy, vz, 81)
+	case particleHappyVillager:
+		p = auraParticle(x, y, z, vx, vy, vz, 82)
+	case particleNote:
+		p = floatingParticle(x, y, z, 0, 0, 0, 64)
+		p.VY = p.VY*0.2 + 0.1
+		p.Size *= 0.75
+		p.Life = 6
+		p.Drag = 0.66
+		p.Collide = false
+		// The x velocity picks the color of the note
+		p.R = float32(math.Max(0, math.Sin((vx+0.0/3.0)*math.Pi*2)*0.65+0.35))
+		p.G = float32(math.Max(0, math.Sin((vx+1.0/3.0)*math.Pi*2)*0.65+0.35))
+		p.B = float32(math.Max(0, math.Sin((vx+2.0/3.0)*math.Pi*2)*0.65+0.35))
+	case particlePortal:
+		p = travelParticle(x, y, z, vx, vy, vz)
+		v := rand.Float32()*0.6 + 0.4
+		p.R, p.G, p.B = v*0.9, v*0.3, v
+		p.Size *= rand.Float64()*0.2 + 0.5
+		p.Life = float64(rand.Intn(10) + 40)
+		p.Texture = particleSprite(rand.Intn(8))
+		p.Emissive = true
+	case particleEnchantmentTable:
+		p = travelParticle(x, y, z, vx, vy, vz)
+		v := rand.Float32()*0.6 + 0.4
+		p.R, p.G, p.B = v*0.9, v*0.9, v
+		p.Size *= rand.Float64()*0.5 + 0.2
+		p.Life = float64(rand.Intn(10) + 30)
+		p.Texture = particleSprite(225 + rand.Intn(26))
+		p.Emissive = true
+	case particleFlame:
+		p = newParticle(x, y, z, 0, 0, 0)
+		p.VX, p.VY, p.VZ = p.VX*0.01+vx, p.VY*0.01+vy, p.VZ*0.01+vz
+		p.Life = 8/(rand.Float64()*0.8+0.2) + 4
+		p.Texture = particleSprite(48)
+		p.Drag = 0.96
+		p.Emissive = true
+		size := p.Size
+		p.Update = func(p *render.Particle, ticks float64) {
+			f := p.Age / p.Life
+			p.Size = size * (1 - f*f*0.5)
+		}
+	case particleLava:
+		p = newParticle(x, y, z, 0, 0, 0)
+		p.VX, p.VY, p.VZ = p.VX*0.8, rand.Float64()*0.4+0.05, p.VZ*0.8
+		p.Size *= rand.Float64()*2 + 0.2
+		p.Life = 16 / (rand.Float64()*0.8 + 0.2)
+		p.Texture = particleSprite(49)
+		p.Gravity = 0.03
+		p.Drag = 0.999
+		p.Emissive = true
+	case particleFootstep:
+		p = newParticle(x, y+0.05, z, 0, 0, 0)
+		p.VX, p.VY, p.VZ = 0, 0, 0
+		p.Size = 0.25
+		p.Life = 200
+		p.Texture = render.GetTexture("particle/footprint")
+		p.Collide = false
+		p.Update = func(p *render.Particle, ticks float64) {
+			p.A = float32(math.Min(1, 2-p.Age/p.Life*2))
+		}
+	case particleCloud:
+		p = smokeParticle(x, y, z, vx, vy, vz, 2.5)
+		setGray(p, 1-rand.Float64()*0.3)
+		p.Gravity = 0
+	case particleRedDust:
+		p = newParticle(x, y, z, 0, 0, 0)
+		p.VX, p.VY, p.VZ = p.VX*0.1, p.VY*0.1, p.VZ*0.1
+		// The velocity is the color of the dust, red when unset
+		if vx == 0 {
+			vx = 1
+		}
+		v := rand.Float64()*0.4 + 0.6
+		p.R = float32((rand.Float64()*0.2 + 0.8) * vx * v)
+		p.G = float32((rand.Float64()*0.2 + 0.8) * vy * v)
+		p.B = float32((rand.Float64()*0.2 + 0.8) * vz * v)
+		p.Size *= 0.75
+		p.Life = 8 / (rand.Float64()*0.8 + 0.2)
+		p.Frames = particleAnimation(0, 8)
+		p.Drag = 0.96
+	case particleSnowballPoof, particleSlime:
+		itemID := 332 // Snowball
+		if id == particleSlime {
+			itemID = 341 // Slime ball
+		}
+		tex := itemParticleTexture(itemID, 0)
+		if tex == nil {
+			return
+		}
+		p = fragmentParticle(x, y, z, 0, 0, 0, tex)
+	case particleSnowShovel:
+		p = smokeParticle(x, y, z, vx, vy, vz, 1)
+		setGray(p, 1)
+		p.Gravity = 0.03
+		p.Drag = 0.99
+	case particleHeart:
+		p = floatingParticle(x, y, z, vx, vy, vz, 80)
+	case particleBarrier:
+		tex := itemParticleTexture(166, 0)
+		if tex == nil {
+			return
+		}
+		p = newParticle(x, y, z, 0, 0, 0)
+		p.VX, p.VY, p.VZ = 0, 0, 0
+		p.Size = 0.5
+		p.Life = 80
+		p.Texture = tex
+		p.Collide = false
+	case particleIconCrack:
+		if len(data) < 2 {
+			return
+		}
+		tex := itemParticleTexture(int(data[0]), int(data[1]))
+		if tex == nil {
+			return
+		}
+		p = fragmentParticle(x, y, z, vx, vy, vz, tex)
+	case particleBlockCrack, particleBlockDust:
+		if len(data) < 1 {
+			return
+		}
+		tex := blockParticleTexture(GetBlockByStateID(int(data[0])))
+		if tex == nil {
+			return
+		}
+		p = fragmentParticle(x, y, z, vx, vy, vz, tex)
+		setGray(p, 0.6)
+		if id == particleBlockDust {
+			p.VX, p.VY, p.VZ = vx, vy, vz
+		}
+	default:
+		// Item pickups and the elder guardian's appearance
+		// need entity models and aren't particles here
+		return
+	}
+	render.AddParticle(p)
+}
+
+// handleParticle spawns the particles from a Particle packet.
+func handleParticle(p *protocol.Particle) {
+	x, y, z := float64(p.X), float64(p.Y), float64(p.Z)
+	ox, oy, oz := float64(p.OffsetX), float64(p.OffsetY), float64(p.OffsetZ)
+	speed := float64(p.Speed)
+	if p.Count == 0 {
+		// A single particle moving in the direction of the offset
+		spawnParticle(int(p.ParticleID), x, y, z, ox*speed, oy*speed, oz*speed, p.Data)
+		return
+	}
+	for i := 0; i < int(p.Count); i++ {
+		spawnParticle(int(p.ParticleID),
+			x+rand.NormFloat64()*ox, y+rand.NormFloat64()*oy, z+rand.NormFloat64()*oz,
+			rand.NormFloat64()*speed, rand.NormFloat64()*speed, rand.NormFloat64()*speed,
+			p.Data,
+		)
+	}
+}
+
+// handleEffect plays the sound or spawns the particles for an
+// Effect packet.
+func handleEffect(p *protocol.Effect) {
+	if playEffectSound(p) {
+		return
+	}
+	bx, by, bz := p.Location.X(), p.Location.Y(), p.Location.Z()
+	x, y, z := float64(bx)+0.5, float64(by)+0.5, float64(bz)+0.5
+	switch p.EffectID {
+	case 2000: // Smoke from a dispenser, the data is the direction
+		dx, dz := float64(p.Data%3-1), float64(p.Data/3%3-1)
+		x, z = x+dx*0.6, z+dz*0.6
+		for i := 0; i < 10; i++ {
+			speed := rand.Float64()*0.2 + 0.01
+			spawnParticle(particleSmoke,
+				x+dx*0.01+(rand.Float64()-0.5)*dz*0.5,
+				y+(rand.Float64()-0.5)*0.5,
+				z+dz*0.01+(rand.Float64()-0.5)*dx*0.5,
+				dx*speed+rand.NormFloat64()*0.01,
+				-0.03+rand.NormFloat64()*0.01,
+				dz*speed+rand.NormFloat64()*0.01,
+				nil,
+			)
+		}
+	case 2001: // Block break, the data is the block's state id
+		addBlockBreakParticles(GetBlockByStateID(int(p.Data)), bx, by, bz)
+	case 2002: // Splash potion
+		if tex := itemParticleTexture(373, int(p.Data)); tex != nil {
+			for i := 0; i < 8; i++ {
+				render.AddParticle(fragmentParticle(x, y, z,
+					rand.NormFloat64()*0.15, rand.Float64()*0.2, rand.NormFloat64()*0.15,
+					tex,
+				))
+			}
+		}
+		for i := 0; i < 100; i++ {
+			speed := rand.Float64() * 4
+			angle := rand.Float64() * math.Pi * 2
+			spawnParticle(particleSpell, x, y-0.5, z,
+				math.Cos(angle)*speed*0.05, 0.01+rand.Float64()*0.5, math.Sin(angle)*speed*0.05,
+				nil,
+			)
+		}
+	case 2003: // Eye of ender breaking
+		if tex := itemParticleTexture(381, 0); tex != nil {
+			for i := 0; i < 8; i++ {
+				render.AddParticle(fragmentParticle(x, y, z,
+					rand.NormFloat64()*0.15, rand.Float64()*0.2, rand.NormFloat64()*0.15,
+					tex,
+				))
+			}
+		}
+		for a := 0.0; a < math.Pi*2; a += math.Pi / 20 {
+			spawnParticle(particlePortal,
+				x+math.Cos(a)*5, y-0.4, z+math.Sin(a)*5,
+				math.Cos(a)*-5, 0, math.Sin(a)*-5,
+				nil,
+			)
+			spawnParticle(particlePortal,
+				x+math.Cos(a)*5, y-0.4, z+math.Sin(a)*5,
+				math.Cos(a)*-7, 0, math.Sin(a)*-7,
+				nil,
+			)
+		}
+	case 2004: // Mob spawner
+		for i := 0; i < 20; i++ {
+			px := float64(bx) + 0.5 + (rand.Float64()-0.5)*2
+			py := float64(by) + 0.5 + (rand.Float64()-0.5)*2
+			pz := float64(bz) + 0.5 + (rand.Float64()-0.5)*2
+			spawnParticle(particleSmoke, px, py, pz, 0, 0, 0, nil)
+			spawnParticle(particleFlame, px, py, pz, 0, 0, 0, nil)
+		}
+	case 2005: // Bonemeal, the data is the number of particles
+		count := int(p.Data)
+		if count == 0 {
+			count = 15
+		}
+		for i := 0; i < count; i++ {
+			spawnParticle(particleHappyVillager,
+				float64(bx)+rand.Float64(), float64(by)+rand.Float64(), float64(bz)+rand.Float64(),
+				rand.NormFloat64()*0.02, rand.NormFloat64()*0.02, rand.NormFloat64()*0.02,
+				nil,
+			)
+		}
+	}
+}
+
+// addBlockBreakParticles spawns the pieces of a block that was
+// broken at the position.
+func addBlockBreakParticles(b Block, x, y, z int) {
+	tex := blockParticleTexture(b)
+	if tex == nil {
+		return
+	}
+	const count = 4
+	for i := 0; i < count; i++ {
+		for j := 0; j < count; j++ {
+			for k := 0; k < count; k++ {
+				px := float64(x) + (float64(i)+0.5)/count
+				py := float64(y) + (float64(j)+0.5)/count
+				pz := float64(z) + (float64(k)+0.5)/count
+				p := fragmentParticle(px, py, pz,
+					px-float64(x)-0.5, py-float64(y)-0.5, pz-float64(z)-0.5,
+					tex,
+				)
+				setGray(p, 0.6)
+				render.AddParticle(p)
+			}
+		}
+	}
+}
+
+// handleExplosion removes the blocks destroyed by an explosion,
+// spawns its particles and pushes the player away from it.
+func handleExplosion(p *protocol.Explosion) {
+	x, y, z := float64(p.X), float64(p.Y), float64(p.Z)
+	if p.Radius >= 2 {
+		spawnParticle(particleHugeExplosion, x, y, z, 1, 0, 0, nil)
+	} else {
+		spawnParticle(particleLargeExplode, x, y, z, 1, 0, 0, nil)
+	}
+
+	// Records are relative to the explosion's block
+	ex, ey, ez := int(p.X), int(p.Y), int(p.Z)
+	for _, r := range p.Records {
+		bx, by, bz := ex+int(r.X), ey+int(r.Y), ez+int(r.Z)
+		chunkMap.SetBlock(Blocks.Air.Base, bx, by, bz)
+		chunkMap.UpdateBlock(bx, by, bz)
+
+		// Particles moving away from the center of the explosion
+		px := float64(bx) + rand.Float64()
+		py := float64(by) + rand.Float64()
+		pz := float64(bz) + rand.Float64()
+		dx, dy, dz := px-x, py-y, pz-z
+		dist := math.Sqrt(dx*dx + dy*dy + dz*dz)
+		if dist == 0 {
+			continue
+		}
+		dx, dy, dz = dx/dist, dy/dist, dz/dist
+		speed := 0.5 / (dist/float64(p.Radius) + 0.1)
+		speed *= rand.Float64()*rand.Float64() + 0.3
+		dx, dy, dz = dx*speed, dy*speed, dz*speed
+		spawnParticle(particleExplode, (px+x)/2, (py+y)/2, (pz+z)/2, dx, dy, dz, nil)
+		spawnParticle(particleSmoke, px, py, pz, dx, dy, dz, nil)
+	}
+
+	Client.knockback(float64(p.VelocityX), float64(p.VelocityY), float64(p.VelocityZ))
+}
+
+// particleWorld provides the world to the particle simulation.
+type particleWorld struct{}
+
+func (particleWorld) Light(x, y, z float64) float32 {
+	bx, by, bz := int(math.Floor(x)), int(math.Floor(y)), int(math.Floor(z))
+	bl := float64(chunkMap.BlockLight(bx, by, bz))
+	sl := float64(chunkMap.SkyLight(bx, by, bz)) * float64(render.SkyBrightness)
+	light := float32((math.Max(bl, sl) + 1) / 16)
+	// Night vision lights particles up along with the world
+	return light + (1-light)*render.NightVision
+}
+
+func (particleWorld) Solid(x, y, z float64) bool {
+	bx, by, bz := int(math.Floor(x)), int(math.Floor(y)), int(math.Floor(z))
+	b := chunkMap.Block(bx, by, bz)
+	if !b.Collidable() {
+		return false
+	}
+	rx, ry, rz := float32(x)-float32(bx), float32(y)-float32(by), float32(z)-float32(bz)
+	for _, bb := range b.CollisionBounds() {
+		if rx >= bb.Min.X() && rx <= bb.Max.X() &&
+			ry >= bb.Min.Y() && ry <= bb.Max.Y() &&
+			rz >= bb.Min.Z() && rz <= bb.Max.Z() {
+			return true
+		}
+	}
+	return false
+}
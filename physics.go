@@ -0,0 +1,652 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steven
+
+import (
+	"math"
+
+	"github.com/thinkofdeath/steven/protocol"
+	"github.com/thinkofdeath/steven/type/vmath"
+)
+
+// The player's movement is simulated once a tick in the same way
+// as the server does it. Any difference between the two causes the
+// server to move the player back to where it thinks they should be.
+
+const (
+	// The height the player can walk up without jumping
+	stepHeight = 0.6
+	// Speed whilst flying in blocks per a tick
+	flySpeed = 0.6
+	// The speed lost (per a tick) when moving slower than this is
+	// dropped entirely
+	motionCutoff = 0.005
+)
+
+// playerPhysics is the state of the player's movement simulation.
+type playerPhysics struct {
+	// Velocity in blocks per a tick
+	motionX, motionY, motionZ float64
+
+	// The position at the start of the tick, the player is
+	// drawn between this and their current position
+	lastX, lastY, lastZ float64
+	// The fraction of a tick since the last one
+	partial float64
+
+	jumpTicks            int
+	collidedHorizontally bool
+	sprinting            bool
+	// Set when the player touches a sticky block (e.g. a cobweb),
+	// their next move is slowed
+	inWeb bool
+
+	// The states last sent to the server
+	sneaking, sprintSent bool
+}
+
+// physicsBox is an axis aligned box with the same precision the
+// server uses for its physics.
+type physicsBox struct {
+	minX, minY, minZ float64
+	maxX, maxY, maxZ float64
+}
+
+func boxFromAABB(a vmath.AABB) physicsBox {
+	return physicsBox{
+		float64(a.Min.X()), float64(a.Min.Y()), float64(a.Min.Z()),
+		float64(a.Max.X()), float64(a.Max.Y()), float64(a.Max.Z()),
+	}
+}
+
+func (b physicsBox) offset(x, y, z float64) physicsBox {
+	return physicsBox{
+		b.minX + x, b.minY + y, b.minZ + z,
+		b.maxX + x, b.maxY + y, b.maxZ + z,
+	}
+}
+
+// expand grows the box to cover its movement by the offset.
+func (b physicsBox) expand(x, y, z float64) physicsBox {
+	if x < 0 {
+		b.minX += x
+	} else {
+		b.maxX += x
+	}
+	if y < 0 {
+		b.minY += y
+	} else {
+		b.maxY += y
+	}
+	if z < 0 {
+		b.minZ += z
+	} else {
+		b.maxZ += z
+	}
+	return b
+}
+
+// grow grows (or shrinks for negative values) each side of the box.
+func (b physicsBox) grow(x, y, z float64) physicsBox {
+	return physicsBox{
+		b.minX - x, b.minY - y, b.minZ - z,
+		b.maxX + x, b.maxY + y, b.maxZ + z,
+	}
+}
+
+func (b physicsBox) intersects(o physicsBox) bool {
+	return o.maxX > b.minX && o.minX < b.maxX &&
+		o.maxY > b.minY && o.minY < b.maxY &&
+		o.maxZ > b.minZ && o.minZ < b.maxZ
+}
+
+// clipX limits the movement of o along the x axis so that it
+// stops at the box.
+func (b physicsBox) clipX(o physicsBox, dx float64) float64 {
+	if o.maxY <= b.minY || o.minY >= b.maxY || o.maxZ <= b.minZ || o.minZ >= b.maxZ {
+		return dx
+	}
+	if dx > 0 && o.maxX <= b.minX {
+		dx = math.Min(dx, b.minX-o.maxX)
+	} else if dx < 0 && o.minX >= b.maxX {
+		dx = math.Max(dx, b.maxX-o.minX)
+	}
+	return dx
+}
+
+// clipY limits the movement of o along the y axis so that it
+// stops at the box.
+func (b physicsBox) clipY(o physicsBox, dy float64) float64 {
+	if o.maxX <= b.minX || o.minX >= b.maxX || o.maxZ <= b.minZ || o.minZ >= b.maxZ {
+		return dy
+	}
+	if dy > 0 && o.maxY <= b.minY {
+		dy = math.Min(dy, b.minY-o.maxY)
+	} else if dy < 0 && o.minY >= b.maxY {
+		dy = math.Max(dy, b.maxY-o.minY)
+	}
+	return dy
+}
+
+// clipZ limits the movement of o along the z axis so that it
+// stops at the box.
+func (b physicsBox) clipZ(o physicsBox, dz float64) float64 {
+	if o.maxX <= b.minX || o.minX >= b.maxX || o.maxY <= b.minY || o.minY >= b.maxY {
+		return dz
+	}
+	if dz > 0 && o.maxZ <= b.minZ {
+		dz = math.Min(dz, b.minZ-o.maxZ)
+	} else if dz < 0 && o.minZ >= b.maxZ {
+		dz = math.Max(dz, b.maxZ-o.minZ)
+	}
+	return dz
+}
+
+// forBlocksIn calls f for every block the box is in.
+func forBlocksIn(box physicsBox, f func(x, y, z int, b Block)) {
+	minX, minY, minZ := int(math.Floor(box.minX)), int(math.Floor(box.minY)), int(math.Floor(box.minZ))
+	maxX, maxY, maxZ := int(math.Floor(box.maxX)), int(math.Floor(box.maxY)), int(math.Floor(box.maxZ))
+	for y := minY; y <= maxY; y++ {
+		for z := minZ; z <= maxZ; z++ {
+			for x := minX; x <= maxX; x++ {
+				f(x, y, z, chunkMap.Block(x, y, z))
+			}
+		}
+	}
+}
+
+// playerBox returns the player's bounding box at their current
+// position.
+func (c *ClientState) playerBox() physicsBox {
+	return boxFromAABB(c.Bounds).offset(c.X, c.Y, c.Z)
+}
+
+func (c *ClientState) setPlayerBox(box physicsBox) {
+	c.X = (box.minX + box.maxX) / 2
+	c.Y = box.minY
+	c.Z = (box.minZ + box.maxZ) / 2
+}
+
+// collisionBoxes returns the boxes of the blocks (and the world
+// border) that the box collides with.
+func (c *ClientState) collisionBoxes(box physicsBox) (out []physicsBox) {
+	// Blocks such as fences stick up into the block above them
+	// so the search starts a block lower
+	forBlocksIn(box.offset(0, -1, 0).expand(0, 1, 0), func(x, y, z int, b Block) {
+		if !b.Collidable() {
+			return
+		}
+		for _, bb := range b.CollisionBounds() {
+			pb := boxFromAABB(bb).offset(float64(x), float64(y), float64(z))
+			if pb.intersects(box) {
+				out = append(out, pb)
+			}
+		}
+	})
+	for _, bb := range c.worldBorder.collisionBoxes(c.X, c.Z) {
+		if bb.intersects(box) {
+			out = append(out, bb)
+		}
+	}
+	return out
+}
+
+// liquidIn returns whether any part of the box is in the liquid,
+// water is only counted if the box reaches its surface.
+func liquidIn(box physicsBox, lava bool) (found bool) {
+	forBlocksIn(box, func(x, y, z int, b Block) {
+		l, ok := b.(*blockLiquid)
+		if !ok || l.Lava != lava {
+			return
+		}
+		if lava {
+			found = true
+			return
+		}
+		// Falling water (level 8 and over) is as high as a
+		// source block
+		level := l.Level
+		if level >= 8 {
+			level = 0
+		}
+		if box.maxY >= float64(y)+1-float64(level+1)/9 {
+			found = true
+		}
+	})
+	return found
+}
+
+func (c *ClientState) inWater() bool {
+	return liquidIn(c.playerBox().grow(-0.001, -0.4-0.001, -0.001), false)
+}
+
+func (c *ClientState) inLava() bool {
+	return liquidIn(c.playerBox().grow(-0.1, -0.4, -0.1), true)
+}
+
+// isFree returns whether the player could be moved by the offset
+// without hitting a block or entering a liquid.
+func (c *ClientState) isFree(x, y, z float64) bool {
+	box := c.playerBox().offset(x, y, z)
+	if len(c.collisionBoxes(box)) != 0 {
+		return false
+	}
+	free := true
+	forBlocksIn(box, func(x, y, z int, b Block) {
+		if _, ok := b.(*blockLiquid); ok {
+			free = false
+		}
+	})
+	return free
+}
+
+func (c *ClientState) onLadder() bool {
+	b := chunkMap.Block(int(math.Floor(c.X)), int(math.Floor(c.Y)), int(math.Floor(c.Z)))
+	return b.Climbable()
+}
+
+// blockBelow returns the block the player is standing on.
+func (c *ClientState) blockBelow() Block {
+	return chunkMap.Block(int(math.Floor(c.X)), int(math.Floor(c.Y))-1, int(math.Floor(c.Z)))
+}
+
+// movementInput returns the movement keys as sideways (left is
+// positive) and forward input.
+func (c *ClientState) movementInput() (strafe, forward float64) {
+	if c.KeyState[KeyForward] {
+		forward++
+	}
+	if c.KeyState[KeyBackwards] {
+		forward--
+	}
+	if c.KeyState[KeyLeft] {
+		strafe++
+	}
+	if c.KeyState[KeyRight] {
+		strafe--
+	}
+	if c.KeyState[KeySneak] {
+		strafe *= 0.3
+		forward *= 0.3
+	}
+	return strafe, forward
+}
+
+// physicsTick moves the player by a single tick.
+func (c *ClientState) physicsTick() {
+	p := &c.physics
+	p.lastX, p.lastY, p.lastZ = c.X, c.Y, c.Z
+	p.partial = 0
+
+	if c.vehicle() != nil {
+		// Moved by the vehicle instead
+		return
+	}
+	if chunkMap[chunkPosition{int(math.Floor(c.X)) >> 4, int(math.Floor(c.Z)) >> 4}] == nil {
+		// Wait for the world to load
+		return
+	}
+
+	strafe, forward := c.movementInput()
+	sneaking := c.KeyState[KeySneak] && !c.GameMode.Fly()
+	p.sprinting = c.KeyState[KeySprint] && forward >= 0.8 &&
+		(c.Hunger > 6 || c.GameMode.Fly()) &&
+		c.effects.Effect(effectBlindness) == nil &&
+		!p.collidedHorizontally
+	c.sendMovementState(sneaking, p.sprinting)
+
+	if c.GameMode.Fly() {
+		c.flyTick()
+		return
+	}
+
+	if p.jumpTicks > 0 {
+		p.jumpTicks--
+	}
+	if math.Abs(p.motionX) < motionCutoff {
+		p.motionX = 0
+	}
+	if math.Abs(p.motionY) < motionCutoff {
+		p.motionY = 0
+	}
+	if math.Abs(p.motionZ) < motionCutoff {
+		p.motionZ = 0
+	}
+
+	inWater, inLava := c.inWater(), c.inLava()
+	if c.KeyState[KeyJump] {
+		if inWater || inLava {
+			p.motionY += 0.04
+		} else if c.OnGround && p.jumpTicks == 0 {
+			c.jump()
+			p.jumpTicks = 10
+		}
+	} else {
+		p.jumpTicks = 0
+	}
+
+	strafe *= 0.98
+	forward *= 0.98
+
+	switch {
+	case inWater || inLava:
+		drag := 0.8
+		if inLava {
+			drag = 0.5
+		}
+		y := c.Y
+		c.accelerate(strafe, forward, 0.02)
+		c.moveEntity(p.motionX, p.motionY, p.motionZ, sneaking)
+		p.motionX *= drag
+		p.motionY *= drag
+		p.motionZ *= drag
+		p.motionY -= 0.02
+		// Lets the player jump out of the liquid onto the bank
+		if p.collidedHorizontally && c.isFree(p.motionX, p.motionY+0.6-c.Y+y, p.motionZ) {
+			p.motionY = 0.3
+		}
+	default:
+		friction := 0.91
+		if c.OnGround {
+			friction *= c.blockBelow().Slipperiness()
+		}
+		speed := 0.02
+		if p.sprinting {
+			speed += 0.02 * 0.3
+		}
+		if c.OnGround {
+			// Slippery blocks need less speed to keep the same
+			// top speed
+			speed = c.walkSpeed() * (0.16277136 / (friction * friction * friction))
+		}
+		c.accelerate(strafe, forward, speed)
+
+		if c.onLadder() {
+			const climbSpeed = 0.15
+			p.motionX = math.Max(-climbSpeed, math.Min(climbSpeed, p.motionX))
+			p.motionZ = math.Max(-climbSpeed, math.Min(climbSpeed, p.motionZ))
+			if p.motionY < -climbSpeed {
+				p.motionY = -climbSpeed
+			}
+			// Sneaking holds the player in place
+			if sneaking && p.motionY < 0 {
+				p.motionY = 0
+			}
+		}
+		c.moveEntity(p.motionX, p.motionY, p.motionZ, sneaking)
+		if p.collidedHorizontally && c.onLadder() {
+			p.motionY = 0.2
+		}
+
+		p.motionY -= 0.08
+		p.motionY *= 0.98
+		p.motionX *= friction
+		p.motionZ *= friction
+	}
+}
+
+// walkSpeed returns the player's speed on the ground before
+// friction.
+func (c *ClientState) walkSpeed() float64 {
+	speed := 0.1 * c.movementSpeed()
+	if c.physics.sprinting {
+		speed *= 1.3
+	}
+	return speed
+}
+
+// accelerate adds the movement input, rotated to the way the player
+// is facing, to their motion.
+func (c *ClientState) accelerate(strafe, forward, speed float64) {
+	l := strafe*strafe + forward*forward
+	if l < 1e-4 {
+		return
+	}
+	l = math.Max(1, math.Sqrt(l))
+	strafe *= speed / l
+	forward *= speed / l
+	sin, cos := math.Sin(c.Yaw), math.Cos(c.Yaw)
+	c.physics.motionX += strafe*cos + forward*sin
+	c.physics.motionZ += forward*cos - strafe*sin
+}
+
+func (c *ClientState) jump() {
+	p := &c.physics
+	p.motionY = 0.42 + c.jumpBoost()
+	if p.sprinting {
+		p.motionX += math.Sin(c.Yaw) * 0.2
+		p.motionZ += math.Cos(c.Yaw) * 0.2
+	}
+}
+
+// flyTick moves the player the way they are looking whilst flying.
+func (c *ClientState) flyTick() {
+	p := &c.physics
+	p.motionX, p.motionY, p.motionZ = 0, 0, 0
+	forward, yaw := c.calculateMovement()
+	dx := forward * math.Cos(yaw) * -math.Cos(c.Pitch) * flySpeed
+	dz := -forward * math.Sin(yaw) * -math.Cos(c.Pitch) * flySpeed
+	dy := -forward * math.Sin(c.Pitch) * flySpeed
+	if c.GameMode.NoClip() {
+		c.X += dx
+		c.Y += dy
+		c.Z += dz
+		return
+	}
+	c.moveEntity(dx, dy, dz, false)
+}
+
+// moveEntity moves the player by the offset, sliding along and
+// stepping up any blocks in the way.
+func (c *ClientState) moveEntity(dx, dy, dz float64, sneaking bool) {
+	p := &c.physics
+	if p.inWeb {
+		p.inWeb = false
+		dx *= 0.25
+		dy *= 0.05
+		dz *= 0.25
+		p.motionX, p.motionY, p.motionZ = 0, 0, 0
+	}
+	ox, oy, oz := dx, dy, dz
+	box := c.playerBox()
+
+	if c.OnGround && sneaking {
+		// Stops the player walking off the edge of the block
+		// they are standing on
+		const step = 0.05
+		towards := func(v float64) float64 {
+			switch {
+			case v < step && v >= -step:
+				return 0
+			case v > 0:
+				return v - step
+			}
+			return v + step
+		}
+		for dx != 0 && len(c.collisionBoxes(box.offset(dx, -1, 0))) == 0 {
+			dx = towards(dx)
+			ox = dx
+		}
+		for dz != 0 && len(c.collisionBoxes(box.offset(0, -1, dz))) == 0 {
+			dz = towards(dz)
+			oz = dz
+		}
+		for dx != 0 && dz != 0 && len(c.collisionBoxes(box.offset(dx, -1, dz))) == 0 {
+			dx = towards(dx)
+			ox = dx
+			dz = towards(dz)
+			oz = dz
+		}
+	}
+
+	boxes := c.collisionBoxes(box.expand(dx, dy, dz))
+	start := box
+	for _, bb := range boxes {
+		dy = bb.clipY(box, dy)
+	}
+	box = box.offset(0, dy, 0)
+	canStep := c.OnGround || (oy != dy && oy < 0)
+	for _, bb := range boxes {
+		dx = bb.clipX(box, dx)
+	}
+	box = box.offset(dx, 0, 0)
+	for _, bb := range boxes {
+		dz = bb.clipZ(box, dz)
+	}
+	box = box.offset(0, 0, dz)
+
+	if canStep && (ox != dx || oz != dz) {
+		// Try moving up by the step height first and use that if
+		// it gets the player further
+		flatX, flatY, flatZ, flat := dx, dy, dz, box
+		boxes := c.collisionBoxes(start.expand(ox, stepHeight, oz))
+
+		// Steps up as high as the space above the player's
+		// destination allows
+		high := start
+		highY := float64(stepHeight)
+		dest := start.expand(ox, 0, oz)
+		for _, bb := range boxes {
+			highY = bb.clipY(dest, highY)
+		}
+		high = high.offset(0, highY, 0)
+		highX := ox
+		for _, bb := range boxes {
+			highX = bb.clipX(high, highX)
+		}
+		high = high.offset(highX, 0, 0)
+		highZ := oz
+		for _, bb := range boxes {
+			highZ = bb.clipZ(high, highZ)
+		}
+		high = high.offset(0, 0, highZ)
+
+		// Steps up as high as the space above the player allows
+		low := start
+		lowY := float64(stepHeight)
+		for _, bb := range boxes {
+			lowY = bb.clipY(low, lowY)
+		}
+		low = low.offset(0, lowY, 0)
+		lowX := ox
+		for _, bb := range boxes {
+			lowX = bb.clipX(low, lowX)
+		}
+		low = low.offset(lowX, 0, 0)
+		lowZ := oz
+		for _, bb := range boxes {
+			lowZ = bb.clipZ(low, lowZ)
+		}
+		low = low.offset(0, 0, lowZ)
+
+		if highX*highX+highZ*highZ > lowX*lowX+lowZ*lowZ {
+			dx, dz, dy, box = highX, highZ, -highY, high
+		} else {
+			dx, dz, dy, box = lowX, lowZ, -lowY, low
+		}
+		// Drops back down onto the step
+		for _, bb := range boxes {
+			dy = bb.clipY(box, dy)
+		}
+		box = box.offset(0, dy, 0)
+
+		if flatX*flatX+flatZ*flatZ >= dx*dx+dz*dz {
+			dx, dy, dz, box = flatX, flatY, flatZ, flat
+		}
+	}
+	c.setPlayerBox(box)
+
+	p.collidedHorizontally = ox != dx || oz != dz
+	wasOnGround := c.OnGround
+	c.OnGround = oy != dy && oy < 0
+	if !wasOnGround && c.OnGround {
+		c.didTouchGround = true
+	}
+	if ox != dx {
+		p.motionX = 0
+	}
+	if oy != dy {
+		p.motionY = 0
+	}
+	if oz != dz {
+		p.motionZ = 0
+	}
+
+	// Blocks such as soul sand slow down the player whilst
+	// they are inside them
+	forBlocksIn(c.playerBox().grow(-0.001, -0.001, -0.001), func(x, y, z int, b Block) {
+		m := b.MotionMultiplier()
+		p.motionX *= m
+		p.motionZ *= m
+		if b.Sticky() {
+			p.inWeb = true
+		}
+	})
+}
+
+// checkGround updates whether the player is standing on a block.
+func (c *ClientState) checkGround() {
+	prev := c.OnGround
+	c.OnGround = len(c.collisionBoxes(c.playerBox().offset(0, -0.05, 0))) != 0
+	if !prev && c.OnGround {
+		c.didTouchGround = true
+	}
+}
+
+// sendMovementState tells the server when the player starts or
+// stops sneaking and sprinting.
+func (c *ClientState) sendMovementState(sneaking, sprinting bool) {
+	p := &c.physics
+	if sprinting != p.sprintSent {
+		p.sprintSent = sprinting
+		action := 4
+		if sprinting {
+			action = 3
+		}
+		c.network.Write(&protocol.PlayerAction{
+			EntityID: protocol.VarInt(c.entityID),
+			ActionID: protocol.VarInt(action),
+		})
+	}
+	if sneaking != p.sneaking {
+		p.sneaking = sneaking
+		action := 1
+		if sneaking {
+			action = 0
+		}
+		c.network.Write(&protocol.PlayerAction{
+			EntityID: protocol.VarInt(c.entityID),
+			ActionID: protocol.VarInt(action),
+		})
+	}
+}
+
+// knockback pushes the player with the velocity (in blocks per a
+// tick).
+func (c *ClientState) knockback(vx, vy, vz float64) {
+	c.physics.motionX += vx
+	c.physics.motionY += vy
+	c.physics.motionZ += vz
+}
+
+// interpolatedPosition returns where the player is drawn between
+// their last two simulated positions.
+func (c *ClientState) interpolatedPosition() (x, y, z float64) {
+	p := &c.physics
+	t := p.partial
+	return p.lastX + (c.X-p.lastX)*t,
+		p.lastY + (c.Y-p.lastY)*t,
+		p.lastZ + (c.Z-p.lastZ)*t
+}
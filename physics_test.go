@@ -0,0 +1,313 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steven
+
+import (
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/thinkofdeath/steven/type/direction"
+	"github.com/thinkofdeath/steven/type/vmath"
+)
+
+// The expected values are worked out from the vanilla client's
+// movement code.
+
+const physicsEpsilon = 1e-6
+
+var physicsBlocks sync.Once
+
+// newPhysicsClient returns a player standing at the position in an
+// empty world.
+func newPhysicsClient(x, y, z float64) *ClientState {
+	physicsBlocks.Do(initBlockIDs)
+	chunkMap = world{}
+	c := &ClientState{
+		Bounds: vmath.AABB{
+			Min: mgl32.Vec3{-0.3, 0, -0.3},
+			Max: mgl32.Vec3{0.3, 1.8, 0.3},
+		},
+		vehicleID: -1,
+	}
+	c.worldBorder.oldSize, c.worldBorder.newSize = defaultBorderSize, defaultBorderSize
+	c.X, c.Y, c.Z = x, y, z
+	setPhysicsBlock(int(math.Floor(x)), int(math.Floor(y)), int(math.Floor(z)), Blocks.Air.Base)
+	return c
+}
+
+// standPhysicsClient puts the player on the ground as if they had
+// been standing there for a while.
+func standPhysicsClient(c *ClientState) {
+	c.OnGround = true
+	c.physics.motionY = -0.08 * 0.98
+}
+
+// setPhysicsBlock places a block, creating its chunk if needed.
+func setPhysicsBlock(x, y, z int, b Block) {
+	pos := chunkPosition{x >> 4, z >> 4}
+	c := chunkMap[pos]
+	if c == nil {
+		c = &chunk{chunkPosition: pos}
+		chunkMap[pos] = c
+	}
+	s := c.Sections[y>>4]
+	if s == nil {
+		s = newChunkSection(c, y>>4)
+		c.Sections[y>>4] = s
+	}
+	s.setBlock(b, x&0xF, y&0xF, z&0xF)
+}
+
+// fillPhysicsBlocks places the block in every position between the
+// two corners (inclusive).
+func fillPhysicsBlocks(x1, y1, z1, x2, y2, z2 int, b Block) {
+	for y := y1; y <= y2; y++ {
+		for z := z1; z <= z2; z++ {
+			for x := x1; x <= x2; x++ {
+				setPhysicsBlock(x, y, z, b)
+			}
+		}
+	}
+}
+
+func TestPhysicsJump(t *testing.T) {
+	tests := []struct {
+		name      string
+		boost     bool
+		apex      float64
+		apexTicks int
+	}{
+		{"normal", false, 1.2491871, 5},
+		{"jump boost", true, 1.8361316, 7},
+	}
+	for _, test := range tests {
+		c := newPhysicsClient(0.5, 64, 0.5)
+		fillPhysicsBlocks(-1, 63, -1, 1, 63, 1, Blocks.Stone.Base)
+		standPhysicsClient(c)
+		if test.boost {
+			c.effects.AddEffect(statusEffect{Type: effectJumpBoost})
+		}
+
+		c.KeyState[KeyJump] = true
+		apex, apexTicks := 0.0, 0
+		for tick := 1; tick <= 40; tick++ {
+			c.physicsTick()
+			c.KeyState[KeyJump] = false
+			if h := c.Y - 64; h > apex {
+				apex, apexTicks = h, tick
+			}
+		}
+		if math.Abs(apex-test.apex) > physicsEpsilon || apexTicks != test.apexTicks {
+			t.Errorf("%s: apex %f after %d ticks, wanted %f after %d ticks",
+				test.name, apex, apexTicks, test.apex, test.apexTicks)
+		}
+		if c.Y != 64 || !c.OnGround {
+			t.Errorf("%s: didn't land, at %f", test.name, c.Y)
+		}
+	}
+}
+
+func TestPhysicsTopSpeed(t *testing.T) {
+	tests := []struct {
+		name  string
+		floor Block
+		// The height of the top of the floor
+		height float64
+		speed  float64
+	}{
+		{"stone", Blocks.Stone.Base, 64, 0.2158591},
+		{"ice", Blocks.Ice.Base, 64, 0.2078615},
+		// Slowed once for each block the player is in, so the
+		// speed changes as the player crosses between blocks
+		{"soul sand", Blocks.SoulSand.Base, 63 + 14/16.0, 0.1133228},
+	}
+	for _, test := range tests {
+		c := newPhysicsClient(0.5, test.height, 0.5)
+		fillPhysicsBlocks(-1, 62, -1, 1, 62, 50, Blocks.Stone.Base)
+		fillPhysicsBlocks(-1, 63, -1, 1, 63, 50, test.floor)
+		standPhysicsClient(c)
+
+		// A yaw of 0 walks towards positive z. The speed is
+		// averaged once the player has had time to speed up
+		c.KeyState[KeyForward] = true
+		var start float64
+		for i := 0; i < 200; i++ {
+			if i == 100 {
+				start = c.Z
+			}
+			c.physicsTick()
+		}
+		if speed := (c.Z - start) / 100; math.Abs(speed-test.speed) > physicsEpsilon {
+			t.Errorf("%s: top speed %f, wanted %f", test.name, speed, test.speed)
+		}
+		if c.Y != test.height {
+			t.Errorf("%s: left the floor, at %f", test.name, c.Y)
+		}
+	}
+}
+
+func TestPhysicsStep(t *testing.T) {
+	tests := []struct {
+		name  string
+		step  Block
+		wantY float64
+		// Whether the player should get past the block
+		past bool
+	}{
+		{"slab", Blocks.StoneSlab.Base.Set("half", slabBottom), 64.5, true},
+		{"full block", Blocks.Stone.Base, 64, false},
+	}
+	for _, test := range tests {
+		c := newPhysicsClient(0.5, 64, 0.5)
+		fillPhysicsBlocks(-1, 63, -1, 1, 63, 4, Blocks.Stone.Base)
+		fillPhysicsBlocks(-1, 64, 2, 1, 64, 4, test.step)
+		standPhysicsClient(c)
+
+		c.KeyState[KeyForward] = true
+		for i := 0; i < 20; i++ {
+			c.physicsTick()
+		}
+		if c.Y != test.wantY {
+			t.Errorf("%s: at height %f, wanted %f", test.name, c.Y, test.wantY)
+		}
+		if past := c.Z > 2.3; past != test.past {
+			t.Errorf("%s: got to %f", test.name, c.Z)
+		}
+	}
+}
+
+func TestPhysicsSneakEdge(t *testing.T) {
+	tests := []struct {
+		name     string
+		sneaking bool
+		wantZ    float64
+	}{
+		// Stops once the edge of the player is only just over
+		// the block
+		{"sneaking", true, 1.3},
+		{"walking", false, 1.5},
+	}
+	for _, test := range tests {
+		c := newPhysicsClient(0.5, 64, 0.5)
+		setPhysicsBlock(0, 63, 0, Blocks.Stone.Base)
+		standPhysicsClient(c)
+
+		c.moveEntity(0, 0, 1, test.sneaking)
+		if math.Abs(c.Z-test.wantZ) > physicsEpsilon {
+			t.Errorf("%s: moved to %f, wanted %f", test.name, c.Z, test.wantZ)
+		}
+	}
+}
+
+func TestPhysicsLadder(t *testing.T) {
+	tests := []struct {
+		name   string
+		block  Block
+		motion float64
+		moved  float64
+	}{
+		{"fast", Blocks.Ladder.Base, -0.5, -0.15},
+		{"slow", Blocks.Ladder.Base, -0.1, -0.1},
+		{"no ladder", Blocks.Air.Base, -0.5, -0.5},
+	}
+	for _, test := range tests {
+		c := newPhysicsClient(0.5, 70, 0.5)
+		fillPhysicsBlocks(0, 64, 0, 0, 72, 0, test.block)
+		c.physics.motionY = test.motion
+
+		c.physicsTick()
+		if moved := c.Y - 70; math.Abs(moved-test.moved) > physicsEpsilon {
+			t.Errorf("%s: moved %f, wanted %f", test.name, moved, test.moved)
+		}
+	}
+
+	// Walking into the ladder from the middle of its block stops
+	// at the side the ladder is on
+	walls := []struct {
+		facing direction.Type
+		dx, dz float64
+		x, z   float64
+	}{
+		{direction.North, 0, 1, 0.5, 1 - 2/16.0 - 0.3},
+		{direction.South, 0, -1, 0.5, 2/16.0 + 0.3},
+		{direction.West, 1, 0, 1 - 2/16.0 - 0.3, 0.5},
+		{direction.East, -1, 0, 2/16.0 + 0.3, 0.5},
+	}
+	for _, test := range walls {
+		c := newPhysicsClient(0.5, 64, 0.5)
+		setPhysicsBlock(0, 63, 0, Blocks.Stone.Base)
+		fillPhysicsBlocks(0, 64, 0, 0, 65, 0, Blocks.Ladder.Base.Set("facing", test.facing))
+		standPhysicsClient(c)
+
+		c.moveEntity(test.dx, 0, test.dz, false)
+		if math.Abs(c.X-test.x) > physicsEpsilon || math.Abs(c.Z-test.z) > physicsEpsilon {
+			t.Errorf("facing %s: moved to %f, %f, wanted %f, %f",
+				test.facing, c.X, c.Z, test.x, test.z)
+		}
+	}
+}
+
+func TestPhysicsLiquidDrag(t *testing.T) {
+	tests := []struct {
+		name   string
+		liquid Block
+		drag   float64
+	}{
+		{"water", Blocks.Water.Base, 0.8},
+		{"lava", Blocks.Lava.Base, 0.5},
+	}
+	for _, test := range tests {
+		c := newPhysicsClient(0.5, 64, 0.5)
+		fillPhysicsBlocks(-2, 64, -2, 3, 66, 2, test.liquid)
+		c.physics.motionX = 0.5
+
+		c.physicsTick()
+		if moved := c.X - 0.5; math.Abs(moved-0.5) > physicsEpsilon {
+			t.Errorf("%s: moved %f, wanted 0.5", test.name, moved)
+		}
+		p := c.physics
+		if math.Abs(p.motionX-0.5*test.drag) > physicsEpsilon {
+			t.Errorf("%s: speed %f, wanted %f", test.name, p.motionX, 0.5*test.drag)
+		}
+		// Sinks slowly
+		if math.Abs(p.motionY+0.02) > physicsEpsilon {
+			t.Errorf("%s: falling at %f, wanted 0.02", test.name, -p.motionY)
+		}
+	}
+}
+
+func TestPhysicsCobweb(t *testing.T) {
+	c := newPhysicsClient(0.5, 64, 0.5)
+	fillPhysicsBlocks(-1, 63, -1, 4, 63, 1, Blocks.Stone.Base)
+	fillPhysicsBlocks(0, 64, 0, 4, 65, 0, Blocks.Web.Base)
+	standPhysicsClient(c)
+
+	// The cobweb only slows down the move after the one that
+	// touched it
+	c.moveEntity(0.5, 0, 0, false)
+	if c.X != 1 {
+		t.Errorf("first move got to %f, wanted 1", c.X)
+	}
+	c.physics.motionX = 0.5
+	c.moveEntity(0.5, 0, 0, false)
+	if math.Abs(c.X-1.125) > physicsEpsilon {
+		t.Errorf("second move got to %f, wanted 1.125", c.X)
+	}
+	if c.physics.motionX != 0 {
+		t.Errorf("still moving at %f", c.physics.motionX)
+	}
+}
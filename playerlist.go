@@ -16,6 +16,7 @@ package steven
 
 import (
 	"sort"
+	"strconv"
 
 	"github.com/thinkofdeath/steven/chat"
 	"github.com/thinkofdeath/steven/protocol"
@@ -45,7 +46,9 @@ type playerListUI struct {
 }
 
 type playerListUIEntry struct {
-	text    *ui.Text
+	text    *ui.Formatted
+	name    string
+	score   *ui.Text
 	icon    *ui.Image
 	iconHat *ui.Image
 	ping    *ui.Image
@@ -53,6 +56,7 @@ type playerListUIEntry struct {
 
 func (p playerListUIEntry) set(enabled bool) {
 	p.text.SetDraw(enabled)
+	p.score.SetDraw(enabled)
 	p.icon.SetDraw(enabled)
 	p.iconHat.SetDraw(enabled)
 	p.ping.SetDraw(enabled)
@@ -119,9 +123,13 @@ func (p *playerListUI) render(delta float64) {
 		background := p.background[bTab]
 		background.SetDraw(true)
 		if offset >= len(p.entries) {
-			text := ui.NewText("", 24, 0, 255, 255, 255).
+			text := ui.NewFormatted(chat.AnyComponent{Value: &chat.TextComponent{}}, 24, 0).
 				Attach(ui.Top, ui.Left)
 			p.scene.AddDrawable(text)
+			// Leaves space for the ping icon
+			score := ui.NewText("", 24, 0, 255, 255, 85).
+				Attach(ui.Top, ui.Right)
+			p.scene.AddDrawable(score)
 			icon := ui.NewImage(pl.skin, 0, 0, 16, 16, 8/64.0, 8/64.0, 8/64.0, 8/64.0, 255, 255, 255).
 				Attach(ui.Top, ui.Center)
 			p.scene.AddDrawable(icon)
@@ -133,12 +141,14 @@ func (p *playerListUI) render(delta float64) {
 			p.scene.AddDrawable(ping)
 
 			text.AttachTo(background)
+			score.AttachTo(background)
 			icon.AttachTo(background)
 			iconHat.AttachTo(background)
 			ping.AttachTo(background)
 
 			p.entries = append(p.entries, &playerListUIEntry{
 				text:    text,
+				score:   score,
 				icon:    icon,
 				iconHat: iconHat,
 				ping:    ping,
@@ -148,7 +158,18 @@ func (p *playerListUI) render(delta float64) {
 		e.set(true)
 		offset++
 		e.text.SetY(1 + 18*float64(count))
-		e.text.Update(pl.name)
+		// Only rebuild the name when it changes as formatted
+		// text is costly to update
+		if name := Client.teams.formatName(pl.name); name != e.name {
+			e.name = name
+			e.text.Update(legacyComponent(name))
+		}
+		e.score.SetY(1 + 18*float64(count))
+		if score, ok := Client.scoreboard.score(slotList, pl.name); ok {
+			e.score.Update(strconv.Itoa(score))
+		} else {
+			e.score.SetDraw(false)
+		}
 		e.icon.SetY(1 + 18*float64(count))
 		e.icon.SetTexture(pl.skin)
 		e.iconHat.SetY(1 + 18*float64(count))
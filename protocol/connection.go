@@ -48,6 +48,15 @@ type Conn struct {
 
 	zlibReader io.ReadCloser
 	zlibWriter *zlib.Writer
+
+	// A packet read early (e.g. during login) that will be
+	// returned by the next call to ReadPacket
+	pending Packet
+
+	recorder *Recorder
+	// The packet registry for the protocol version in use,
+	// nil for SupportedProtocolVersion
+	version *protocolVersion
 }
 
 // Dial creates a connection to a Minecraft server at
@@ -94,13 +103,18 @@ func Dial(address string) (*Conn, error) {
 // WritePacket serializes the packet to the underlying
 // connection, optionally encrypting and/or compressing
 func (c *Conn) WritePacket(packet Packet) error {
+	id, err := c.packetID(c.State, c.direction, packet)
+	if err != nil {
+		return err
+	}
 	// 15 second timeout
-	c.net.SetWriteDeadline(time.Now().Add(15 * time.Second))
+	if err := c.net.SetWriteDeadline(time.Now().Add(15 * time.Second)); err != nil {
+		return err
+	}
 
 	buf := &bytes.Buffer{}
-
 	// Contents of the packet (ID + Data)
-	if err := WriteVarInt(buf, VarInt(packet.id())); err != nil {
+	if err := WriteVarInt(buf, VarInt(id)); err != nil {
 		return err
 	}
 	if err := packet.write(buf); err != nil {
@@ -134,28 +148,43 @@ func (c *Conn) WritePacket(packet Packet) error {
 		extra = varIntSize(VarInt(uncompessedSize))
 	}
 
-	// Write the length prefix followed by the buffer
-	if err := WriteVarInt(c.w, VarInt(buf.Len()+extra)); err != nil {
+	// The length prefix followed by the uncompressed packet size
+	// are sent in a single write so that nothing more is sent
+	// once the connection fails
+	header := &bytes.Buffer{}
+	WriteVarInt(header, VarInt(buf.Len()+extra))
+	if c.compressionThreshold >= 0 {
+		WriteVarInt(header, VarInt(uncompessedSize))
+	}
+	if _, err := header.WriteTo(c.w); err != nil {
 		return err
 	}
 
-	// Write the uncompressed packet size
-	if c.compressionThreshold >= 0 {
-		if err := WriteVarInt(c.w, VarInt(uncompessedSize)); err != nil {
-			return err
-		}
+	if _, err := buf.WriteTo(c.w); err != nil {
+		return err
 	}
-
-	_, err := buf.WriteTo(c.w)
-	return err
+	if c.recorder != nil {
+		return c.recorder.record(c.State, c.direction, packet)
+	}
+	return nil
 }
 
 // ReadPacket deserializes a packet from the underlying
 // connection, optionally decrypting and/or decompressing
 func (c *Conn) ReadPacket() (Packet, error) {
+	if c.pending != nil {
+		packet := c.pending
+		c.pending = nil
+		return packet, nil
+	}
 	// 15 second timeout
 	c.net.SetReadDeadline(time.Now().Add(15 * time.Second))
-	return c.readPacket()
+	state := c.State
+	packet, err := c.readPacket()
+	if err == nil && c.recorder != nil {
+		err = c.recorder.record(state, (c.direction+1)&1, packet)
+	}
+	return packet, err
 }
 
 var (
@@ -219,11 +248,20 @@ func (c *Conn) readPacket() (Packet, error) {
 		return nil, err
 	}
 	// Direction is swapped as this is coming from the other way
-	packets := packetCreator[c.State][(c.direction+1)&1]
-	if id < 0 || int(id) >= len(packets) || packets[id] == nil {
+	creator := c.packetCreatorFor(c.State, (c.direction+1)&1, id)
+	if creator == nil {
+		if c.version != nil {
+			// The whole packet has been read so the connection
+			// can carry on without it
+			return nil, &UnsupportedPacketError{
+				Version: c.version.version,
+				State:   c.State,
+				ID:      int(id),
+			}
+		}
 		return nil, fmt.Errorf("Unknown packet %s:%02X", c.State, id)
 	}
-	packet := packets[id]()
+	packet := creator()
 	if err := packet.read(r); err != nil {
 		return packet, fmt.Errorf("packet(%s:%02X): %s", c.State, id, err)
 	}
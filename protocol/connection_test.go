@@ -16,6 +16,8 @@ package protocol
 
 import (
 	"bytes"
+	"errors"
+	"net"
 	"reflect"
 	"strings"
 	"testing"
@@ -82,3 +84,34 @@ func TestCrashers(t *testing.T) {
 		c.readPacket()
 	}
 }
+
+// failingWriter fails every write and counts the attempts.
+type failingWriter struct {
+	writes int
+}
+
+func (f *failingWriter) Write(b []byte) (int, error) {
+	f.writes++
+	return 0, errors.New("write failed")
+}
+
+func TestWriteStopsOnError(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	w := &failingWriter{}
+	c := &Conn{
+		w:                    w,
+		net:                  c1,
+		direction:            serverbound,
+		State:                Handshaking,
+		compressionThreshold: 0,
+	}
+	err := c.WritePacket(&Handshake{ProtocolVersion: 47, Host: "localhost", Port: 25565, Next: 1})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if w.writes != 1 {
+		t.Fatalf("expected a single write attempt, got %d", w.writes)
+	}
+}
@@ -24,14 +24,19 @@ import (
 	"github.com/thinkofdeath/steven/protocol/mojang"
 )
 
-// BUG(Think) LoginToServer doesn't support offline mode. Call it a feature?
+var errOnlineMode = errors.New("server is in online mode")
 
 // LoginToServer sends the necessary packets to join a server. This
 // also authenticates the request with mojang for online mode connections.
 // This stops before LoginSuccess (or any other preceding packets).
+//
+// Servers in offline mode skip encryption and reply straight away
+// with SetInitialCompression or LoginSuccess, in which case the packet
+// is left to be returned by the next call to ReadPacket. Incomplete
+// profiles (without an access token) may only join offline servers.
 func (c *Conn) LoginToServer(profile mojang.Profile) (err error) {
 	err = c.WritePacket(&Handshake{
-		ProtocolVersion: SupportedProtocolVersion,
+		ProtocolVersion: VarInt(c.ProtocolVersion()),
 		Host:            c.host,
 		Port:            c.port,
 		Next:            VarInt(Login - 1),
@@ -50,10 +55,20 @@ func (c *Conn) LoginToServer(profile mojang.Profile) (err error) {
 	if packet, err = c.ReadPacket(); err != nil {
 		return
 	}
-	req, ok := packet.(*EncryptionRequest)
-	if !ok {
+	var req *EncryptionRequest
+	switch packet := packet.(type) {
+	case *EncryptionRequest:
+		req = packet
+	case *SetInitialCompression, *LoginSuccess:
+		// Offline mode
+		c.pending = packet
+		return
+	default:
 		return fmt.Errorf("unexpected packet %#v", packet)
 	}
+	if !profile.IsComplete() {
+		return errOnlineMode
+	}
 	var p interface{}
 	if p, err = x509.ParsePKIXPublicKey(req.PublicKey); err != nil {
 		return
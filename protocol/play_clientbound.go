@@ -27,6 +27,7 @@ import (
 // packet setting ID to the same as this one.
 //
 // Currently the packet id is: 0x00
+// In protocol 107 the packet id is: 0x1F
 type KeepAliveClientbound struct {
 	ID VarInt
 }
@@ -35,6 +36,7 @@ type KeepAliveClientbound struct {
 // sets the initial state for the client.
 //
 // Currently the packet id is: 0x01
+// In protocol 107 the packet id is: 0x23
 type JoinGame struct {
 	// The entity id the client will be referenced by
 	EntityID int32
@@ -58,6 +60,7 @@ type JoinGame struct {
 // message is displayed at and when the message is displayed.
 //
 // Currently the packet id is: 0x02
+// In protocol 107 the packet id is: 0x0F
 type ServerMessage struct {
 	Message chat.AnyComponent `as:"json"`
 	// 0 - Chat message, 1 - System message, 2 - Action bar message
@@ -70,6 +73,7 @@ type ServerMessage struct {
 // so it is a good idea to sent this now and again
 //
 // Currently the packet id is: 0x03
+// In protocol 107 the packet id is: 0x44
 type TimeUpdate struct {
 	WorldAge  int64
 	TimeOfDay int64
@@ -80,6 +84,7 @@ type TimeUpdate struct {
 // chestplate and helmet respectively.
 //
 // Currently the packet id is: 0x04
+// In protocol 107 the packet's layout changed, its id is: 0x3C
 type EntityEquipment struct {
 	EntityID VarInt
 	Slot     int16
@@ -90,6 +95,7 @@ type EntityEquipment struct {
 // only used by the client for the compass.
 //
 // Currently the packet id is: 0x05
+// In protocol 107 the packet id is: 0x43
 type SpawnPosition struct {
 	Location Position
 }
@@ -97,6 +103,7 @@ type SpawnPosition struct {
 // UpdateHealth is sent by the server to update the player's health and food.
 //
 // Currently the packet id is: 0x06
+// In protocol 107 the packet id is: 0x3E
 type UpdateHealth struct {
 	Health         float32
 	Food           VarInt
@@ -106,6 +113,7 @@ type UpdateHealth struct {
 // Respawn is sent to respawn the player after death or when they move worlds.
 //
 // Currently the packet id is: 0x07
+// In protocol 107 the packet id is: 0x33
 type Respawn struct {
 	Dimension  int32
 	Difficulty byte
@@ -118,6 +126,7 @@ type Respawn struct {
 // otherwise will reject future packets.
 //
 // Currently the packet id is: 0x08
+// In protocol 107 the packet's layout changed, its id is: 0x2E
 type TeleportPlayer struct {
 	X, Y, Z    float64
 	Yaw, Pitch float32
@@ -127,6 +136,7 @@ type TeleportPlayer struct {
 // SetCurrentHotbarSlot changes the player's currently selected hotbar item.
 //
 // Currently the packet id is: 0x09
+// In protocol 107 the packet id is: 0x37
 type SetCurrentHotbarSlot struct {
 	Slot byte
 }
@@ -134,6 +144,7 @@ type SetCurrentHotbarSlot struct {
 // EntityUsedBed is sent by the server when a player goes to bed.
 //
 // Currently the packet id is: 0x0A
+// In protocol 107 the packet id is: 0x2F
 type EntityUsedBed struct {
 	EntityID VarInt
 	Location Position
@@ -142,6 +153,7 @@ type EntityUsedBed struct {
 // Animation is sent by the server to play an animation on a specific entity.
 //
 // Currently the packet id is: 0x0B
+// In protocol 107 the packet id is: 0x06
 type Animation struct {
 	EntityID    VarInt
 	AnimationID byte
@@ -152,6 +164,7 @@ type Animation struct {
 // information is in the player information packet.
 //
 // Currently the packet id is: 0x0C
+// In protocol 107 the packet's layout changed, its id is: 0x05
 type SpawnPlayer struct {
 	EntityID    VarInt
 	UUID        UUID `as:"raw"`
@@ -165,6 +178,7 @@ type SpawnPlayer struct {
 // does not destroy the entity.
 //
 // Currently the packet id is: 0x0D
+// In protocol 107 the packet id is: 0x49
 type CollectItem struct {
 	CollectedEntityID VarInt
 	CollectorEntityID VarInt
@@ -175,6 +189,7 @@ type CollectItem struct {
 // non-zero.
 //
 // Currently the packet id is: 0x0E
+// In protocol 107 the packet's layout changed, its id is: 0x00
 type SpawnObject struct {
 	EntityID                        VarInt
 	Type                            byte
@@ -188,6 +203,7 @@ type SpawnObject struct {
 // range of the client.
 //
 // Currently the packet id is: 0x0F
+// In protocol 107 the packet's layout changed, its id is: 0x03
 type SpawnMob struct {
 	EntityID                        VarInt
 	Type                            byte
@@ -202,6 +218,7 @@ type SpawnMob struct {
 // the client. The title effects the size and the texture of the painting.
 //
 // Currently the packet id is: 0x10
+// In protocol 107 the packet's layout changed, its id is: 0x04
 type SpawnPainting struct {
 	EntityID  VarInt
 	Title     string
@@ -214,6 +231,7 @@ type SpawnPainting struct {
 // gained when collected.
 //
 // Currently the packet id is: 0x11
+// In protocol 107 the packet's layout changed, its id is: 0x01
 type SpawnExperienceOrb struct {
 	EntityID VarInt
 	X, Y, Z  int32
@@ -224,6 +242,7 @@ type SpawnExperienceOrb struct {
 // per a tick.
 //
 // Currently the packet id is: 0x12
+// In protocol 107 the packet id is: 0x3B
 type EntityVelocity struct {
 	EntityID                        VarInt
 	VelocityX, VelocityY, VelocityZ int16
@@ -232,6 +251,7 @@ type EntityVelocity struct {
 // EntityDestroy destroys the entities with the ids in the provided slice.
 //
 // Currently the packet id is: 0x13
+// In protocol 107 the packet id is: 0x30
 type EntityDestroy struct {
 	EntityIDs []VarInt `length:"VarInt"`
 }
@@ -239,6 +259,7 @@ type EntityDestroy struct {
 // Entity does nothing. It is a result of subclassing used in Minecraft.
 //
 // Currently the packet id is: 0x14
+// In protocol 107 the packet id is: 0x28
 type Entity struct {
 	EntityID VarInt
 }
@@ -246,6 +267,7 @@ type Entity struct {
 // EntityMove moves the entity with the id by the offsets provided.
 //
 // Currently the packet id is: 0x15
+// In protocol 107 the packet's layout changed, its id is: 0x25
 type EntityMove struct {
 	EntityID               VarInt
 	DeltaX, DeltaY, DeltaZ int8
@@ -255,6 +277,7 @@ type EntityMove struct {
 // EntityLook rotates the entity to the new angles provided.
 //
 // Currently the packet id is: 0x16
+// In protocol 107 the packet id is: 0x27
 type EntityLook struct {
 	EntityID   VarInt
 	Yaw, Pitch int8
@@ -264,6 +287,7 @@ type EntityLook struct {
 // EntityLookAndMove is a combination of EntityMove and EntityLook.
 //
 // Currently the packet id is: 0x17
+// In protocol 107 the packet's layout changed, its id is: 0x26
 type EntityLookAndMove struct {
 	EntityID               VarInt
 	DeltaX, DeltaY, DeltaZ int8
@@ -275,6 +299,7 @@ type EntityLookAndMove struct {
 // sent if the entity moves further than EntityMove allows.
 //
 // Currently the packet id is: 0x18
+// In protocol 107 the packet's layout changed, its id is: 0x4A
 type EntityTeleport struct {
 	EntityID   VarInt
 	X, Y, Z    int32
@@ -285,6 +310,7 @@ type EntityTeleport struct {
 // EntityHeadLook rotates an entity's head to the new angle.
 //
 // Currently the packet id is: 0x19
+// In protocol 107 the packet id is: 0x34
 type EntityHeadLook struct {
 	EntityID VarInt
 	HeadYaw  int8
@@ -294,6 +320,7 @@ type EntityHeadLook struct {
 // id.
 //
 // Currently the packet id is: 0x1A
+// In protocol 107 the packet id is: 0x1B
 type EntityAction struct {
 	EntityID int32
 	ActionID byte
@@ -303,6 +330,7 @@ type EntityAction struct {
 // -1 can be used at the EntityID to deattach.
 //
 // Currently the packet id is: 0x1B
+// In protocol 107 the packet's layout changed, its id is: 0x3A
 type EntityAttach struct {
 	EntityID int32
 	Vehicle  int32
@@ -312,6 +340,7 @@ type EntityAttach struct {
 // EntityMetadata updates the metadata for an entity.
 //
 // Currently the packet id is: 0x1C
+// In protocol 107 the packet's layout changed, its id is: 0x39
 type EntityMetadata struct {
 	EntityID VarInt
 	Metadata Metadata
@@ -320,6 +349,7 @@ type EntityMetadata struct {
 // EntityEffect applies a status effect to an entity for a given duration.
 //
 // Currently the packet id is: 0x1D
+// In protocol 107 the packet id is: 0x4C
 type EntityEffect struct {
 	EntityID      VarInt
 	EffectID      int8
@@ -331,6 +361,7 @@ type EntityEffect struct {
 // EntityRemoveEffect removes an effect from an entity.
 //
 // Currently the packet id is: 0x1E
+// In protocol 107 the packet id is: 0x31
 type EntityRemoveEffect struct {
 	EntityID VarInt
 	EffectID int8
@@ -339,6 +370,7 @@ type EntityRemoveEffect struct {
 // SetExperience updates the experience bar on the client.
 //
 // Currently the packet id is: 0x1F
+// In protocol 107 the packet id is: 0x3D
 type SetExperience struct {
 	ExperienceBar   float32
 	Level           VarInt
@@ -348,6 +380,7 @@ type SetExperience struct {
 // EntityProperties updates the properties for an entity.
 //
 // Currently the packet id is: 0x20
+// In protocol 107 the packet id is: 0x4B
 type EntityProperties struct {
 	EntityID   VarInt
 	Properties []EntityProperty `length:"int32"`
@@ -373,6 +406,7 @@ type PropertyModifier struct {
 // then biome data should be sent too.
 //
 // Currently the packet id is: 0x21
+// In protocol 107 the packet's layout changed, its id is: 0x20
 type ChunkData struct {
 	ChunkX, ChunkZ int32
 	New            bool
@@ -383,6 +417,7 @@ type ChunkData struct {
 // MultiBlockChange is used to update a batch of blocks in a single packet.
 //
 // Currently the packet id is: 0x22
+// In protocol 107 the packet id is: 0x10
 type MultiBlockChange struct {
 	ChunkX, ChunkZ int32
 	Records        []BlockChangeRecord `length:"VarInt"`
@@ -398,6 +433,7 @@ type BlockChangeRecord struct {
 // BlockChange is used to update a single block on the client.
 //
 // Currently the packet id is: 0x23
+// In protocol 107 the packet id is: 0x0B
 type BlockChange struct {
 	Location Position
 	BlockID  VarInt
@@ -406,6 +442,7 @@ type BlockChange struct {
 // BlockAction triggers different actions depending on the target block.
 //
 // Currently the packet id is: 0x24
+// In protocol 107 the packet id is: 0x0A
 type BlockAction struct {
 	Location  Position
 	Byte1     byte
@@ -417,6 +454,7 @@ type BlockAction struct {
 // animation played when a player starts digging a block.
 //
 // Currently the packet id is: 0x25
+// In protocol 107 the packet id is: 0x08
 type BlockBreakAnimation struct {
 	EntityID VarInt
 	Location Position
@@ -427,6 +465,7 @@ type BlockBreakAnimation struct {
 // at once.
 //
 // Currently the packet id is: 0x26
+// In protocol 107 the packet was removed
 type ChunkDataBulk struct {
 	SkyLight bool
 	Meta     []ChunkMeta `length:"VarInt"`
@@ -443,6 +482,7 @@ type ChunkMeta struct {
 // This plays the effect and removes the effected blocks.
 //
 // Currently the packet id is: 0x27
+// In protocol 107 the packet id is: 0x1C
 type Explosion struct {
 	X, Y, Z                         float32
 	Radius                          float32
@@ -460,6 +500,7 @@ type ExplosionRecord struct {
 // DisableRelative is set to true.
 //
 // Currently the packet id is: 0x28
+// In protocol 107 the packet id is: 0x21
 type Effect struct {
 	EffectID        int32
 	Location        Position
@@ -470,6 +511,7 @@ type Effect struct {
 // SoundEffect plays the named sound at the target location.
 //
 // Currently the packet id is: 0x29
+// In protocol 107 the packet's layout changed, its id is: 0x19
 type SoundEffect struct {
 	Name    string
 	X, Y, Z int32
@@ -481,6 +523,7 @@ type SoundEffect struct {
 // modifiers. Data's length depends on the particle ID.
 //
 // Currently the packet id is: 0x2A
+// In protocol 107 the packet id is: 0x22
 type Particle struct {
 	ParticleID                int32
 	LongDistance              bool
@@ -505,6 +548,7 @@ func particleDataLength(p *Particle) int {
 // weather.
 //
 // Currently the packet id is: 0x2B
+// In protocol 107 the packet id is: 0x1E
 type ChangeGameState struct {
 	Reason byte
 	Value  float32
@@ -514,6 +558,7 @@ type ChangeGameState struct {
 // world. Currently only used for lightning.
 //
 // Currently the packet id is: 0x2C
+// In protocol 107 the packet's layout changed, its id is: 0x02
 type SpawnGlobalEntity struct {
 	EntityID VarInt
 	Type     byte
@@ -525,6 +570,7 @@ type SpawnGlobalEntity struct {
 // other packets.
 //
 // Currently the packet id is: 0x2D
+// In protocol 107 the packet id is: 0x13
 type WindowOpen struct {
 	ID        byte
 	Type      string
@@ -537,6 +583,7 @@ type WindowOpen struct {
 // e.g. a chest getting destroyed.
 //
 // Currently the packet id is: 0x2E
+// In protocol 107 the packet id is: 0x12
 type WindowClose struct {
 	ID byte
 }
@@ -544,6 +591,7 @@ type WindowClose struct {
 // WindowSetSlot changes an itemstack in one of the slots in a window.
 //
 // Currently the packet id is: 0x2F
+// In protocol 107 the packet id is: 0x16
 type WindowSetSlot struct {
 	ID        byte
 	Slot      int16
@@ -553,6 +601,7 @@ type WindowSetSlot struct {
 // WindowItems sets every item in a window.
 //
 // Currently the packet id is: 0x30
+// In protocol 107 the packet id is: 0x14
 type WindowItems struct {
 	ID    byte
 	Items []ItemStack `length:"int16" as:"raw"`
@@ -562,6 +611,7 @@ type WindowItems struct {
 // vary depending on the window type.
 //
 // Currently the packet id is: 0x31
+// In protocol 107 the packet id is: 0x15
 type WindowProperty struct {
 	ID       byte
 	Property int16
@@ -572,6 +622,7 @@ type WindowProperty struct {
 // or failed (e.g. due to lag).
 //
 // Currently the packet id is: 0x32
+// In protocol 107 the packet id is: 0x11
 type ConfirmTransaction struct {
 	ID           byte
 	ActionNumber int16
@@ -581,6 +632,7 @@ type ConfirmTransaction struct {
 // UpdateSign sets or changes the text on a sign.
 //
 // Currently the packet id is: 0x33
+// In protocol 107 the packet id is: 0x46
 type UpdateSign struct {
 	Location Position
 	Line1    chat.AnyComponent `as:"json"`
@@ -592,6 +644,7 @@ type UpdateSign struct {
 // Maps updates a single map's contents
 //
 // Currently the packet id is: 0x34
+// In protocol 107 the packet's layout changed, its id is: 0x24
 type Maps struct {
 	ItemDamage VarInt
 	Scale      int8
@@ -613,6 +666,7 @@ type MapIcon struct {
 // world.
 //
 // Currently the packet id is: 0x35
+// In protocol 107 the packet id is: 0x09
 type UpdateBlockEntity struct {
 	Location Position
 	Action   byte
@@ -623,6 +677,7 @@ type UpdateBlockEntity struct {
 // it can write to it. Only sent in vanilla when the player places a sign.
 //
 // Currently the packet id is: 0x36
+// In protocol 107 the packet id is: 0x2A
 type SignEditorOpen struct {
 	Location Position
 }
@@ -630,6 +685,7 @@ type SignEditorOpen struct {
 // Statistics is used to update the statistics screen for the client.
 //
 // Currently the packet id is: 0x37
+// In protocol 107 the packet id is: 0x07
 type Statistics struct {
 	Statistics []Statistic `length:"VarInt"`
 }
@@ -644,6 +700,7 @@ type Statistic struct {
 // to provide skin and username information as well as ping and gamemode info.
 //
 // Currently the packet id is: 0x38
+// In protocol 107 the packet id is: 0x2D
 type PlayerInfo struct {
 	Action  VarInt
 	Players []PlayerDetail `length:"VarInt"`
@@ -672,6 +729,7 @@ type PlayerProperty struct {
 // creative, god mode etc.
 //
 // Currently the packet id is: 0x39
+// In protocol 107 the packet id is: 0x2B
 type PlayerAbilities struct {
 	Flags        byte
 	FlyingSpeed  float32
@@ -683,6 +741,7 @@ type PlayerAbilities struct {
 // player sent.
 //
 // Currently the packet id is: 0x3A
+// In protocol 107 the packet id is: 0x0E
 type TabCompleteReply struct {
 	Matches []string `length:"VarInt"`
 }
@@ -690,6 +749,7 @@ type TabCompleteReply struct {
 // ScoreboardObjective creates/updates a scoreboard objective.
 //
 // Currently the packet id is: 0x3B
+// In protocol 107 the packet id is: 0x3F
 type ScoreboardObjective struct {
 	Name  string
 	Mode  byte
@@ -701,6 +761,7 @@ type ScoreboardObjective struct {
 // objective.
 //
 // Currently the packet id is: 0x3C
+// In protocol 107 the packet id is: 0x42
 type UpdateScore struct {
 	Name       string
 	Action     byte
@@ -711,6 +772,7 @@ type UpdateScore struct {
 // ScoreboardDisplay is used to set the display position of a scoreboard.
 //
 // Currently the packet id is: 0x3D
+// In protocol 107 the packet id is: 0x38
 type ScoreboardDisplay struct {
 	Position byte
 	Name     string
@@ -719,6 +781,7 @@ type ScoreboardDisplay struct {
 // Teams creates and updates teams
 //
 // Currently the packet id is: 0x3E
+// In protocol 107 the packet's layout changed, its id is: 0x41
 type Teams struct {
 	Name              string
 	Mode              byte
@@ -736,6 +799,7 @@ type Teams struct {
 // registered too.
 //
 // Currently the packet id is: 0x3F
+// In protocol 107 the packet id is: 0x18
 type PluginMessageClientbound struct {
 	Channel string
 	Data    []byte `length:"remaining"`
@@ -744,6 +808,7 @@ type PluginMessageClientbound struct {
 // Disconnect causes the client to disconnect displaying the passed reason.
 //
 // Currently the packet id is: 0x40
+// In protocol 107 the packet id is: 0x1A
 type Disconnect struct {
 	Reason chat.AnyComponent `as:"json"`
 }
@@ -752,6 +817,7 @@ type Disconnect struct {
 // as well as some ui changes for hardcore.
 //
 // Currently the packet id is: 0x41
+// In protocol 107 the packet id is: 0x0D
 type ServerDifficulty struct {
 	Difficulty byte
 }
@@ -760,6 +826,7 @@ type ServerDifficulty struct {
 // clue.
 //
 // Currently the packet id is: 0x42
+// In protocol 107 the packet id is: 0x2C
 type CombatEvent struct {
 	Event    VarInt
 	Duration VarInt `if:".Event == 1"`
@@ -772,6 +839,7 @@ type CombatEvent struct {
 // Use the player's id to de-spectate.
 //
 // Currently the packet id is: 0x43
+// In protocol 107 the packet id is: 0x36
 type Camera struct {
 	TargetID VarInt
 }
@@ -779,6 +847,7 @@ type Camera struct {
 // WorldBorder configures the world's border.
 //
 // Currently the packet id is: 0x44
+// In protocol 107 the packet id is: 0x35
 type WorldBorder struct {
 	Action         VarInt
 	OldRadius      float64 `if:".Action == 3 .Action == 1"`
@@ -793,6 +862,7 @@ type WorldBorder struct {
 // Title configures an on-screen title.
 //
 // Currently the packet id is: 0x45
+// In protocol 107 the packet id is: 0x45
 type Title struct {
 	Action   VarInt
 	Title    chat.AnyComponent `as:"json" if:".Action == 0"`
@@ -805,6 +875,7 @@ type Title struct {
 // SetCompression updates the compression threshold.
 //
 // Currently the packet id is: 0x46
+// In protocol 107 the packet was removed
 type SetCompression struct {
 	Threshold VarInt
 }
@@ -812,6 +883,7 @@ type SetCompression struct {
 // PlayerListHeaderFooter updates the header/footer of the player list.
 //
 // Currently the packet id is: 0x47
+// In protocol 107 the packet id is: 0x48
 type PlayerListHeaderFooter struct {
 	Header chat.AnyComponent `as:"json"`
 	Footer chat.AnyComponent `as:"json"`
@@ -822,6 +894,7 @@ type PlayerListHeaderFooter struct {
 // is obtained the client will use it.
 //
 // Currently the packet id is: 0x48
+// In protocol 107 the packet id is: 0x32
 type ResourcePackSend struct {
 	URL  string
 	Hash string
@@ -830,6 +903,7 @@ type ResourcePackSend struct {
 // UpdateEntityNBT updates the nbt tag for an entity.
 //
 // Currently the packet id is: 0x49
+// In protocol 107 the packet was removed
 type UpdateEntityNBT struct {
 	EntityID VarInt
 	Tag      *nbt.Compound
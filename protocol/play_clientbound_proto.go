@@ -3888,4 +3888,78 @@ func init() {
 	packetCreator[Play][clientbound][71] = func() Packet { return &PlayerListHeaderFooter{} }
 	packetCreator[Play][clientbound][72] = func() Packet { return &ResourcePackSend{} }
 	packetCreator[Play][clientbound][73] = func() Packet { return &UpdateEntityNBT{} }
+	registerPacketID(107, Play, clientbound, 31, 0)
+	registerPacketID(107, Play, clientbound, 35, 1)
+	registerPacketID(107, Play, clientbound, 15, 2)
+	registerPacketID(107, Play, clientbound, 68, 3)
+	registerUnsupportedPacket(107, Play, clientbound, 4)
+	registerPacketID(107, Play, clientbound, 67, 5)
+	registerPacketID(107, Play, clientbound, 62, 6)
+	registerPacketID(107, Play, clientbound, 51, 7)
+	registerUnsupportedPacket(107, Play, clientbound, 8)
+	registerPacketID(107, Play, clientbound, 55, 9)
+	registerPacketID(107, Play, clientbound, 47, 10)
+	registerPacketID(107, Play, clientbound, 6, 11)
+	registerUnsupportedPacket(107, Play, clientbound, 12)
+	registerPacketID(107, Play, clientbound, 73, 13)
+	registerUnsupportedPacket(107, Play, clientbound, 14)
+	registerUnsupportedPacket(107, Play, clientbound, 15)
+	registerUnsupportedPacket(107, Play, clientbound, 16)
+	registerUnsupportedPacket(107, Play, clientbound, 17)
+	registerPacketID(107, Play, clientbound, 59, 18)
+	registerPacketID(107, Play, clientbound, 48, 19)
+	registerPacketID(107, Play, clientbound, 40, 20)
+	registerUnsupportedPacket(107, Play, clientbound, 21)
+	registerPacketID(107, Play, clientbound, 39, 22)
+	registerUnsupportedPacket(107, Play, clientbound, 23)
+	registerUnsupportedPacket(107, Play, clientbound, 24)
+	registerPacketID(107, Play, clientbound, 52, 25)
+	registerPacketID(107, Play, clientbound, 27, 26)
+	registerUnsupportedPacket(107, Play, clientbound, 27)
+	registerUnsupportedPacket(107, Play, clientbound, 28)
+	registerPacketID(107, Play, clientbound, 76, 29)
+	registerPacketID(107, Play, clientbound, 49, 30)
+	registerPacketID(107, Play, clientbound, 61, 31)
+	registerPacketID(107, Play, clientbound, 75, 32)
+	registerUnsupportedPacket(107, Play, clientbound, 33)
+	registerPacketID(107, Play, clientbound, 16, 34)
+	registerPacketID(107, Play, clientbound, 11, 35)
+	registerPacketID(107, Play, clientbound, 10, 36)
+	registerPacketID(107, Play, clientbound, 8, 37)
+	registerUnsupportedPacket(107, Play, clientbound, 38)
+	registerPacketID(107, Play, clientbound, 28, 39)
+	registerPacketID(107, Play, clientbound, 33, 40)
+	registerUnsupportedPacket(107, Play, clientbound, 41)
+	registerPacketID(107, Play, clientbound, 34, 42)
+	registerPacketID(107, Play, clientbound, 30, 43)
+	registerUnsupportedPacket(107, Play, clientbound, 44)
+	registerPacketID(107, Play, clientbound, 19, 45)
+	registerPacketID(107, Play, clientbound, 18, 46)
+	registerPacketID(107, Play, clientbound, 22, 47)
+	registerPacketID(107, Play, clientbound, 20, 48)
+	registerPacketID(107, Play, clientbound, 21, 49)
+	registerPacketID(107, Play, clientbound, 17, 50)
+	registerPacketID(107, Play, clientbound, 70, 51)
+	registerUnsupportedPacket(107, Play, clientbound, 52)
+	registerPacketID(107, Play, clientbound, 9, 53)
+	registerPacketID(107, Play, clientbound, 42, 54)
+	registerPacketID(107, Play, clientbound, 7, 55)
+	registerPacketID(107, Play, clientbound, 45, 56)
+	registerPacketID(107, Play, clientbound, 43, 57)
+	registerPacketID(107, Play, clientbound, 14, 58)
+	registerPacketID(107, Play, clientbound, 63, 59)
+	registerPacketID(107, Play, clientbound, 66, 60)
+	registerPacketID(107, Play, clientbound, 56, 61)
+	registerUnsupportedPacket(107, Play, clientbound, 62)
+	registerPacketID(107, Play, clientbound, 24, 63)
+	registerPacketID(107, Play, clientbound, 26, 64)
+	registerPacketID(107, Play, clientbound, 13, 65)
+	registerPacketID(107, Play, clientbound, 44, 66)
+	registerPacketID(107, Play, clientbound, 54, 67)
+	registerPacketID(107, Play, clientbound, 53, 68)
+	registerPacketID(107, Play, clientbound, 69, 69)
+	registerUnsupportedPacket(107, Play, clientbound, 70)
+	registerPacketID(107, Play, clientbound, 72, 71)
+	registerPacketID(107, Play, clientbound, 50, 72)
+	registerUnsupportedPacket(107, Play, clientbound, 73)
 }
@@ -25,6 +25,7 @@ import (
 // may disconnect the client.
 //
 // Currently the packet id is: 0x00
+// In protocol 107 the packet id is: 0x0B
 type KeepAliveServerbound struct {
 	ID VarInt
 }
@@ -33,6 +34,7 @@ type KeepAliveServerbound struct {
 // executes a command (prefixed by '/').
 //
 // Currently the packet id is: 0x01
+// In protocol 107 the packet id is: 0x02
 type ChatMessage struct {
 	Message string
 }
@@ -41,6 +43,7 @@ type ChatMessage struct {
 // (left clicks) an entity.
 //
 // Currently the packet id is: 0x02
+// In protocol 107 the packet's layout changed, its id is: 0x0A
 type UseEntity struct {
 	TargetID VarInt
 	Type     VarInt
@@ -52,6 +55,7 @@ type UseEntity struct {
 // Player is used to update whether the player is on the ground or not.
 //
 // Currently the packet id is: 0x03
+// In protocol 107 the packet id is: 0x0F
 type Player struct {
 	OnGround bool
 }
@@ -59,6 +63,7 @@ type Player struct {
 // PlayerPosition is used to update the player's position.
 //
 // Currently the packet id is: 0x04
+// In protocol 107 the packet id is: 0x0C
 type PlayerPosition struct {
 	X, Y, Z  float64
 	OnGround bool
@@ -67,6 +72,7 @@ type PlayerPosition struct {
 // PlayerLook is used to update the player's rotation.
 //
 // Currently the packet id is: 0x05
+// In protocol 107 the packet id is: 0x0E
 type PlayerLook struct {
 	Yaw, Pitch float32
 	OnGround   bool
@@ -76,6 +82,7 @@ type PlayerLook struct {
 // PlayerLook.
 //
 // Currently the packet id is: 0x06
+// In protocol 107 the packet id is: 0x0D
 type PlayerPositionLook struct {
 	X, Y, Z    float64
 	Yaw, Pitch float32
@@ -86,6 +93,7 @@ type PlayerPositionLook struct {
 // It also can be sent for droppping items and eating/shooting.
 //
 // Currently the packet id is: 0x07
+// In protocol 107 the packet's layout changed, its id is: 0x13
 type PlayerDigging struct {
 	Status   byte
 	Location Position
@@ -95,6 +103,7 @@ type PlayerDigging struct {
 // PlayerBlockPlacement is sent when the client tries to place a block.
 //
 // Currently the packet id is: 0x08
+// In protocol 107 the packet's layout changed, its id is: 0x1C
 type PlayerBlockPlacement struct {
 	Location                  Position
 	Face                      byte
@@ -106,6 +115,7 @@ type PlayerBlockPlacement struct {
 // hotbar slot.
 //
 // Currently the packet id is: 0x09
+// In protocol 107 the packet id is: 0x17
 type HeldItemChange struct {
 	Slot int16
 }
@@ -114,12 +124,14 @@ type HeldItemChange struct {
 // arm).
 //
 // Currently the packet id is: 0x0A
+// In protocol 107 the packet's layout changed, its id is: 0x1A
 type ArmSwing struct {
 }
 
 // PlayerAction is sent when a player preforms various actions.
 //
 // Currently the packet id is: 0x0B
+// In protocol 107 the packet id is: 0x14
 type PlayerAction struct {
 	EntityID  VarInt
 	ActionID  VarInt
@@ -130,6 +142,7 @@ type PlayerAction struct {
 // on a vehicle.
 //
 // Currently the packet id is: 0x0C
+// In protocol 107 the packet id is: 0x15
 type SteerVehicle struct {
 	Sideways float32
 	Forward  float32
@@ -139,6 +152,7 @@ type SteerVehicle struct {
 // CloseWindow is sent when the client closes a window.
 //
 // Currently the packet id is: 0x0D
+// In protocol 107 the packet id is: 0x08
 type CloseWindow struct {
 	ID byte
 }
@@ -146,6 +160,7 @@ type CloseWindow struct {
 // ClickWindow is sent when the client clicks in a window.
 //
 // Currently the packet id is: 0x0E
+// In protocol 107 the packet's layout changed, its id is: 0x07
 type ClickWindow struct {
 	ID           byte
 	Slot         int16
@@ -158,6 +173,7 @@ type ClickWindow struct {
 // ConfirmTransactionServerbound is a reply to ConfirmTransaction.
 //
 // Currently the packet id is: 0x0F
+// In protocol 107 the packet id is: 0x05
 type ConfirmTransactionServerbound struct {
 	ID           byte
 	ActionNumber int16
@@ -168,6 +184,7 @@ type ConfirmTransactionServerbound struct {
 // inventory. This is used to spawn items in creative.
 //
 // Currently the packet id is: 0x10
+// In protocol 107 the packet id is: 0x18
 type CreativeInventoryAction struct {
 	Slot        int16
 	ClickedItem ItemStack `as:"raw"`
@@ -176,6 +193,7 @@ type CreativeInventoryAction struct {
 // EnchantItem is sent when the client enchants an item.
 //
 // Currently the packet id is: 0x11
+// In protocol 107 the packet id is: 0x06
 type EnchantItem struct {
 	ID          byte
 	Enchantment byte
@@ -184,6 +202,7 @@ type EnchantItem struct {
 // SetSign sets the text on a sign after placing it.
 //
 // Currently the packet id is: 0x12
+// In protocol 107 the packet's layout changed, its id is: 0x19
 type SetSign struct {
 	Location Position
 	Line1    chat.AnyComponent `as:"json"`
@@ -196,6 +215,7 @@ type SetSign struct {
 // Currently flying is the only one
 //
 // Currently the packet id is: 0x13
+// In protocol 107 the packet id is: 0x12
 type ClientAbilities struct {
 	Flags        byte
 	FlyingSpeed  float32
@@ -206,6 +226,7 @@ type ClientAbilities struct {
 // the chat box.
 //
 // Currently the packet id is: 0x14
+// In protocol 107 the packet's layout changed, its id is: 0x01
 type TabComplete struct {
 	Text      string
 	HasTarget bool
@@ -215,6 +236,7 @@ type TabComplete struct {
 // ClientSettings is sent by the client to update its current settings.
 //
 // Currently the packet id is: 0x15
+// In protocol 107 the packet's layout changed, its id is: 0x04
 type ClientSettings struct {
 	Locale             string
 	ViewDistance       byte
@@ -226,6 +248,7 @@ type ClientSettings struct {
 // ClientStatus is sent to update the client's status
 //
 // Currently the packet id is: 0x16
+// In protocol 107 the packet id is: 0x03
 type ClientStatus struct {
 	ActionID VarInt
 }
@@ -235,6 +258,7 @@ type ClientStatus struct {
 // registered too.
 //
 // Currently the packet id is: 0x17
+// In protocol 107 the packet id is: 0x09
 type PluginMessageServerbound struct {
 	Channel string
 	Data    []byte `length:"remaining"`
@@ -243,6 +267,7 @@ type PluginMessageServerbound struct {
 // SpectateTeleport is sent by clients in spectator mode to teleport to a player.
 //
 // Currently the packet id is: 0x18
+// In protocol 107 the packet id is: 0x1B
 type SpectateTeleport struct {
 	Target UUID `as:"raw"`
 }
@@ -987,4 +987,29 @@ func init() {
 	packetCreator[Play][serverbound][22] = func() Packet { return &ClientStatus{} }
 	packetCreator[Play][serverbound][23] = func() Packet { return &PluginMessageServerbound{} }
 	packetCreator[Play][serverbound][24] = func() Packet { return &SpectateTeleport{} }
+	registerPacketID(107, Play, serverbound, 11, 0)
+	registerPacketID(107, Play, serverbound, 2, 1)
+	registerUnsupportedPacket(107, Play, serverbound, 2)
+	registerPacketID(107, Play, serverbound, 15, 3)
+	registerPacketID(107, Play, serverbound, 12, 4)
+	registerPacketID(107, Play, serverbound, 14, 5)
+	registerPacketID(107, Play, serverbound, 13, 6)
+	registerUnsupportedPacket(107, Play, serverbound, 7)
+	registerUnsupportedPacket(107, Play, serverbound, 8)
+	registerPacketID(107, Play, serverbound, 23, 9)
+	registerUnsupportedPacket(107, Play, serverbound, 10)
+	registerPacketID(107, Play, serverbound, 20, 11)
+	registerPacketID(107, Play, serverbound, 21, 12)
+	registerPacketID(107, Play, serverbound, 8, 13)
+	registerUnsupportedPacket(107, Play, serverbound, 14)
+	registerPacketID(107, Play, serverbound, 5, 15)
+	registerPacketID(107, Play, serverbound, 24, 16)
+	registerPacketID(107, Play, serverbound, 6, 17)
+	registerUnsupportedPacket(107, Play, serverbound, 18)
+	registerPacketID(107, Play, serverbound, 18, 19)
+	registerUnsupportedPacket(107, Play, serverbound, 20)
+	registerUnsupportedPacket(107, Play, serverbound, 21)
+	registerPacketID(107, Play, serverbound, 3, 22)
+	registerPacketID(107, Play, serverbound, 9, 23)
+	registerPacketID(107, Play, serverbound, 27, 24)
 }
@@ -0,0 +1,227 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package proxy provides a proxy that sits between a Minecraft client
+// and server, decoding every packet that passes through it. Hooks can
+// be used to inspect, rewrite or drop packets.
+//
+// The proxy can't decrypt connections to online mode servers as it
+// doesn't have the client's session, the target server must be in
+// offline mode.
+package proxy
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/thinkofdeath/steven/chat"
+	"github.com/thinkofdeath/steven/protocol"
+	"github.com/thinkofdeath/steven/protocol/mojang"
+)
+
+// Hook is called for every packet passing through the proxy in
+// either direction. The returned packet is forwarded in place of
+// the original, returning nil drops the packet.
+type Hook func(s *Session, serverbound bool, packet protocol.Packet) protocol.Packet
+
+// Proxy forwards clients connecting to it to a server.
+type Proxy struct {
+	// The address of the server clients are forwarded to
+	Server string
+	// Compression threshold used for connections to clients, -1
+	// disables compression
+	CompressionThreshold int
+
+	hooksLock sync.RWMutex
+	hooks     []Hook
+}
+
+// New creates a proxy that forwards clients to the server at the
+// passed address.
+func New(server string) *Proxy {
+	return &Proxy{
+		Server:               server,
+		CompressionThreshold: 256,
+	}
+}
+
+// AddHook adds a hook to the proxy. Hooks are called in the order they
+// were added with each hook being passed the packet returned by the
+// previous one.
+func (p *Proxy) AddHook(h Hook) {
+	p.hooksLock.Lock()
+	defer p.hooksLock.Unlock()
+	p.hooks = append(p.hooks, h)
+}
+
+func (p *Proxy) runHooks(s *Session, serverbound bool, packet protocol.Packet) protocol.Packet {
+	p.hooksLock.RLock()
+	defer p.hooksLock.RUnlock()
+	for _, h := range p.hooks {
+		if packet = h(s, serverbound, packet); packet == nil {
+			return nil
+		}
+	}
+	return packet
+}
+
+// ListenAndServe listens on the passed address and forwards every
+// client that connects.
+func (p *Proxy) ListenAndServe(address string) error {
+	l, err := protocol.Listen(address)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			if err := p.Serve(c); err != nil {
+				log.Printf("proxy: %s\n", err)
+			}
+		}()
+	}
+}
+
+// Session is a single client connected through the proxy.
+type Session struct {
+	// The client's username, empty for status requests
+	Username string
+
+	Client *protocol.Conn
+	Server *protocol.Conn
+
+	writeLock [2]sync.Mutex
+}
+
+// WriteToClient sends the packet to the client. Hooks aren't called
+// for the packet.
+func (s *Session) WriteToClient(packet protocol.Packet) error {
+	s.writeLock[0].Lock()
+	defer s.writeLock[0].Unlock()
+	return s.Client.WritePacket(packet)
+}
+
+// WriteToServer sends the packet to the server. Hooks aren't called
+// for the packet.
+func (s *Session) WriteToServer(packet protocol.Packet) error {
+	s.writeLock[1].Lock()
+	defer s.writeLock[1].Unlock()
+	return s.Server.WritePacket(packet)
+}
+
+// Serve forwards the client connection to the server until either
+// side disconnects. The connection must be in the Handshaking state.
+// Clients using a protocol version the proxy can't fully decode are
+// disconnected.
+func (p *Proxy) Serve(c *protocol.Conn) error {
+	defer c.Close()
+	h, err := c.AcceptHandshake()
+	if err != nil {
+		if h != nil && c.State == protocol.Login {
+			c.WritePacket(&protocol.LoginDisconnect{
+				Reason: chat.AnyComponent{Value: &chat.TextComponent{
+					Text: fmt.Sprintf("The proxy doesn't support protocol %d", h.ProtocolVersion),
+				}},
+			})
+		}
+		return err
+	}
+
+	server, err := protocol.Dial(p.Server)
+	if err != nil {
+		return err
+	}
+	defer server.Close()
+	if err := server.SetProtocolVersion(c.ProtocolVersion()); err != nil {
+		return err
+	}
+	s := &Session{Client: c, Server: server}
+
+	if c.State == protocol.Status {
+		reply, _, err := server.RequestStatus()
+		if err != nil {
+			return err
+		}
+		return c.RespondStatus(reply)
+	}
+
+	if s.Username, _, err = c.AcceptLogin(nil, ""); err != nil {
+		return err
+	}
+	log.Printf("proxy: %s logging in (protocol %d)\n", s.Username, h.ProtocolVersion)
+	if err := server.LoginToServer(mojang.Profile{Username: s.Username}); err != nil {
+		return err
+	}
+
+preLogin:
+	for {
+		packet, err := server.ReadPacket()
+		if err != nil {
+			return err
+		}
+		switch packet := packet.(type) {
+		case *protocol.SetInitialCompression:
+			server.SetCompression(int(packet.Threshold))
+		case *protocol.LoginSuccess:
+			server.State = protocol.Play
+			if err := c.CompleteLogin(packet.UUID, packet.Username, p.CompressionThreshold); err != nil {
+				return err
+			}
+			break preLogin
+		case *protocol.LoginDisconnect:
+			c.WritePacket(packet)
+			return fmt.Errorf("%s disconnected: %s", s.Username, packet.Reason)
+		default:
+			return fmt.Errorf("unhandled packet %T", packet)
+		}
+	}
+
+	errs := make(chan error, 2)
+	go func() { errs <- p.forward(s, true) }()
+	go func() { errs <- p.forward(s, false) }()
+	err = <-errs
+	// Unblock the other direction
+	c.Close()
+	server.Close()
+	return err
+}
+
+func (p *Proxy) forward(s *Session, serverbound bool) error {
+	from, write := s.Server, s.WriteToClient
+	if serverbound {
+		from, write = s.Client, s.WriteToServer
+	}
+	for {
+		packet, err := from.ReadPacket()
+		if err != nil {
+			return err
+		}
+		// Compression is handled separately for each side
+		if sc, ok := packet.(*protocol.SetCompression); ok {
+			from.SetCompression(int(sc.Threshold))
+			continue
+		}
+		if packet = p.runHooks(s, serverbound, packet); packet == nil {
+			continue
+		}
+		if err := write(packet); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"testing"
+
+	"github.com/thinkofdeath/steven/protocol"
+	"github.com/thinkofdeath/steven/protocol/mojang"
+)
+
+func TestProxyHooks(t *testing.T) {
+	// Fake server that echos chat messages back as keep alives
+	sl, err := protocol.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sl.Close()
+	go func() {
+		c, err := sl.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		if _, err := c.AcceptHandshake(); err != nil {
+			return
+		}
+		name, _, err := c.AcceptLogin(nil, "")
+		if err != nil {
+			return
+		}
+		if err := c.CompleteLogin(protocol.OfflineUUID(name).String(), name, 64); err != nil {
+			return
+		}
+		for {
+			packet, err := c.ReadPacket()
+			if err != nil {
+				return
+			}
+			if msg, ok := packet.(*protocol.ChatMessage); ok {
+				c.WritePacket(&protocol.KeepAliveClientbound{ID: protocol.VarInt(len(msg.Message))})
+			}
+		}
+	}()
+
+	p := New(sl.Addr().String())
+	p.AddHook(func(s *Session, serverbound bool, packet protocol.Packet) protocol.Packet {
+		switch packet := packet.(type) {
+		case *protocol.ChatMessage:
+			if packet.Message == "drop" {
+				return nil
+			}
+			return &protocol.ChatMessage{Message: packet.Message + packet.Message}
+		}
+		return packet
+	})
+	pl, err := protocol.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pl.Close()
+	go func() {
+		c, err := pl.Accept()
+		if err != nil {
+			return
+		}
+		p.Serve(c)
+	}()
+
+	c, err := protocol.Dial(pl.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.LoginToServer(mojang.Profile{Username: "Steven"}); err != nil {
+		t.Fatal(err)
+	}
+preLogin:
+	for {
+		packet, err := c.ReadPacket()
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch packet := packet.(type) {
+		case *protocol.SetInitialCompression:
+			c.SetCompression(int(packet.Threshold))
+		case *protocol.LoginSuccess:
+			c.State = protocol.Play
+			break preLogin
+		default:
+			t.Fatalf("unexpected packet %#v", packet)
+		}
+	}
+
+	c.WritePacket(&protocol.ChatMessage{Message: "drop"})
+	c.WritePacket(&protocol.ChatMessage{Message: "abc"})
+	packet, err := c.ReadPacket()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ka, ok := packet.(*protocol.KeepAliveClientbound); !ok || ka.ID != 6 {
+		t.Errorf("unexpected packet %#v", packet)
+	}
+}
+
+func TestProxyRejectsUnsupportedVersion(t *testing.T) {
+	// The client is turned away before the server is needed
+	p := New("127.0.0.1:1")
+	pl, err := protocol.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pl.Close()
+	errs := make(chan error, 1)
+	go func() {
+		c, err := pl.Accept()
+		if err != nil {
+			errs <- err
+			return
+		}
+		errs <- p.Serve(c)
+	}()
+
+	c, err := protocol.Dial(pl.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	// Only part of protocol 107 is mapped
+	if err := c.WritePacket(&protocol.Handshake{
+		ProtocolVersion: 107,
+		Next:            protocol.VarInt(protocol.Login - 1),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	c.State = protocol.Login
+	packet, err := c.ReadPacket()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := packet.(*protocol.LoginDisconnect); !ok {
+		t.Errorf("unexpected packet %#v", packet)
+	}
+	if err := <-errs; err == nil {
+		t.Error("expected Serve to fail")
+	}
+}
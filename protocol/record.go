@@ -0,0 +1,221 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Recordings start with a magic value followed by the format
+// and protocol versions (as VarInts). After that every packet
+// is stored as:
+//
+//	VarLong - microseconds since the previous packet
+//	byte    - state (lower 2 bits) and direction (3rd bit)
+//	VarInt  - length of the packet
+//	[]byte  - packet id (VarInt) and data, uncompressed
+//
+// The packet ids are always the ones of SupportedProtocolVersion
+// whatever version the connection was using.
+const (
+	recordMagic   = "SREC"
+	recordVersion = 1
+)
+
+var errInvalidRecording = errors.New("invalid recording")
+
+// Recorder saves packets sent and received by connections into a
+// compact format that can later be played back with a Replay. Use
+// Conn.Record to attach a recorder to a connection.
+type Recorder struct {
+	mu   sync.Mutex
+	w    *bufio.Writer
+	last time.Time
+	buf  bytes.Buffer
+	// Packets are discarded once the recorder is closed, the
+	// connection may still be using it
+	closed bool
+}
+
+// NewRecorder creates a recorder which writes to the passed writer.
+func NewRecorder(w io.Writer) (*Recorder, error) {
+	r := &Recorder{
+		w:    bufio.NewWriter(w),
+		last: time.Now(),
+	}
+	if _, err := r.w.WriteString(recordMagic); err != nil {
+		return nil, err
+	}
+	if err := WriteVarInt(r.w, recordVersion); err != nil {
+		return nil, err
+	}
+	if err := WriteVarInt(r.w, SupportedProtocolVersion); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Recorder) record(state State, direction int, packet Packet) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+
+	now := time.Now()
+	delta := now.Sub(r.last)
+	r.last = now
+
+	r.buf.Reset()
+	if err := WriteVarInt(&r.buf, VarInt(packet.id())); err != nil {
+		return err
+	}
+	if err := packet.write(&r.buf); err != nil {
+		return err
+	}
+
+	if err := WriteVarLong(r.w, VarLong(delta/time.Microsecond)); err != nil {
+		return err
+	}
+	if err := r.w.WriteByte(byte(state) | byte(direction<<2)); err != nil {
+		return err
+	}
+	if err := WriteVarInt(r.w, VarInt(r.buf.Len())); err != nil {
+		return err
+	}
+	_, err := r.buf.WriteTo(r.w)
+	return err
+}
+
+// Close flushes any buffered packets to the underlying writer,
+// packets recorded after this are discarded. The underlying writer
+// isn't closed.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	return r.w.Flush()
+}
+
+// Record makes the connection save every packet it reads and writes
+// from now on into the recorder. Passing nil stops recording.
+func (c *Conn) Record(r *Recorder) {
+	c.recorder = r
+}
+
+// RecordedPacket is a single packet from a recording.
+type RecordedPacket struct {
+	// Time since the start of the recording
+	Time time.Duration
+	// The state the connection was in when the packet was sent
+	State State
+	// Whether the packet was sent by the client instead of the
+	// server
+	Serverbound bool
+	Packet      Packet
+}
+
+// Replay reads packets from a recording created by a Recorder.
+type Replay struct {
+	r    *bufio.Reader
+	time time.Duration
+
+	// The protocol version the recording was made with
+	ProtocolVersion int
+}
+
+// NewReplay creates a replay reading the recording from the passed
+// reader.
+func NewReplay(r io.Reader) (*Replay, error) {
+	rp := &Replay{
+		r: bufio.NewReader(r),
+	}
+	var magic [len(recordMagic)]byte
+	if _, err := io.ReadFull(rp.r, magic[:]); err != nil {
+		return nil, err
+	}
+	if string(magic[:]) != recordMagic {
+		return nil, errInvalidRecording
+	}
+	version, err := ReadVarInt(rp.r)
+	if err != nil {
+		return nil, err
+	}
+	if version != recordVersion {
+		return nil, fmt.Errorf("unsupported recording version %d", version)
+	}
+	pv, err := ReadVarInt(rp.r)
+	if err != nil {
+		return nil, err
+	}
+	rp.ProtocolVersion = int(pv)
+	return rp, nil
+}
+
+// Next returns the next packet in the recording. io.EOF is returned
+// once the end of the recording is reached.
+func (rp *Replay) Next() (RecordedPacket, error) {
+	delta, err := ReadVarLong(rp.r)
+	if err != nil {
+		return RecordedPacket{}, err
+	}
+	rp.time += time.Duration(delta) * time.Microsecond
+	flags, err := rp.r.ReadByte()
+	if err != nil {
+		return RecordedPacket{}, io.ErrUnexpectedEOF
+	}
+	size, err := ReadVarInt(rp.r)
+	if err != nil {
+		return RecordedPacket{}, io.ErrUnexpectedEOF
+	}
+	if size < 0 {
+		return RecordedPacket{}, errNegativeLength
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(rp.r, buf); err != nil {
+		return RecordedPacket{}, io.ErrUnexpectedEOF
+	}
+
+	state := State(flags & 0x3)
+	direction := int(flags>>2) & 1
+	r := bytes.NewReader(buf)
+	id, err := ReadVarInt(r)
+	if err != nil {
+		return RecordedPacket{}, err
+	}
+	packets := packetCreator[state][direction]
+	if id < 0 || int(id) >= len(packets) || packets[id] == nil {
+		return RecordedPacket{}, fmt.Errorf("Unknown packet %s:%02X", state, id)
+	}
+	packet := packets[id]()
+	if err := packet.read(r); err != nil {
+		return RecordedPacket{}, fmt.Errorf("packet(%s:%02X): %s", state, id, err)
+	}
+	return RecordedPacket{
+		Time:        rp.time,
+		State:       state,
+		Serverbound: direction == serverbound,
+		Packet:      packet,
+	}, nil
+}
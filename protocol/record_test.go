@@ -0,0 +1,80 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	buf := &bytes.Buffer{}
+	rec, err := NewRecorder(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packets := []struct {
+		state     State
+		direction int
+		packet    Packet
+	}{
+		{Handshaking, serverbound, &Handshake{ProtocolVersion: SupportedProtocolVersion, Port: 25565, Next: 2}},
+		{Login, clientbound, &LoginSuccess{UUID: "b50ad385-829d-3141-a216-7e7d7539ba7f", Username: "Notch"}},
+		{Play, clientbound, &KeepAliveClientbound{ID: 5}},
+		{Play, serverbound, &KeepAliveServerbound{ID: 5}},
+	}
+	for _, p := range packets {
+		if err := rec.record(p.state, p.direction, p.packet); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// The connection may still be using the recorder
+	n := buf.Len()
+	if err := rec.record(Play, clientbound, &KeepAliveClientbound{ID: 6}); err != nil {
+		t.Fatal(err)
+	}
+	rec.Close()
+	if buf.Len() != n {
+		t.Errorf("recorded %d bytes after closing", buf.Len()-n)
+	}
+
+	rp, err := NewReplay(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rp.ProtocolVersion != SupportedProtocolVersion {
+		t.Errorf("wrong protocol version %d", rp.ProtocolVersion)
+	}
+	for _, p := range packets {
+		r, err := rp.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.State != p.state || r.Serverbound != (p.direction == serverbound) {
+			t.Errorf("wrong state/direction for %#v", r)
+		}
+		if !reflect.DeepEqual(r.Packet, p.packet) {
+			t.Errorf("got %#v, wanted %#v", r.Packet, p.packet)
+		}
+	}
+	if _, err := rp.Next(); err != io.EOF {
+		t.Errorf("expected EOF, got %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// Listener accepts connections from Minecraft clients. Connections
+// returned by the listener speak the server's side of the protocol
+// (reading serverbound packets and writing clientbound ones).
+type Listener struct {
+	net net.Listener
+}
+
+// Listen announces on the passed tcp address and returns a Listener
+// that can be used to accept clients. The address is in the same
+// format as net.Listen takes it.
+func Listen(address string) (*Listener, error) {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	return &Listener{net: l}, nil
+}
+
+// Accept waits for the next client to connect to the listener.
+// The returned connection starts in the Handshaking state.
+func (l *Listener) Accept() (*Conn, error) {
+	c, err := l.net.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{
+		r:                    c,
+		w:                    c,
+		net:                  c,
+		direction:            clientbound,
+		compressionThreshold: -1,
+	}, nil
+}
+
+// Addr returns the address the listener is listening on.
+func (l *Listener) Addr() net.Addr {
+	return l.net.Addr()
+}
+
+// Close stops the listener. Connections that have already been
+// accepted are not closed.
+func (l *Listener) Close() error {
+	return l.net.Close()
+}
+
+var (
+	errNotServer        = errors.New("connection isn't a server connection")
+	errInvalidNextState = errors.New("invalid next state in handshake")
+	errVerifyToken      = errors.New("verify token mismatch")
+)
+
+// AcceptHandshake reads the handshake sent by a client and switches
+// the connection into the state the client requested (Status or Login).
+// The connection switches to the client's protocol version if it is
+// supported, otherwise an error is returned for logins (status requests
+// are the same in every version).
+func (c *Conn) AcceptHandshake() (*Handshake, error) {
+	if c.direction != clientbound {
+		return nil, errNotServer
+	}
+	packet, err := c.ReadPacket()
+	if err != nil {
+		return nil, err
+	}
+	h, ok := packet.(*Handshake)
+	if !ok {
+		return nil, fmt.Errorf("unexpected packet %#v", packet)
+	}
+	next := State(h.Next + 1)
+	if next != Status && next != Login {
+		return nil, errInvalidNextState
+	}
+	c.host = h.Host
+	c.port = h.Port
+	c.State = next
+	if next == Login && !IsProtocolVersionSupported(int(h.ProtocolVersion)) {
+		return h, fmt.Errorf("unsupported protocol version %d", h.ProtocolVersion)
+	}
+	c.SetProtocolVersion(int(h.ProtocolVersion))
+	return h, nil
+}
+
+// RespondStatus answers a status request from a client with the
+// passed reply and then replies to the client's ping. The connection
+// must be in the Status state. The connection will be closed after
+// this request.
+func (c *Conn) RespondStatus(reply StatusReply) (err error) {
+	defer c.Close()
+
+	var packet Packet
+	if packet, err = c.ReadPacket(); err != nil {
+		return
+	}
+	if _, ok := packet.(*StatusRequest); !ok {
+		return fmt.Errorf("unexpected packet %#v", packet)
+	}
+	if err = c.WritePacket(&StatusResponse{Status: reply}); err != nil {
+		return
+	}
+
+	// Vanilla clients may disconnect without pinging
+	if packet, err = c.ReadPacket(); err != nil {
+		return
+	}
+	ping, ok := packet.(*StatusPing)
+	if !ok {
+		return fmt.Errorf("unexpected packet %#v", packet)
+	}
+	return c.WritePacket(&StatusPong{Time: ping.Time})
+}
+
+// AcceptLogin reads the LoginStart packet sent by the client and
+// returns the username the client is logging in with. If key is
+// non-nil then the client is asked to enable encryption and the
+// shared secret it replies with is returned, the connection is
+// encrypted once this returns. Authenticating the client with
+// mojang's session servers is left to the caller.
+//
+// The login must be finished with a call to CompleteLogin.
+func (c *Conn) AcceptLogin(key *rsa.PrivateKey, serverID string) (username string, sharedSecret []byte, err error) {
+	var packet Packet
+	if packet, err = c.ReadPacket(); err != nil {
+		return
+	}
+	start, ok := packet.(*LoginStart)
+	if !ok {
+		err = fmt.Errorf("unexpected packet %#v", packet)
+		return
+	}
+	username = start.Username
+	if key == nil {
+		return
+	}
+
+	var pub []byte
+	if pub, err = x509.MarshalPKIXPublicKey(&key.PublicKey); err != nil {
+		return
+	}
+	token := make([]byte, 4)
+	if _, err = rand.Read(token); err != nil {
+		return
+	}
+	if err = c.WritePacket(&EncryptionRequest{
+		ServerID:    serverID,
+		PublicKey:   pub,
+		VerifyToken: token,
+	}); err != nil {
+		return
+	}
+
+	if packet, err = c.ReadPacket(); err != nil {
+		return
+	}
+	resp, ok := packet.(*EncryptionResponse)
+	if !ok {
+		err = fmt.Errorf("unexpected packet %#v", packet)
+		return
+	}
+	verifyToken, err := rsa.DecryptPKCS1v15(rand.Reader, key, resp.VerifyToken)
+	if err != nil {
+		return
+	}
+	if !bytes.Equal(verifyToken, token) {
+		err = errVerifyToken
+		return
+	}
+	if sharedSecret, err = rsa.DecryptPKCS1v15(rand.Reader, key, resp.SharedSecret); err != nil {
+		return
+	}
+	err = c.EnableEncryption(sharedSecret)
+	return
+}
+
+// CompleteLogin finishes the login started by AcceptLogin and switches
+// the connection into the Play state. If threshold is not negative
+// compression is enabled on the connection before the LoginSuccess
+// packet is sent.
+func (c *Conn) CompleteLogin(uuid, username string, threshold int) error {
+	if threshold >= 0 {
+		if err := c.WritePacket(&SetInitialCompression{
+			Threshold: VarInt(threshold),
+		}); err != nil {
+			return err
+		}
+		c.SetCompression(threshold)
+	}
+	if err := c.WritePacket(&LoginSuccess{
+		UUID:     uuid,
+		Username: username,
+	}); err != nil {
+		return err
+	}
+	c.State = Play
+	return nil
+}
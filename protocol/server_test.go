@@ -0,0 +1,128 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/thinkofdeath/steven/protocol/mojang"
+)
+
+func testListener(t *testing.T, serve func(c *Conn) error) (*Listener, <-chan error) {
+	l, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := make(chan error, 1)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			errs <- err
+			return
+		}
+		defer c.Close()
+		errs <- serve(c)
+	}()
+	return l, errs
+}
+
+func TestListenerStatus(t *testing.T) {
+	l, errs := testListener(t, func(c *Conn) error {
+		if _, err := c.AcceptHandshake(); err != nil {
+			return err
+		}
+		reply := StatusReply{}
+		reply.Version.Name = "test"
+		reply.Version.Protocol = SupportedProtocolVersion
+		reply.Players.Max = 20
+		return c.RespondStatus(reply)
+	})
+	defer l.Close()
+
+	c, err := Dial(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, _, err := c.RequestStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Version.Name != "test" || reply.Players.Max != 20 {
+		t.Errorf("unexpected reply %#v", reply)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListenerLogin(t *testing.T) {
+	l, errs := testListener(t, func(c *Conn) error {
+		if _, err := c.AcceptHandshake(); err != nil {
+			return err
+		}
+		name, _, err := c.AcceptLogin(nil, "")
+		if err != nil {
+			return err
+		}
+		if err := c.CompleteLogin(OfflineUUID(name).String(), name, 16); err != nil {
+			return err
+		}
+		return c.WritePacket(&ServerMessage{})
+	})
+	defer l.Close()
+
+	c, err := Dial(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.LoginToServer(mojang.Profile{Username: "Notch"}); err != nil {
+		t.Fatal(err)
+	}
+
+	packet, err := c.ReadPacket()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := packet.(*SetInitialCompression); !ok || p.Threshold != 16 {
+		t.Fatalf("unexpected packet %#v", packet)
+	}
+	c.SetCompression(16)
+	if packet, err = c.ReadPacket(); err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := packet.(*LoginSuccess); !ok || p.UUID != notchUUID || p.Username != "Notch" {
+		t.Fatalf("unexpected packet %#v", packet)
+	}
+	c.State = Play
+	if packet, err = c.ReadPacket(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := packet.(*ServerMessage); !ok {
+		t.Fatalf("unexpected packet %#v", packet)
+	}
+	if err := <-errs; err != nil && !strings.Contains(err.Error(), "closed") {
+		t.Fatal(err)
+	}
+}
+
+const notchUUID = "b50ad385-829d-3141-a216-7e7d7539ba7f"
+
+func TestOfflineUUID(t *testing.T) {
+	if u := OfflineUUID("Notch").String(); u != notchUUID {
+		t.Errorf("got %s, wanted %s", u, notchUUID)
+	}
+}
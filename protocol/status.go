@@ -50,7 +50,7 @@ func (c *Conn) RequestStatus() (response StatusReply, ping time.Duration, err er
 	defer c.Close()
 
 	err = c.WritePacket(&Handshake{
-		ProtocolVersion: SupportedProtocolVersion,
+		ProtocolVersion: VarInt(c.ProtocolVersion()),
 		Host:            c.host,
 		Port:            c.port,
 		Next:            VarInt(Status - 1),
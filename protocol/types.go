@@ -15,6 +15,8 @@
 package protocol
 
 import (
+	"crypto/md5"
+	"encoding/hex"
 	"fmt"
 	"io"
 )
@@ -42,7 +44,9 @@ const (
 )
 
 const (
-	// SupportedProtocolVersion is current protocol version this package defines
+	// SupportedProtocolVersion is current protocol version this package defines.
+	// The packet ids for other versions (see SupportedProtocolVersions) are
+	// mapped to and from the ids of this version.
 	SupportedProtocolVersion = 47
 )
 
@@ -107,6 +111,23 @@ func (u *UUID) Deserialize(r io.Reader) error {
 	return err
 }
 
+// String returns the hyphenated string representation of the
+// uuid.
+func (u UUID) String() string {
+	h := hex.EncodeToString(u[:])
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// OfflineUUID returns the uuid a server in offline mode will give
+// to the player with the passed username. This matches vanilla's
+// method of a version 3 (name based) uuid of "OfflinePlayer:<name>".
+func OfflineUUID(username string) UUID {
+	u := UUID(md5.Sum([]byte("OfflinePlayer:" + username)))
+	u[6] = (u[6] & 0x0F) | 0x30
+	u[8] = (u[8] & 0x3F) | 0x80
+	return u
+}
+
 // Packet is a structure that can be serialized or deserialized from
 // Minecraft connection
 type Packet interface {
@@ -0,0 +1,193 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"fmt"
+	"sort"
+)
+
+// protocolVersion is the packet registry for a protocol version other
+// than SupportedProtocolVersion. The packets' id methods return the
+// ids used by SupportedProtocolVersion (the base ids) which are mapped
+// to and from the ids used by the version.
+//
+// Only packets that exist in both versions with the same layout can
+// be mapped, all other packets are unavailable in the version. States
+// and directions without any registered packets (e.g. handshaking,
+// which is the same in every version) use the base ids unchanged.
+//
+// Versions with unavailable packets are partial: connections can be
+// switched to them (e.g. to inspect packets) but they aren't reported
+// as supported as a client couldn't play using them.
+type protocolVersion struct {
+	version int
+	// version id -> base id, -1 if there is no packet with the id
+	toBase [4][2][maxPacketCount]int
+	// base id -> version id, -1 if the packet doesn't exist
+	fromBase [4][2][maxPacketCount]int
+	// Whether the ids of the state and direction are remapped
+	remapped [4][2]bool
+	// Set when any packet is unavailable in the version
+	partial bool
+}
+
+var protocolVersions = map[int]*protocolVersion{}
+
+// UnsupportedPacketError is returned when reading or writing a packet
+// that doesn't exist, or has a different layout, in the protocol
+// version used by the connection. The packet is skipped so the
+// connection can still be used.
+type UnsupportedPacketError struct {
+	Version int
+	State   State
+	// The id of the packet in the version, -1 for written packets
+	ID int
+	// The packet that failed to be written, nil for read packets
+	Packet Packet
+}
+
+func (e *UnsupportedPacketError) Error() string {
+	if e.Packet != nil {
+		return fmt.Sprintf("packet %T isn't supported by protocol %d", e.Packet, e.Version)
+	}
+	return fmt.Sprintf("packet %s:%02X isn't supported by protocol %d", e.State, e.ID, e.Version)
+}
+
+// versionFor returns the registry for the version, creating it if
+// this is the first packet registered for it.
+func versionFor(version int) *protocolVersion {
+	pv, ok := protocolVersions[version]
+	if !ok {
+		pv = &protocolVersion{version: version}
+		for s := range pv.toBase {
+			for d := range pv.toBase[s] {
+				for i := range pv.toBase[s][d] {
+					pv.toBase[s][d][i] = -1
+					pv.fromBase[s][d][i] = -1
+				}
+			}
+		}
+		protocolVersions[version] = pv
+	}
+	return pv
+}
+
+// registerPacketID maps the packet with the passed base id to the
+// id it uses in the passed protocol version. This is called by the
+// code generated by protocol_builder.
+func registerPacketID(version int, state State, dir int, id, base int) {
+	pv := versionFor(version)
+	pv.remapped[state][dir] = true
+	pv.toBase[state][dir][id] = base
+	pv.fromBase[state][dir][base] = id
+}
+
+// registerUnsupportedPacket marks the packet with the passed base id
+// as unusable in the protocol version, either because it was removed
+// or because its layout changed. This is called by the code generated
+// by protocol_builder.
+func registerUnsupportedPacket(version int, state State, dir int, base int) {
+	pv := versionFor(version)
+	pv.remapped[state][dir] = true
+	pv.fromBase[state][dir][base] = -1
+	pv.partial = true
+}
+
+// SupportedProtocolVersions returns every protocol version this
+// package has a complete packet registry for, in ascending order.
+func SupportedProtocolVersions() []int {
+	out := []int{SupportedProtocolVersion}
+	for v, pv := range protocolVersions {
+		if !pv.partial {
+			out = append(out, v)
+		}
+	}
+	sort.Ints(out)
+	return out
+}
+
+// IsProtocolVersionSupported returns whether this package has a
+// complete packet registry for the protocol version. Partial versions
+// can still be passed to SetProtocolVersion.
+func IsProtocolVersionSupported(version int) bool {
+	if version == SupportedProtocolVersion {
+		return true
+	}
+	pv, ok := protocolVersions[version]
+	return ok && !pv.partial
+}
+
+// ProtocolVersion returns the protocol version the connection is
+// using.
+func (c *Conn) ProtocolVersion() int {
+	if c.version == nil {
+		return SupportedProtocolVersion
+	}
+	return c.version.version
+}
+
+// SetProtocolVersion changes the protocol version the connection uses
+// to encode and decode packets. This should be done before the
+// handshake is sent (or once it has been received for servers).
+// Partial versions are accepted, packets that are unavailable in them
+// fail with an UnsupportedPacketError.
+func (c *Conn) SetProtocolVersion(version int) error {
+	if version == SupportedProtocolVersion {
+		c.version = nil
+		return nil
+	}
+	pv, ok := protocolVersions[version]
+	if !ok {
+		return fmt.Errorf("unsupported protocol version %d", version)
+	}
+	c.version = pv
+	return nil
+}
+
+// packetID returns the id the packet uses in the connection's protocol
+// version
+func (c *Conn) packetID(state State, dir int, packet Packet) (int, error) {
+	id := packet.id()
+	if c.version == nil || !c.version.remapped[state][dir] {
+		return id, nil
+	}
+	if id = c.version.fromBase[state][dir][id]; id == -1 {
+		return 0, &UnsupportedPacketError{
+			Version: c.version.version,
+			State:   state,
+			ID:      -1,
+			Packet:  packet,
+		}
+	}
+	return id, nil
+}
+
+// packetCreatorFor returns the function used to create the packet with
+// the passed id (in the connection's protocol version) or nil if there
+// isn't one.
+func (c *Conn) packetCreatorFor(state State, dir int, id VarInt) func() Packet {
+	if id < 0 || int(id) >= maxPacketCount {
+		return nil
+	}
+	if c.version != nil && c.version.remapped[state][dir] {
+		base := c.version.toBase[state][dir][id]
+		if base == -1 {
+			return nil
+		}
+		id = VarInt(base)
+	}
+	return packetCreator[state][dir][id]
+}
@@ -0,0 +1,153 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"net"
+	"testing"
+)
+
+func TestProtocolVersionMapping(t *testing.T) {
+	const testVersion = 9999
+	registerPacketID(testVersion, Play, clientbound, 0x1F, 0x00)
+	defer delete(protocolVersions, testVersion)
+
+	if !IsProtocolVersionSupported(testVersion) {
+		t.Fatal("test version isn't supported")
+	}
+
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	server := &Conn{
+		r:                    c1,
+		w:                    c1,
+		net:                  c1,
+		direction:            clientbound,
+		State:                Play,
+		compressionThreshold: -1,
+	}
+	if err := server.SetProtocolVersion(testVersion); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := server.packetID(Play, clientbound, &KeepAliveClientbound{}); err != nil || id != 0x1F {
+		t.Errorf("wrong packet id %02X (%v)", id, err)
+	}
+	if _, err := server.packetID(Play, clientbound, &JoinGame{}); err == nil {
+		t.Error("expected unmapped packet to fail")
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.WritePacket(&KeepAliveClientbound{ID: 3})
+	}()
+
+	client := &Conn{
+		r:                    c2,
+		w:                    c2,
+		net:                  c2,
+		direction:            serverbound,
+		State:                Play,
+		compressionThreshold: -1,
+	}
+	client.SetProtocolVersion(testVersion)
+	packet, err := client.ReadPacket()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := packet.(*KeepAliveClientbound); !ok || p.ID != 3 {
+		t.Errorf("unexpected packet %#v", packet)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProtocolVersion107(t *testing.T) {
+	// Only part of the protocol is mapped
+	if IsProtocolVersionSupported(107) {
+		t.Error("partial protocol 107 is reported as supported")
+	}
+	for _, v := range SupportedProtocolVersions() {
+		if v == 107 {
+			t.Error("partial protocol 107 is listed as supported")
+		}
+	}
+	c := &Conn{direction: serverbound}
+	if err := c.SetProtocolVersion(107); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		state  State
+		packet Packet
+		id     int
+	}{
+		// The same in every version
+		{Handshaking, &Handshake{}, 0x00},
+		{Login, &LoginStart{}, 0x00},
+		{Status, &StatusRequest{}, 0x00},
+		// Moved
+		{Play, &KeepAliveServerbound{}, 0x0B},
+		{Play, &PlayerPositionLook{}, 0x0D},
+		{Play, &ChatMessage{}, 0x02},
+	}
+	for _, test := range tests {
+		id, err := c.packetID(test.state, serverbound, test.packet)
+		if err != nil || id != test.id {
+			t.Errorf("%T: got %02X (%v), wanted %02X", test.packet, id, err, test.id)
+		}
+	}
+
+	// The layout of the packet changed so it can't be sent
+	_, err := c.packetID(Play, serverbound, &ClickWindow{})
+	if _, ok := err.(*UnsupportedPacketError); !ok {
+		t.Errorf("expected an UnsupportedPacketError, got %v", err)
+	}
+}
+
+func TestUnsupportedPacketSkipped(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	go func() {
+		// Chunk data (0x20) changed in protocol 107, keep alive
+		// is 0x1F
+		c1.Write([]byte{2, 0x20, 0})
+		c1.Write([]byte{2, 0x1F, 5})
+	}()
+
+	client := &Conn{
+		r:                    c2,
+		w:                    c2,
+		net:                  c2,
+		direction:            serverbound,
+		State:                Play,
+		compressionThreshold: -1,
+	}
+	client.SetProtocolVersion(107)
+	_, err := client.ReadPacket()
+	if e, ok := err.(*UnsupportedPacketError); !ok || e.ID != 0x20 {
+		t.Fatalf("expected an UnsupportedPacketError for 0x20, got %v", err)
+	}
+	packet, err := client.ReadPacket()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := packet.(*KeepAliveClientbound); !ok || p.ID != 5 {
+		t.Errorf("unexpected packet %#v", packet)
+	}
+}
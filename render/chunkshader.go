@@ -26,6 +26,11 @@ type chunkShader struct {
 	CameraMatrix      gl.Uniform   `gl:"cameraMatrix"`
 	Offset            gl.Uniform   `gl:"offset"`
 	Texture           gl.Uniform   `gl:"textures"`
+	SkyBrightness     gl.Uniform   `gl:"skyBrightness"`
+	NightVision       gl.Uniform   `gl:"nightVision"`
+	Blindness         gl.Uniform   `gl:"blindness"`
+	FogColor          gl.Uniform   `gl:"fogColor"`
+	FogDistance       gl.Uniform   `gl:"fogDistance"`
 }
 
 const (
@@ -40,6 +45,8 @@ in vec2 aLighting;
 uniform mat4 perspectiveMatrix;
 uniform mat4 cameraMatrix;
 uniform ivec3 offset;
+uniform float skyBrightness;
+uniform float nightVision;
 
 out vec3 vColor;
 out vec4 vTextureInfo;
@@ -47,6 +54,7 @@ out vec2 vTextureOffset;
 out float vAtlas;
 out float vLighting;
 out float vLogDepth;
+out float vDepth;
 
 const float C = 0.01;
 const float FC = 1.0/log(500.0*C + 1);
@@ -55,6 +63,7 @@ void main() {
 	ivec3 pos = ivec3(aPosition.x, -aPosition.y, aPosition.z);
 	vec3 o = vec3(offset.x, -offset.y, offset.z);
 	gl_Position = perspectiveMatrix * cameraMatrix * vec4((pos / 256.0) + o * 16.0, 1.0);
+	vDepth = gl_Position.w;
 
 	vLogDepth = log(gl_Position.w*C + 1)*FC;
 	gl_Position.z = (2*vLogDepth - 1)*gl_Position.w;
@@ -64,8 +73,9 @@ void main() {
 	vTextureOffset = aTextureOffset.xy / 16.0;
 	vAtlas = aTextureOffset.z;
 
-	float light = max(aLighting.x, aLighting.y * 1.0);
+	float light = max(aLighting.x, aLighting.y * skyBrightness);
 	vLighting = clamp(0.05 + pow(light / (4000.0 * 16.0), 1.5), 0.1, 1.0);
+	vLighting = mix(vLighting, 1.0, nightVision);
 }
 `
 	fragment = `
@@ -78,6 +88,9 @@ layout(depth_less) out float gl_FragDepth;
 const float atlasSize = ` + atlasSizeStr + `;
 
 uniform sampler2DArray textures;
+uniform float blindness;
+uniform vec3 fogColor;
+uniform float fogDistance;
 
 in vec3 vColor;
 in vec4 vTextureInfo;
@@ -85,6 +98,7 @@ in vec2 vTextureOffset;
 in float vAtlas;
 in float vLighting;
 in float vLogDepth;
+in float vDepth;
 
 out vec4 fragColor;
 
@@ -100,6 +114,10 @@ void main() {
 	#endif
 	col *= vec4(vColor, 1.0);
 	col.rgb *= vLighting;
+	// Blindness only lets the player see a few blocks
+	col.rgb *= 1.0 - blindness * clamp((vDepth - 1.0) / 4.0, 0.0, 1.0);
+	// Distant blocks fade into the sky
+	col.rgb = mix(col.rgb, fogColor, clamp((vDepth - fogDistance * 0.75) / (fogDistance * 0.25), 0.0, 1.0));
 	fragColor = col;
 }
 `
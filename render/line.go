@@ -67,6 +67,9 @@ func drawLines() {
 }
 
 func DrawBox(x1, y1, z1, x2, y2, z2 float64, r, g, b, a byte) {
+	if Headless {
+		return
+	}
 	for _, f := range faceVertices {
 		for _, v := range f {
 			val := v[0]*x2 + (1.0-v[0])*x1
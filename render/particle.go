@@ -0,0 +1,315 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"math"
+
+	"github.com/thinkofdeath/steven/render/builder"
+	"github.com/thinkofdeath/steven/render/gl"
+)
+
+// MaxParticles is the most particles that will be simulated at
+// once, the oldest are removed to make room for new ones.
+const MaxParticles = 4000
+
+// Particle is a sprite that always faces the camera. Particles are
+// simulated on the cpu and removed once they reach the end of their
+// lifetime.
+type Particle struct {
+	X, Y, Z    float64
+	VX, VY, VZ float64
+	// Acceleration downwards in blocks per a tick squared
+	Gravity float64
+	// Multiplier applied to the velocity each tick
+	Drag float64
+	// Current age and lifetime in ticks
+	Age, Life float64
+	// Width of the particle in blocks
+	Size       float64
+	R, G, B, A float32
+
+	// Texture is the sprite drawn for the particle. If Frames is
+	// set the sprite is instead picked from it based on the age
+	// of the particle.
+	Texture TextureInfo
+	Frames  []TextureInfo
+
+	// Collide stops the particle from moving into solid blocks
+	Collide bool
+	// Emissive particles ignore the light level of the world
+	Emissive bool
+	// Update is called every frame before the particle is moved,
+	// ticks is the time passed in ticks. Optional.
+	Update func(p *Particle, ticks float64)
+
+	OnGround bool
+	light    float32
+}
+
+// ParticleWorld provides the information about the world needed to
+// simulate particles.
+type ParticleWorld interface {
+	// Light returns the brightness (0-1) at the position
+	Light(x, y, z float64) float32
+	// Solid returns whether the position is inside a block that
+	// particles can't move through
+	Solid(x, y, z float64) bool
+}
+
+// The particles are kept in a fixed ring, oldest first, so that
+// replacing the oldest particle when full doesn't move the others.
+var (
+	particles     [MaxParticles]*Particle
+	particleStart int
+	particleCount int
+)
+
+// particleAt returns the i-th oldest particle.
+func particleAt(i int) *Particle {
+	return particles[(particleStart+i)%MaxParticles]
+}
+
+// AddParticle adds the particle to the world.
+func AddParticle(p *Particle) {
+	if p.Drag == 0 {
+		p.Drag = 0.98
+	}
+	p.light = 1
+	if particleCount == MaxParticles {
+		particles[particleStart] = p
+		particleStart = (particleStart + 1) % MaxParticles
+		return
+	}
+	particles[(particleStart+particleCount)%MaxParticles] = p
+	particleCount++
+}
+
+// ClearParticles removes all particles from the world.
+func ClearParticles() {
+	for i := range particles {
+		particles[i] = nil
+	}
+	particleStart, particleCount = 0, 0
+}
+
+// ParticleCount returns the number of particles currently in the
+// world.
+func ParticleCount() int {
+	return particleCount
+}
+
+// TickParticles moves all particles by delta (in 60ths of a second)
+// and removes ones that have expired.
+func TickParticles(delta float64, w ParticleWorld) {
+	ticks := delta / 3
+	alive := 0
+	for i := 0; i < particleCount; i++ {
+		p := particleAt(i)
+		p.Age += ticks
+		if p.Age >= p.Life {
+			continue
+		}
+		if p.Update != nil {
+			p.Update(p, ticks)
+		}
+		p.VY -= p.Gravity * ticks
+		p.move(w, ticks)
+
+		drag := math.Pow(p.Drag, ticks)
+		p.VX *= drag
+		p.VY *= drag
+		p.VZ *= drag
+		if p.OnGround {
+			friction := math.Pow(0.7, ticks)
+			p.VX *= friction
+			p.VZ *= friction
+		}
+
+		if p.Emissive {
+			p.light = 1
+		} else {
+			p.light = w.Light(p.X, p.Y, p.Z)
+		}
+		// Compacts the ring in place, the particle is never
+		// written ahead of one that hasn't been visited yet
+		particles[(particleStart+alive)%MaxParticles] = p
+		alive++
+	}
+	// Allow the removed particles to be collected
+	for i := alive; i < particleCount; i++ {
+		particles[(particleStart+i)%MaxParticles] = nil
+	}
+	particleCount = alive
+}
+
+// move moves the particle along each axis separately stopping
+// on any axis that would take it into a solid block.
+func (p *Particle) move(w ParticleWorld, ticks float64) {
+	if !p.Collide {
+		p.X += p.VX * ticks
+		p.Y += p.VY * ticks
+		p.Z += p.VZ * ticks
+		return
+	}
+	p.OnGround = false
+	if x := p.X + p.VX*ticks; !w.Solid(x, p.Y, p.Z) {
+		p.X = x
+	} else {
+		p.VX = 0
+	}
+	if y := p.Y + p.VY*ticks; !w.Solid(p.X, y, p.Z) {
+		p.Y = y
+	} else {
+		p.OnGround = p.VY < 0
+		p.VY = 0
+	}
+	if z := p.Z + p.VZ*ticks; !w.Solid(p.X, p.Y, z) {
+		p.Z = z
+	} else {
+		p.VZ = 0
+	}
+}
+
+func (p *Particle) texture() TextureInfo {
+	if len(p.Frames) == 0 {
+		return p.Texture
+	}
+	i := int(p.Age / p.Life * float64(len(p.Frames)))
+	if i >= len(p.Frames) {
+		i = len(p.Frames) - 1
+	}
+	return p.Frames[i]
+}
+
+var particleState = struct {
+	program  gl.Program
+	shader   *particleShader
+	array    gl.VertexArray
+	buffer   gl.Buffer
+	data     *builder.Buffer
+	prevSize int
+}{
+	prevSize: -1,
+}
+
+type particleVertex struct {
+	X, Y, Z                    float32
+	OX, OY                     float32
+	TX, TY, TW, TH             uint16
+	TOffsetX, TOffsetY, TAtlas int16
+	Pad0                       int16
+	R, G, B, A                 byte
+}
+
+var particleFunc, particleTypes = builder.Struct(&particleVertex{})
+
+// The corners of the two triangles that make up a particle
+var particleCorners = [6][2]float32{
+	{-1, -1}, {1, -1}, {-1, 1},
+	{1, 1}, {-1, 1}, {1, -1},
+}
+
+func initParticles() {
+	particleState.program = CreateProgram(vertexParticle, fragmentParticle)
+	particleState.shader = &particleShader{}
+	InitStruct(particleState.shader, particleState.program)
+	particleState.data = builder.New(particleTypes...)
+
+	particleState.array = gl.CreateVertexArray()
+	particleState.array.Bind()
+	particleState.buffer = gl.CreateBuffer()
+	particleState.buffer.Bind(gl.ArrayBuffer)
+	s := particleState.shader
+	s.Position.Enable()
+	s.Offset.Enable()
+	s.TextureInfo.Enable()
+	s.TextureOffset.Enable()
+	s.Color.Enable()
+	s.Position.Pointer(3, gl.Float, false, 40, 0)
+	s.Offset.Pointer(2, gl.Float, false, 40, 12)
+	s.TextureInfo.Pointer(4, gl.UnsignedShort, false, 40, 20)
+	s.TextureOffset.PointerInt(3, gl.Short, 40, 28)
+	s.Color.Pointer(4, gl.UnsignedByte, true, 40, 36)
+}
+
+func drawParticles() {
+	if particleCount == 0 {
+		return
+	}
+	buf := particleState.data
+	buf.Reset()
+	v := particleVertex{}
+	for i := 0; i < particleCount; i++ {
+		p := particleAt(i)
+		tex := p.texture()
+		if tex == nil {
+			continue
+		}
+		rect := tex.Rect()
+		v.X, v.Y, v.Z = float32(p.X), float32(p.Y), float32(p.Z)
+		v.TX, v.TY = uint16(rect.X), uint16(rect.Y)
+		v.TW, v.TH = uint16(rect.Width), uint16(rect.Height)
+		v.TAtlas = int16(tex.Atlas())
+		v.R = byte(clampColor(p.R*p.light) * 255)
+		v.G = byte(clampColor(p.G*p.light) * 255)
+		v.B = byte(clampColor(p.B*p.light) * 255)
+		v.A = byte(clampColor(p.A) * 255)
+		size := float32(p.Size / 2)
+		for _, c := range particleCorners {
+			v.OX, v.OY = c[0]*size, c[1]*size
+			v.TOffsetX = int16(8 * float32(rect.Width) * (c[0] + 1))
+			v.TOffsetY = int16(8 * float32(rect.Height) * (1 - c[1]))
+			particleFunc(buf, &v)
+		}
+	}
+	if buf.Count() == 0 {
+		return
+	}
+
+	gl.Enable(gl.Blend)
+	// Particles may be seen from either side depending on how
+	// the camera is rotated
+	gl.Disable(gl.CullFaceFlag)
+	particleState.program.Use()
+	particleState.shader.Texture.Int(0)
+	particleState.shader.PerspectiveMatrix.Matrix4(&perspectiveMatrix)
+	particleState.shader.CameraMatrix.Matrix4(&cameraMatrix)
+	particleState.shader.Blindness.Float(Blindness)
+	particleState.array.Bind()
+	particleState.buffer.Bind(gl.ArrayBuffer)
+	data := buf.Data()
+	if len(data) > particleState.prevSize {
+		particleState.prevSize = len(data)
+		particleState.buffer.Data(data, gl.DynamicDraw)
+	} else {
+		target := particleState.buffer.Map(gl.WriteOnly, len(data))
+		copy(target, data)
+		particleState.buffer.Unmap()
+	}
+	gl.DrawArrays(gl.Triangles, 0, buf.Count())
+	gl.Enable(gl.CullFaceFlag)
+	gl.Disable(gl.Blend)
+}
+
+func clampColor(f float32) float32 {
+	if f < 0 {
+		return 0
+	}
+	if f > 1 {
+		return 1
+	}
+	return f
+}
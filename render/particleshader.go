@@ -0,0 +1,105 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import "github.com/thinkofdeath/steven/render/gl"
+
+type particleShader struct {
+	Position          gl.Attribute `gl:"aPosition"`
+	Offset            gl.Attribute `gl:"aOffset"`
+	TextureInfo       gl.Attribute `gl:"aTextureInfo"`
+	TextureOffset     gl.Attribute `gl:"aTextureOffset"`
+	Color             gl.Attribute `gl:"aColor"`
+	PerspectiveMatrix gl.Uniform   `gl:"perspectiveMatrix"`
+	CameraMatrix      gl.Uniform   `gl:"cameraMatrix"`
+	Texture           gl.Uniform   `gl:"textures"`
+	Blindness         gl.Uniform   `gl:"blindness"`
+}
+
+const (
+	vertexParticle = `
+#version 150
+in vec3 aPosition;
+in vec2 aOffset;
+in vec4 aTextureInfo;
+in ivec3 aTextureOffset;
+in vec4 aColor;
+
+uniform mat4 perspectiveMatrix;
+uniform mat4 cameraMatrix;
+
+out vec4 vColor;
+out vec4 vTextureInfo;
+out vec2 vTextureOffset;
+out float vAtlas;
+out float vLogDepth;
+out float vDepth;
+
+const float C = 0.01;
+const float FC = 1.0/log(500.0*C + 1);
+
+void main() {
+	vec3 pos = vec3(aPosition.x, -aPosition.y, aPosition.z);
+	// Offset in view space so that the particle faces the camera
+	vec4 view = cameraMatrix * vec4(pos, 1.0);
+	view.xy += aOffset;
+	gl_Position = perspectiveMatrix * view;
+
+	vLogDepth = log(gl_Position.w*C + 1)*FC;
+	gl_Position.z = (2*vLogDepth - 1)*gl_Position.w;
+	vDepth = gl_Position.w;
+
+	vColor = aColor;
+	vTextureInfo = aTextureInfo;
+	vTextureOffset = aTextureOffset.xy / 16.0;
+	vAtlas = aTextureOffset.z;
+}
+`
+	fragmentParticle = `
+#version 150
+#ifdef GL_ARB_conservative_depth
+#extension GL_ARB_conservative_depth : enable
+layout(depth_less) out float gl_FragDepth;
+#endif
+
+const float atlasSize = ` + atlasSizeStr + `;
+
+uniform sampler2DArray textures;
+uniform float blindness;
+
+in vec4 vColor;
+in vec4 vTextureInfo;
+in vec2 vTextureOffset;
+in float vAtlas;
+in float vLogDepth;
+in float vDepth;
+
+out vec4 fragColor;
+
+void main() {
+	gl_FragDepth = vLogDepth;
+	vec2 tPos = vTextureOffset;
+	tPos = clamp(tPos, vec2(0.0), vTextureInfo.zw);
+	tPos += vTextureInfo.xy;
+	tPos /= atlasSize;
+	vec4 col = texture(textures, vec3(tPos, vAtlas));
+	if (col.a <= 0.05) discard;
+	col *= vColor;
+	// Blindness only lets the player see a few blocks
+	col.rgb *= 1.0 - blindness * clamp((vDepth - 1.0) / 4.0, 0.0, 1.0);
+	fragColor = col;
+}
+`
+)
@@ -37,6 +37,7 @@ var (
 	lastWidth, lastHeight int = -1, -1
 	perspectiveMatrix         = mgl32.Mat4{}
 	cameraMatrix              = mgl32.Mat4{}
+	fogColor                  = mgl32.Vec3{}
 	frustum                   = vmath.NewFrustum()
 
 	syncChan = make(chan func(), 500)
@@ -46,6 +47,28 @@ var (
 	texturesCreated bool
 
 	MultiSample bool
+
+	// Headless disables all OpenGL calls. Textures and models are
+	// still tracked so that the rest of the client can use them
+	// but nothing is uploaded or drawn. Start and Draw must not be
+	// called in this mode, Update should be called instead.
+	// This must be set before LoadTextures is called.
+	Headless bool
+
+	// SkyBrightness scales the sky light of the world (0-1) to
+	// simulate the time of day.
+	SkyBrightness float32 = 1.0
+	// SkyColor is the color drawn behind the world, distant parts
+	// of the world fade into it.
+	SkyColor = mgl32.Vec3{122.0 / 255.0, 165.0 / 255.0, 247.0 / 255.0}
+	// FogDistance is the distance in blocks at which the world has
+	// completely faded into the sky.
+	FogDistance float32 = 160
+	// NightVision brightens the world towards full light (0-1).
+	NightVision float32
+	// Blindness fades the world to black a few blocks away from
+	// the camera (0-1).
+	Blindness float32
 )
 
 // Start starts the renderer
@@ -54,7 +77,6 @@ func Start() {
 		gl.DebugLog()
 	}
 
-	gl.ClearColor(122.0/255.0, 165.0/255.0, 247.0/255.0, 1.0)
 	gl.Enable(gl.DepthTest)
 	gl.Enable(gl.CullFaceFlag)
 	gl.CullFace(gl.Back)
@@ -71,6 +93,7 @@ func Start() {
 	initUI()
 	initLineDraw()
 	initStatic()
+	initParticles()
 
 	gl.BlendFunc(gl.SrcAlpha, gl.OneMinusSrcAlpha)
 
@@ -88,15 +111,7 @@ var (
 func Draw(width, height int, delta float64) {
 	tickAnimatedTextures(delta)
 	frameID++
-sync:
-	for {
-		select {
-		case f := <-syncChan:
-			f()
-		default:
-			break sync
-		}
-	}
+	runSync()
 
 	// Only update the viewport if the window was resized
 	if lastHeight != height || lastWidth != width || lastFOV != FOV {
@@ -126,6 +141,10 @@ sync:
 	glTexture.Bind(gl.Texture2DArray)
 	gl.ActiveTexture(0)
 
+	// The fog fades into the sky so the edge of the world
+	// blends into the background
+	fogColor = SkyColor.Mul(1 - Blindness)
+	gl.ClearColor(fogColor.X(), fogColor.Y(), fogColor.Z(), 1.0)
 	gl.Clear(gl.ColorBufferBit | gl.DepthBufferBit)
 
 	chunkProgram.Use()
@@ -152,6 +171,11 @@ sync:
 	shaderChunk.PerspectiveMatrix.Matrix4(&perspectiveMatrix)
 	shaderChunk.CameraMatrix.Matrix4(&cameraMatrix)
 	shaderChunk.Texture.Int(0)
+	shaderChunk.SkyBrightness.Float(SkyBrightness)
+	shaderChunk.NightVision.Float(NightVision)
+	shaderChunk.Blindness.Float(Blindness)
+	shaderChunk.FogColor.Float3(fogColor.X(), fogColor.Y(), fogColor.Z())
+	shaderChunk.FogDistance.Float(FogDistance)
 
 	chunkPos := position{
 		X: int(Camera.X) >> 4,
@@ -169,11 +193,17 @@ sync:
 
 	drawLines()
 	drawStatic()
+	drawParticles()
 
 	chunkProgramT.Use()
 	shaderChunkT.PerspectiveMatrix.Matrix4(&perspectiveMatrix)
 	shaderChunkT.CameraMatrix.Matrix4(&cameraMatrix)
 	shaderChunkT.Texture.Int(0)
+	shaderChunkT.SkyBrightness.Float(SkyBrightness)
+	shaderChunkT.NightVision.Float(NightVision)
+	shaderChunkT.Blindness.Float(Blindness)
+	shaderChunkT.FogColor.Float3(fogColor.X(), fogColor.Y(), fogColor.Z())
+	shaderChunkT.FogDistance.Float(FogDistance)
 
 	gl.Enable(gl.Blend)
 	for i := range renderOrder {
@@ -265,6 +295,23 @@ itQueue:
 	}
 }
 
+// Update runs the functions queued with Sync without drawing a
+// frame. This replaces Draw when running headless.
+func Update() {
+	runSync()
+}
+
+func runSync() {
+	for {
+		select {
+		case f := <-syncChan:
+			f()
+		default:
+			return
+		}
+	}
+}
+
 // Sync runs the passed function on the next frame on the same goroutine
 // as the renderer.
 func Sync(f func()) {
@@ -71,7 +71,9 @@ func RefSkin(hash string) {
 		refCount: 1,
 	}
 	skins[hash] = s
-	go obtainSkin(hash, s)
+	if !Headless {
+		go obtainSkin(hash, s)
+	}
 }
 
 func obtainSkin(hash string, s *skin) {
@@ -191,3 +193,14 @@ func AddIcon(id string, pix image.Image) {
 	}
 	skins[id] = s
 }
+
+// UpdateIcon replaces the image of an icon added by AddIcon, the new
+// image must be the same size as the original.
+func UpdateIcon(id string, pix image.Image) {
+	s := skins[id]
+	if s == nil {
+		return
+	}
+	s.data = imgToBytes(pix)
+	uploadTexture(s.info.info, s.data)
+}
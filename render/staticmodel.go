@@ -52,6 +52,8 @@ type StaticModel struct {
 	// For culling only
 	X, Y, Z float32
 	Radius  float32
+	// Skips drawing the model when set
+	Hidden bool
 	// Per a part matrix
 	Matrix     []mgl32.Mat4
 	Colors     [][4]float32
@@ -69,21 +71,23 @@ type StaticModel struct {
 func NewStaticModel(parts [][]*StaticVertex) *StaticModel {
 	model := &StaticModel{}
 
-	model.array = gl.CreateVertexArray()
-	model.array.Bind()
-	staticState.indexBuffer.Bind(gl.ElementArrayBuffer)
-	model.buffer = gl.CreateBuffer()
-	model.buffer.Bind(gl.ArrayBuffer)
-	staticState.shader.Position.Enable()
-	staticState.shader.TextureInfo.Enable()
-	staticState.shader.TextureOffset.Enable()
-	staticState.shader.Color.Enable()
-	staticState.shader.ID.Enable()
-	staticState.shader.Position.Pointer(3, gl.Float, false, 36, 0)
-	staticState.shader.TextureInfo.Pointer(4, gl.UnsignedShort, false, 36, 12)
-	staticState.shader.TextureOffset.PointerInt(3, gl.Short, 36, 20)
-	staticState.shader.Color.Pointer(4, gl.UnsignedByte, true, 36, 28)
-	staticState.shader.ID.PointerInt(4, gl.UnsignedByte, 36, 32)
+	if !Headless {
+		model.array = gl.CreateVertexArray()
+		model.array.Bind()
+		staticState.indexBuffer.Bind(gl.ElementArrayBuffer)
+		model.buffer = gl.CreateBuffer()
+		model.buffer.Bind(gl.ArrayBuffer)
+		staticState.shader.Position.Enable()
+		staticState.shader.TextureInfo.Enable()
+		staticState.shader.TextureOffset.Enable()
+		staticState.shader.Color.Enable()
+		staticState.shader.ID.Enable()
+		staticState.shader.Position.Pointer(3, gl.Float, false, 36, 0)
+		staticState.shader.TextureInfo.Pointer(4, gl.UnsignedShort, false, 36, 12)
+		staticState.shader.TextureOffset.PointerInt(3, gl.Short, 36, 20)
+		staticState.shader.Color.Pointer(4, gl.UnsignedByte, true, 36, 28)
+		staticState.shader.ID.PointerInt(4, gl.UnsignedByte, 36, 32)
+	}
 
 	model.Matrix = make([]mgl32.Mat4, len(parts))
 	model.Colors = make([][4]float32, len(parts))
@@ -101,6 +105,11 @@ func NewStaticModel(parts [][]*StaticVertex) *StaticModel {
 		all = append(all, p...)
 	}
 	model.all = all
+	if Headless {
+		// Nothing to upload, the model is only kept for its
+		// matrices
+		return model
+	}
 	model.data()
 
 	staticState.models = append(staticState.models, model)
@@ -152,6 +161,9 @@ func (sm *StaticModel) data() {
 }
 
 func (sm *StaticModel) Free() {
+	if Headless {
+		return
+	}
 	sm.array.Delete()
 	sm.buffer.Delete()
 	for i, s := range staticState.models {
@@ -190,10 +202,16 @@ func drawStatic() {
 	staticState.shader.Texture.Int(0)
 	staticState.shader.PerspectiveMatrix.Matrix4(&perspectiveMatrix)
 	staticState.shader.CameraMatrix.Matrix4(&cameraMatrix)
+	staticState.shader.Blindness.Float(Blindness)
+	staticState.shader.FogColor.Float3(fogColor.X(), fogColor.Y(), fogColor.Z())
+	staticState.shader.FogDistance.Float(FogDistance)
 
 	offsetBuf := make([]uintptr, 10)
 
 	for _, mdl := range staticState.models {
+		if mdl.Hidden {
+			continue
+		}
 		if mdl.Radius != 0 && !frustum.IsSphereInside(mdl.X, mdl.Y, mdl.Z, mdl.Radius) {
 			continue
 		}
@@ -227,6 +245,9 @@ type staticShader struct {
 	ModelMatrix       gl.Uniform   `gl:"modelMatrix[]"`
 	Texture           gl.Uniform   `gl:"textures"`
 	ColorMul          gl.Uniform   `gl:"colorMul[]"`
+	Blindness         gl.Uniform   `gl:"blindness"`
+	FogColor          gl.Uniform   `gl:"fogColor"`
+	FogDistance       gl.Uniform   `gl:"fogDistance"`
 }
 
 const (
@@ -247,6 +268,7 @@ out vec4 vTextureInfo;
 out vec2 vTextureOffset;
 out float vAtlas;
 out float vLogDepth;
+out float vDepth;
 out float vID;
 
 const float C = 0.01;
@@ -255,6 +277,7 @@ const float FC = 1.0/log(500.0*C + 1);
 void main() {
 	vec3 pos = vec3(aPosition.x, -aPosition.y, aPosition.z);
 	gl_Position = perspectiveMatrix * cameraMatrix * modelMatrix[id] * vec4(pos, 1.0);
+	vDepth = gl_Position.w;
 
 	vLogDepth = log(gl_Position.w*C + 1)*FC;
 	gl_Position.z = (2*vLogDepth - 1)*gl_Position.w;
@@ -277,12 +300,16 @@ const float atlasSize = ` + atlasSizeStr + `;
 
 uniform sampler2DArray textures;
 uniform vec4 colorMul[10];
+uniform float blindness;
+uniform vec3 fogColor;
+uniform float fogDistance;
 
 in vec4 vColor;
 in vec4 vTextureInfo;
 in vec2 vTextureOffset;
 in float vAtlas;
 in float vLogDepth;
+in float vDepth;
 in float vID;
 
 out vec4 fragColor;
@@ -295,8 +322,12 @@ void main() {
 	tPos /= atlasSize;
 	vec4 col = texture(textures, vec3(tPos, vAtlas));
 	if (col.a <= 0.05) discard;
-	col *= vColor;
-	fragColor = col * colorMul[int(vID)];
+	col *= vColor * colorMul[int(vID)];
+	// Blindness only lets the player see a few blocks
+	col.rgb *= 1.0 - blindness * clamp((vDepth - 1.0) / 4.0, 0.0, 1.0);
+	// Distant blocks fade into the sky
+	col.rgb = mix(col.rgb, fogColor, clamp((vDepth - fogDistance * 0.75) / (fogDistance * 0.25), 0.0, 1.0));
+	fragColor = col;
 }
 `
 )
@@ -187,10 +187,10 @@ func GetTexture(name string) TextureInfo {
 			}
 			ret <- struct{}{}
 		}
-		if w := glfw.GetCurrentContext(); w == nil {
-			syncChan <- f
-		} else {
+		if Headless || glfw.GetCurrentContext() != nil {
 			f()
+		} else {
+			syncChan <- f
 		}
 		<-ret
 		textureLock.RLock()
@@ -209,7 +209,10 @@ type loadedTexture struct {
 func LoadTextures() {
 	textureLock.Lock()
 
-	if texturesCreated {
+	if Headless {
+		// Only the layout of the atlases is needed
+		texturesCreated = true
+	} else if texturesCreated {
 		glTexture.Bind(gl.Texture2DArray)
 		data := make([]byte, AtlasSize*AtlasSize*textureCount*4)
 		glTexture.Image3D(0, AtlasSize, AtlasSize, textureCount, gl.RGBA, gl.UnsignedByte, data)
@@ -383,7 +386,7 @@ func addTexture(pix []byte, width, height int) *textureInfo {
 
 	info := &textureInfo{atlas: len(textures) - 1, rect: *rect}
 	if texturesCreated {
-		if reupload {
+		if reupload && !Headless {
 			glTexture.Bind(gl.Texture2DArray)
 			data := make([]byte, AtlasSize*AtlasSize*textureCount*4)
 			glTexture.Get(0, gl.RGBA, gl.UnsignedByte, data)
@@ -397,6 +400,9 @@ func addTexture(pix []byte, width, height int) *textureInfo {
 }
 
 func uploadTexture(info *textureInfo, data []byte) {
+	if Headless {
+		return
+	}
 	glTexture.Bind(gl.Texture2DArray)
 	r := info.rect
 	glTexture.SubImage3D(0, r.X, r.Y, info.atlas, r.Width, r.Height, 1, gl.RGBA, gl.UnsignedByte, data)
@@ -94,8 +94,12 @@ func reloadResources() {
 	modelCache = map[string]*model{}
 	log.Println("Reloading textures")
 	render.LoadTextures()
+	mapIcons = nil
+	Client.maps.redraw()
 	log.Println("Reloading biomes")
 	loadBiomes()
+	log.Println("Reloading sounds")
+	loadSounds()
 	ui.ForceDraw()
 	log.Println("Reloading blocks")
 	reinitBlocks()
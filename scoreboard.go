@@ -0,0 +1,295 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steven
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/thinkofdeath/steven/chat"
+	"github.com/thinkofdeath/steven/protocol"
+	"github.com/thinkofdeath/steven/render"
+	"github.com/thinkofdeath/steven/ui"
+	"github.com/thinkofdeath/steven/ui/scene"
+)
+
+type scoreboardSlot int
+
+const (
+	slotList scoreboardSlot = iota
+	slotSidebar
+	slotBelowName
+	// Slots 3-18 are sidebars that are only shown to members
+	// of teams with the matching color
+	slotTeamSidebar
+
+	scoreboardSlots = slotTeamSidebar + 16
+)
+
+// The most scores the sidebar will display
+const maxSidebarScores = 15
+
+type objective struct {
+	name        string
+	displayName string
+	hearts      bool
+	scores      map[string]int
+}
+
+type scoreboard struct {
+	objectives map[string]*objective
+	display    [scoreboardSlots]*objective
+
+	scene   *scene.Type
+	dirty   bool
+	sidebar struct {
+		background *ui.Image
+		title      *ui.Formatted
+		entries    []*sidebarEntry
+	}
+}
+
+type sidebarEntry struct {
+	name  *ui.Formatted
+	score *ui.Text
+}
+
+func (s *sidebarEntry) set(enabled bool) {
+	s.name.SetDraw(enabled)
+	s.score.SetDraw(enabled)
+}
+
+func (s *scoreboard) init() {
+	s.objectives = map[string]*objective{}
+	s.scene = scene.New(true)
+
+	sb := &s.sidebar
+	sb.background = ui.NewImage(render.GetTexture("solid"), 0, 0, 0, 0, 0, 0, 1, 1, 0, 0, 0)
+	sb.background.SetA(80)
+	sb.background.SetDraw(false)
+	s.scene.AddDrawable(sb.background.Attach(ui.Middle, ui.Right))
+	sb.title = ui.NewFormatted(chat.AnyComponent{Value: &chat.TextComponent{}}, 0, 1)
+	sb.title.AttachTo(sb.background)
+	sb.title.SetDraw(false)
+	s.scene.AddDrawable(sb.title.Attach(ui.Top, ui.Center))
+}
+
+func (s *scoreboard) free() {
+	s.scene.Hide()
+}
+
+// updateObjective creates, removes or changes an objective.
+func (s *scoreboard) updateObjective(p *protocol.ScoreboardObjective) {
+	switch p.Mode {
+	case 0: // Create
+		s.objectives[p.Name] = &objective{
+			name:   p.Name,
+			scores: map[string]int{},
+		}
+		fallthrough
+	case 2: // Update
+		o, ok := s.objectives[p.Name]
+		if !ok {
+			return
+		}
+		o.displayName = p.Value
+		o.hearts = p.Type == "hearts"
+	case 1: // Remove
+		o, ok := s.objectives[p.Name]
+		if !ok {
+			return
+		}
+		delete(s.objectives, p.Name)
+		for i := range s.display {
+			if s.display[i] == o {
+				s.display[i] = nil
+			}
+		}
+	}
+	s.dirty = true
+}
+
+// updateScore sets or removes an entity's score.
+func (s *scoreboard) updateScore(p *protocol.UpdateScore) {
+	if p.Action == 1 {
+		// Removing without an objective removes the entity from
+		// every objective
+		if p.ObjectName == "" {
+			for _, o := range s.objectives {
+				delete(o.scores, p.Name)
+			}
+		} else if o, ok := s.objectives[p.ObjectName]; ok {
+			delete(o.scores, p.Name)
+		}
+		s.dirty = true
+		return
+	}
+	o, ok := s.objectives[p.ObjectName]
+	if !ok {
+		return
+	}
+	o.scores[p.Name] = int(p.Value)
+	s.dirty = true
+}
+
+// setDisplay changes the objective displayed in a slot, an empty
+// name clears the slot.
+func (s *scoreboard) setDisplay(p *protocol.ScoreboardDisplay) {
+	if int(p.Position) >= len(s.display) {
+		return
+	}
+	s.display[p.Position] = s.objectives[p.Name]
+	s.dirty = true
+}
+
+// score returns the entity's score in the objective displayed in
+// the slot, ok is false if the slot is empty or the entity has no
+// score.
+func (s *scoreboard) score(slot scoreboardSlot, name string) (score int, ok bool) {
+	o := s.display[slot]
+	if o == nil {
+		return 0, false
+	}
+	score, ok = o.scores[name]
+	return score, ok
+}
+
+// resolveScores fills in the values of the score components in
+// the message from the scoreboard. Components that refer to
+// unknown scores keep the value sent by the server.
+func (s *scoreboard) resolveScores(msg chat.AnyComponent) {
+	var extra []chat.AnyComponent
+	switch c := msg.Value.(type) {
+	case *chat.TextComponent:
+		extra = c.Extra
+	case *chat.TranslateComponent:
+		for _, w := range c.With {
+			s.resolveScores(w)
+		}
+		extra = c.Extra
+	case *chat.SelectorComponent:
+		extra = c.Extra
+	case *chat.ScoreComponent:
+		name := c.Score.Name
+		// * refers to the player reading the message
+		if name == "*" {
+			name = profile.Username
+		}
+		if o, ok := s.objectives[c.Score.Objective]; ok {
+			if v, ok := o.scores[name]; ok {
+				c.Score.Value = strconv.Itoa(v)
+			}
+		}
+		extra = c.Extra
+	}
+	for _, e := range extra {
+		s.resolveScores(e)
+	}
+}
+
+func (s *scoreboard) render(delta float64) {
+	if !s.dirty {
+		return
+	}
+	s.dirty = false
+
+	sb := &s.sidebar
+	for _, e := range sb.entries {
+		e.set(false)
+	}
+	o := s.display[slotSidebar]
+	// The client's team may have its own sidebar
+	if color := Client.teams.sidebarColor(); color != -1 && s.display[slotTeamSidebar+scoreboardSlot(color)] != nil {
+		o = s.display[slotTeamSidebar+scoreboardSlot(color)]
+	}
+	if o == nil {
+		sb.background.SetDraw(false)
+		sb.title.SetDraw(false)
+		return
+	}
+
+	sb.title.Update(legacyComponent(o.displayName))
+	sb.title.SetDraw(true)
+	width := sb.title.Width
+
+	scores := o.sortedScores()
+	if len(scores) > maxSidebarScores {
+		scores = scores[:maxSidebarScores]
+	}
+	for i, sc := range scores {
+		if i >= len(sb.entries) {
+			name := ui.NewFormatted(chat.AnyComponent{Value: &chat.TextComponent{}}, 2, 0)
+			name.AttachTo(sb.background)
+			s.scene.AddDrawable(name.Attach(ui.Top, ui.Left))
+			score := ui.NewText("", 2, 0, 255, 85, 85)
+			score.AttachTo(sb.background)
+			s.scene.AddDrawable(score.Attach(ui.Top, ui.Right))
+			sb.entries = append(sb.entries, &sidebarEntry{name: name, score: score})
+		}
+		e := sb.entries[i]
+		e.set(true)
+		y := 18*float64(i+1) + 1
+		e.name.SetY(y)
+		e.name.Update(legacyComponent(Client.teams.formatName(sc.name)))
+		e.score.SetY(y)
+		e.score.Update(strconv.Itoa(sc.value))
+		if w := e.name.Width + e.score.Width + 16; w > width {
+			width = w
+		}
+	}
+	sb.background.SetWidth(width + 4)
+	sb.background.SetHeight(18 * float64(len(scores)+1))
+	sb.background.SetDraw(true)
+}
+
+type scoreEntry struct {
+	name  string
+	value int
+}
+
+// sortedScores returns the objective's scores from highest to
+// lowest. Scores for names starting with # are hidden from the
+// sidebar so they are left out.
+func (o *objective) sortedScores() []scoreEntry {
+	out := make([]scoreEntry, 0, len(o.scores))
+	for name, v := range o.scores {
+		if strings.HasPrefix(name, "#") {
+			continue
+		}
+		out = append(out, scoreEntry{name: name, value: v})
+	}
+	sort.Sort(sortedScores(out))
+	return out
+}
+
+type sortedScores []scoreEntry
+
+func (s sortedScores) Len() int { return len(s) }
+func (s sortedScores) Less(a, b int) bool {
+	if s[a].value != s[b].value {
+		return s[a].value > s[b].value
+	}
+	return s[a].name < s[b].name
+}
+func (s sortedScores) Swap(a, b int) { s[a], s[b] = s[b], s[a] }
+
+// legacyComponent converts a string using legacy formatting codes
+// into a component.
+func legacyComponent(s string) chat.AnyComponent {
+	c := chat.AnyComponent{Value: &chat.TextComponent{Text: s}}
+	chat.ConvertLegacy(c)
+	return c
+}
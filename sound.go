@@ -0,0 +1,166 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steven
+
+import (
+	"log"
+	"math/rand"
+	"strings"
+
+	"github.com/thinkofdeath/steven/audio"
+	"github.com/thinkofdeath/steven/protocol"
+	"github.com/thinkofdeath/steven/render"
+)
+
+// AudioBackend is used to play the client's sounds. This must be
+// set before the client is started. If nil, sounds are played
+// through an external player (see audio.CommandBackend), or
+// discarded when running headless or when no player is installed.
+var AudioBackend audio.Backend
+
+var soundManager *audio.Manager
+
+func initAudio() {
+	if soundManager != nil {
+		soundManager.Close()
+	}
+	soundManager = audio.NewManager(audioBackend())
+	updateSoundVolumes()
+	loadSounds()
+}
+
+// audioBackend returns the backend the client's sounds should be
+// played with.
+func audioBackend() audio.Backend {
+	if AudioBackend != nil {
+		return AudioBackend
+	}
+	if render.Headless {
+		return audio.NullBackend{}
+	}
+	b, err := audio.NewCommandBackend()
+	if err != nil {
+		log.Printf("Sounds disabled: %s", err)
+		return audio.NullBackend{}
+	}
+	return b
+}
+
+// loadSounds reloads the sound events from the resource packs.
+func loadSounds() {
+	if err := soundManager.LoadSounds(); err != nil {
+		log.Printf("Loading sounds: %s", err)
+	}
+}
+
+// updateSoundVolumes copies the volumes from the config to the
+// sound manager.
+func updateSoundVolumes() {
+	for _, c := range audio.Categories {
+		v, ok := Config.Sound.Volumes[string(c)]
+		if !ok {
+			v = 1
+		}
+		soundManager.SetVolume(c, v)
+	}
+}
+
+// tickAudio moves the listener to the camera.
+func tickAudio() {
+	soundManager.SetListener(render.Camera.X, render.Camera.Y, render.Camera.Z)
+}
+
+// handleSoundEffect plays a sound sent by the server.
+func handleSoundEffect(p *protocol.SoundEffect) {
+	// The position is in 1/8ths of a block and the pitch
+	// is scaled so that 63 is normal speed
+	soundManager.Play(p.Name,
+		float64(p.X)/8, float64(p.Y)/8, float64(p.Z)/8,
+		float64(p.Volume), float64(p.Pitch)/63,
+	)
+}
+
+// effectSounds maps Effect packet ids to the sound they play and
+// its volume.
+var effectSounds = map[int32]struct {
+	name   string
+	volume float64
+}{
+	1007: {"mob.ghast.charge", 10},
+	1008: {"mob.ghast.fireball", 10},
+	1009: {"mob.ghast.fireball", 2},
+	1010: {"mob.zombie.wood", 2},
+	1011: {"mob.zombie.metal", 2},
+	1012: {"mob.zombie.woodbreak", 2},
+	1014: {"mob.wither.shoot", 2},
+	1015: {"mob.bat.takeoff", 0.05},
+	1016: {"mob.zombie.infect", 2},
+	1017: {"mob.zombie.unfect", 2},
+	1020: {"random.anvil_break", 1},
+	1021: {"random.anvil_use", 1},
+	1022: {"random.anvil_land", 0.3},
+}
+
+// playEffectSound plays the sound for an Effect packet, returns
+// false if the effect doesn't have a sound.
+func playEffectSound(p *protocol.Effect) bool {
+	x := float64(p.Location.X()) + 0.5
+	y := float64(p.Location.Y()) + 0.5
+	z := float64(p.Location.Z()) + 0.5
+	play := soundManager.Play
+	if p.DisableRelative {
+		play = func(name string, _, _, _, volume, pitch float64) bool {
+			return soundManager.PlayGlobal(name, volume, pitch)
+		}
+	}
+	switch p.EffectID {
+	case 1000: // Dispenser
+		play("random.click", x, y, z, 1, 1)
+	case 1001: // Dispenser failed
+		play("random.click", x, y, z, 1, 1.2)
+	case 1002: // Dispenser shoot
+		play("random.bow", x, y, z, 1, 1.2)
+	case 1003: // Door
+		name := "random.door_open"
+		if rand.Intn(2) == 0 {
+			name = "random.door_close"
+		}
+		play(name, x, y, z, 1, rand.Float64()*0.1+0.9)
+	case 1004: // Fire extinguished
+		play("random.fizz", x, y, z, 0.5, 2.6+(rand.Float64()-rand.Float64())*0.8)
+	case 1005: // Jukebox, the data is the record's item id
+		// Records are items so anything lower is either
+		// stopping the record or invalid
+		if p.Data < 256 {
+			return true
+		}
+		it := ItemById(int(p.Data))
+		if it == nil || !strings.HasPrefix(it.Name(), "record_") {
+			return true
+		}
+		play("records."+strings.TrimPrefix(it.Name(), "record_"), x, y, z, 4, 1)
+	default:
+		s, ok := effectSounds[p.EffectID]
+		if !ok {
+			return false
+		}
+		pitch := (rand.Float64()-rand.Float64())*0.2 + 1
+		if p.EffectID >= 1020 {
+			pitch = rand.Float64()*0.1 + 0.9
+		}
+		play(s.name, x, y, z, s.volume, pitch)
+	}
+	return true
+}
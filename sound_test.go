@@ -0,0 +1,58 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steven
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/thinkofdeath/steven/audio"
+	"github.com/thinkofdeath/steven/render"
+)
+
+func TestInitAudioBackend(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake player is a shell script")
+	}
+	// A fake player so the test doesn't depend on what is
+	// installed
+	dir, err := ioutil.TempDir("", "steven-audio")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "mpv"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", dir)
+	defer func(h bool) { render.Headless = h }(render.Headless)
+	defer func() { soundManager = nil }()
+
+	render.Headless = false
+	initAudio()
+	if b, ok := soundManager.Backend().(*audio.CommandBackend); !ok || b.Player() != "mpv" {
+		t.Errorf("desktop client got backend %#v, wanted mpv", soundManager.Backend())
+	}
+
+	render.Headless = true
+	initAudio()
+	if _, ok := soundManager.Backend().(audio.NullBackend); !ok {
+		t.Errorf("headless client got backend %#v", soundManager.Backend())
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"os"
 	"runtime"
 	"time"
 
@@ -29,9 +30,12 @@ import (
 )
 
 var (
-	profile   mojang.Profile
-	server    string
-	connected bool
+	profile mojang.Profile
+	server  string
+	// The protocol version the server was last seen using,
+	// 0 if it isn't known
+	serverProtocol int
+	connected      bool
 
 	stevenBuildVersion string = "dev"
 )
@@ -54,6 +58,7 @@ func Main(username, uuid, accessToken, s string) {
 		resource.LoadZip(pck)
 	}
 	loadBiomes()
+	initAudio()
 
 	setUIScale()
 
@@ -64,15 +69,43 @@ func connect() {
 	initClient()
 	connected = true
 	disconnectReason.Value = nil
-	Client.network.Connect(profile, server)
+	if Config.Game.ChunkCache != "" {
+		Client.chunkCache = newChunkCache(server)
+	}
+	Client.network.Connect(profile, server, serverProtocol)
 	server = ""
+	serverProtocol = 0
+}
+
+// replay plays back a recording made by setting STEVEN_RECORD
+// instead of connecting to a server.
+func replay(path string) {
+	initClient()
+	connected = true
+	disconnectReason.Value = nil
+	Client.network.Replay(path)
+}
+
+// view shows a world saved by the chunk cache instead of connecting
+// to a server.
+func view(path string) {
+	initClient()
+	connected = true
+	disconnectReason.Value = nil
+	Client.network.View(path)
 }
 
 func start() {
 	render.LoadTextures()
 	initBlocks()
 
-	if profile.IsComplete() && server != "" {
+	if path := os.Getenv("STEVEN_REPLAY"); path != "" {
+		replay(path)
+	} else if path := os.Getenv("STEVEN_VIEW"); path != "" {
+		view(path)
+	} else if profile.Username != "" && server != "" {
+		// Profiles without an access token can still join servers
+		// in offline mode
 		connect()
 	} else {
 		initClient()
@@ -121,6 +154,10 @@ handle:
 
 			Client.network.Close()
 			log.Printf("Disconnected: %s\n", err)
+			if Client.chunkCache != nil {
+				Client.chunkCache.close()
+				Client.chunkCache = nil
+			}
 			// Reset the ready state to stop packets from being
 			// sent.
 			ready = false
@@ -135,18 +172,38 @@ handle:
 				Client.entities.container.RemoveEntity(Client.entity)
 			}
 
-			setScreen(newServerList())
+			showMenu()
 		default:
 			break handle
 		}
 	}
 }
 
-func draw() {
+// showMenu shows the server list after disconnecting, or the login
+// screen if there isn't a player to join as (e.g. after watching a
+// replay). Bots have no screens so nothing is shown for them.
+func showMenu() {
+	switch {
+	case render.Headless:
+	case profile.Username == "":
+		setScreen(newLoginScreen())
+	default:
+		setScreen(newServerList())
+	}
+}
+
+// frameDelta returns the time since the last frame, scaled so that
+// 1.0 is a frame at 60fps.
+func frameDelta() float64 {
 	now := time.Now()
 	diff := now.Sub(lastFrame)
 	lastFrame = now
-	delta := float64(diff.Nanoseconds()) / (float64(time.Second) / 60)
+	return float64(diff.Nanoseconds()) / (float64(time.Second) / 60)
+}
+
+// handleEvents processes the packets and sync requests that are
+// waiting to be handled.
+func handleEvents() {
 handle:
 	for {
 		select {
@@ -165,6 +222,21 @@ handle:
 			break handle
 		}
 	}
+}
+
+// tickClient updates the client and runs the game tick if it is due.
+func tickClient(delta float64) {
+	Client.renderTick(delta)
+	select {
+	case <-ticker.C:
+		tick()
+	default:
+	}
+}
+
+func draw() {
+	delta := frameDelta()
+	handleEvents()
 	handleErrors()
 
 	width, height := window.GetFramebufferSize()
@@ -174,12 +246,7 @@ handle:
 	}
 
 	if ready && Client != nil {
-		Client.renderTick(delta)
-		select {
-		case <-ticker.C:
-			tick()
-		default:
-		}
+		tickClient(delta)
 	} else {
 		render.Camera.Yaw += 0.005 * delta
 		if render.Camera.Yaw > math.Pi*2 {
@@ -0,0 +1,171 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steven
+
+import "github.com/thinkofdeath/steven/protocol"
+
+// Legacy formatting codes for the 16 colors in the order used by
+// the protocol.
+const legacyColorCodes = "0123456789abcdef"
+
+const (
+	nameTagAlways            = "always"
+	nameTagNever             = "never"
+	nameTagHideForOtherTeams = "hideForOtherTeams"
+	nameTagHideForOwnTeam    = "hideForOwnTeam"
+)
+
+type team struct {
+	name        string
+	displayName string
+	prefix      string
+	suffix      string
+
+	friendlyFire         bool
+	seeFriendlyInvisible bool
+	nameTagVisibility    string
+	// -1 if the team has no color
+	color int
+
+	players map[string]struct{}
+}
+
+// teams tracks the teams on the server and the players on them.
+type teams struct {
+	teams map[string]*team
+	// Players mapped to the team they are on
+	players map[string]*team
+}
+
+func (t *teams) init() {
+	t.teams = map[string]*team{}
+	t.players = map[string]*team{}
+}
+
+// update handles a change to a team.
+func (t *teams) update(p *protocol.Teams) {
+	switch p.Mode {
+	case 0: // Create
+		t.remove(p.Name)
+		t.teams[p.Name] = &team{
+			name:    p.Name,
+			players: map[string]struct{}{},
+		}
+		fallthrough
+	case 2: // Update info
+		tm, ok := t.teams[p.Name]
+		if !ok {
+			return
+		}
+		tm.displayName = p.DisplayName
+		tm.prefix = p.Prefix
+		tm.suffix = p.Suffix
+		tm.friendlyFire = p.Flags&0x1 != 0
+		tm.seeFriendlyInvisible = p.Flags&0x2 != 0
+		tm.nameTagVisibility = p.NameTagVisibility
+		tm.color = int(int8(p.Color))
+		if p.Mode == 0 {
+			t.addPlayers(tm, p.Players)
+		}
+	case 1: // Remove
+		t.remove(p.Name)
+	case 3: // Add players
+		if tm, ok := t.teams[p.Name]; ok {
+			t.addPlayers(tm, p.Players)
+		}
+	case 4: // Remove players
+		tm, ok := t.teams[p.Name]
+		if !ok {
+			return
+		}
+		for _, pl := range p.Players {
+			delete(tm.players, pl)
+			if t.players[pl] == tm {
+				delete(t.players, pl)
+			}
+		}
+	}
+	// Team names are shown on the sidebar
+	Client.scoreboard.dirty = true
+}
+
+func (t *teams) addPlayers(tm *team, players []string) {
+	for _, pl := range players {
+		// A player can only be on a single team
+		if old, ok := t.players[pl]; ok {
+			delete(old.players, pl)
+		}
+		tm.players[pl] = struct{}{}
+		t.players[pl] = tm
+	}
+}
+
+func (t *teams) remove(name string) {
+	tm, ok := t.teams[name]
+	if !ok {
+		return
+	}
+	for pl := range tm.players {
+		delete(t.players, pl)
+	}
+	delete(t.teams, name)
+}
+
+// formatName returns the player's name with their team's prefix and
+// suffix. The result may contain legacy formatting codes.
+func (t *teams) formatName(name string) string {
+	tm := t.players[name]
+	if tm == nil {
+		return name
+	}
+	return tm.prefix + name + tm.suffix
+}
+
+// nameTag returns the text of the name tag to display above the
+// player or an empty string if the team's rules hide it from the
+// client.
+func (t *teams) nameTag(name string) string {
+	tm := t.players[name]
+	if tm == nil {
+		return name
+	}
+	own := t.players[profile.Username]
+	switch tm.nameTagVisibility {
+	case nameTagNever:
+		return ""
+	case nameTagHideForOtherTeams:
+		if own != nil && own != tm {
+			return ""
+		}
+	case nameTagHideForOwnTeam:
+		if own != nil && own == tm {
+			return ""
+		}
+	}
+	tag := tm.prefix + name + tm.suffix
+	if tm.color >= 0 && tm.color < len(legacyColorCodes) {
+		tag = "§" + legacyColorCodes[tm.color:tm.color+1] + tag
+	}
+	return tag
+}
+
+// sidebarColor returns the color of the client's team which selects
+// the team specific sidebar, -1 if there isn't one.
+func (t *teams) sidebarColor() int {
+	if tm := t.players[profile.Username]; tm != nil && tm.color >= 0 && tm.color < len(legacyColorCodes) {
+		return tm.color
+	}
+	return -1
+}
@@ -0,0 +1,120 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steven
+
+import (
+	"github.com/thinkofdeath/steven/chat"
+	"github.com/thinkofdeath/steven/protocol"
+	"github.com/thinkofdeath/steven/ui"
+	"github.com/thinkofdeath/steven/ui/scene"
+)
+
+// Default title timings in ticks
+const (
+	titleFadeIn  = 10
+	titleStay    = 70
+	titleFadeOut = 20
+)
+
+// titleUI displays the large title and subtitle in the middle of
+// the screen.
+type titleUI struct {
+	scene    *scene.Type
+	title    *ui.Formatted
+	subTitle *ui.Formatted
+
+	// Timings in ticks
+	fadeIn, stay, fadeOut float64
+	// Ticks until the title is hidden, 0 when not displayed
+	timer float64
+}
+
+func (t *titleUI) init() {
+	t.scene = scene.New(true)
+	t.title = ui.NewFormatted(chat.AnyComponent{Value: &chat.TextComponent{}}, 0, -90)
+	t.title.SetScaleX(4)
+	t.title.SetScaleY(4)
+	t.title.SetDraw(false)
+	t.scene.AddDrawable(t.title.Attach(ui.Middle, ui.Center))
+	t.subTitle = ui.NewFormatted(chat.AnyComponent{Value: &chat.TextComponent{}}, 0, 10)
+	t.subTitle.SetScaleX(2)
+	t.subTitle.SetScaleY(2)
+	t.subTitle.SetDraw(false)
+	t.scene.AddDrawable(t.subTitle.Attach(ui.Middle, ui.Center))
+	t.resetTimes()
+}
+
+func (t *titleUI) free() {
+	t.scene.Hide()
+}
+
+func (t *titleUI) resetTimes() {
+	t.fadeIn, t.stay, t.fadeOut = titleFadeIn, titleStay, titleFadeOut
+}
+
+// update handles a Title packet.
+func (t *titleUI) update(p *protocol.Title) {
+	switch p.Action {
+	case 0: // Set title
+		Client.scoreboard.resolveScores(p.Title)
+		chat.ConvertLegacy(p.Title)
+		t.title.Update(p.Title)
+		t.timer = t.fadeIn + t.stay + t.fadeOut
+	case 1: // Set subtitle
+		Client.scoreboard.resolveScores(p.SubTitle)
+		chat.ConvertLegacy(p.SubTitle)
+		t.subTitle.Update(p.SubTitle)
+	case 2: // Set times
+		t.fadeIn, t.stay, t.fadeOut = float64(p.FadeIn), float64(p.FadeStay), float64(p.FadeOut)
+	case 3: // Clear
+		t.clear()
+	case 4: // Reset
+		t.clear()
+		t.resetTimes()
+	}
+}
+
+// clear hides the title and drops its text so that it isn't shown
+// again by a later update.
+func (t *titleUI) clear() {
+	t.timer = 0
+	t.title.Update(chat.AnyComponent{Value: &chat.TextComponent{}})
+	t.subTitle.Update(chat.AnyComponent{Value: &chat.TextComponent{}})
+}
+
+func (t *titleUI) render(delta float64) {
+	if t.timer <= 0 {
+		t.timer = 0
+		t.title.SetDraw(false)
+		t.subTitle.SetDraw(false)
+		return
+	}
+	// delta is in 60ths of a second, timings are in ticks
+	t.timer -= delta / 3
+
+	alpha := 1.0
+	elapsed := t.fadeIn + t.stay + t.fadeOut - t.timer
+	switch {
+	case elapsed < t.fadeIn:
+		alpha = elapsed / t.fadeIn
+	case t.timer < t.fadeOut:
+		alpha = t.timer / t.fadeOut
+	}
+	a := int(alpha * 255)
+	t.title.SetA(a)
+	t.subTitle.SetA(a)
+	t.title.SetDraw(true)
+	t.subTitle.SetDraw(true)
+}
@@ -29,6 +29,7 @@ type Formatted struct {
 	x, y           float64
 	MaxWidth       float64
 	scaleX, scaleY float64
+	a              int
 
 	Width, Height float64
 	Lines         int
@@ -41,6 +42,7 @@ func NewFormatted(val chat.AnyComponent, x, y float64) *Formatted {
 	f := &Formatted{
 		x: x, y: y,
 		scaleX: 1, scaleY: 1,
+		a:        255,
 		MaxWidth: -1,
 		baseElement: baseElement{
 			visible: true,
@@ -56,6 +58,7 @@ func NewFormattedWidth(val chat.AnyComponent, x, y, width float64) *Formatted {
 	f := &Formatted{
 		x: x, y: y,
 		scaleX: 1, scaleY: 1,
+		a:        255,
 		MaxWidth: width,
 		baseElement: baseElement{
 			visible: true,
@@ -100,6 +103,22 @@ func (f *Formatted) SetScaleY(s float64) {
 		f.dirty = true
 	}
 }
+func (f *Formatted) A() int { return f.a }
+func (f *Formatted) SetA(a int) {
+	if a > 255 {
+		a = 255
+	}
+	if a < 0 {
+		a = 0
+	}
+	if f.a != a {
+		f.a = a
+		for _, t := range f.Text {
+			t.SetA(a)
+		}
+		f.dirty = true
+	}
+}
 
 // Draw draws this to the target region.
 func (f *Formatted) Draw(r Region, delta float64) {
@@ -195,6 +214,12 @@ func (f *formatState) build(c chat.AnyComponent, color getColorFunc) {
 				f.build(c.With[part], gc)
 			}
 		}
+	case *chat.ScoreComponent:
+		gc := getColor(&c.Component, color)
+		f.appendText(c.Score.Value, gc)
+		for _, e := range c.Extra {
+			f.build(e, gc)
+		}
 
 	default:
 		panic(fmt.Sprintf("unhandled component: %T", c))
@@ -209,6 +234,7 @@ func (f *formatState) appendText(text string, color getColorFunc) {
 		if (f.f.MaxWidth > 0 && f.offset+width+s > f.f.MaxWidth) || r == '\n' {
 			rr, gg, bb := colorRGB(color())
 			txt := NewText(text[last:i], f.offset, float64(f.lines*18+1), rr, gg, bb)
+			txt.a = f.f.a
 			txt.AttachTo(f.f)
 			last = i
 			if r == '\n' {
@@ -227,6 +253,7 @@ func (f *formatState) appendText(text string, color getColorFunc) {
 	if last != len(text) {
 		r, g, b := colorRGB(color())
 		txt := NewText(text[last:], f.offset, float64(f.lines*18+1), r, g, b)
+		txt.a = f.f.a
 		txt.AttachTo(f.f)
 		f.f.Text = append(f.f.Text, txt)
 		f.offset += txt.Width + 2
@@ -0,0 +1,489 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steven
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-gl/glfw/v3.1/glfw"
+	"github.com/thinkofdeath/steven/chat"
+	"github.com/thinkofdeath/steven/protocol"
+	"github.com/thinkofdeath/steven/render"
+	"github.com/thinkofdeath/steven/ui"
+	"github.com/thinkofdeath/steven/ui/scene"
+)
+
+// Time between two clicks for them to count as a double click
+const doubleClickTime = 250 * time.Millisecond
+
+// Returned by slotAt when the position is inside the window but not
+// over a slot
+const noSlot = -1
+
+type containerScreen struct {
+	scene *scene.Type
+	// Items and anything else that changes with the window's
+	// contents
+	itemScene    *scene.Type
+	cursorScene  *scene.Type
+	tooltipScene *scene.Type
+	previewScene *scene.Type
+
+	win *containerWindow
+
+	background *ui.Image
+	frame      *ui.Container
+	highlight  *ui.Image
+	cursorIcon *ui.Container
+	lastCursor *ItemStack
+
+	tooltip      *ui.Formatted
+	tooltipBack  *ui.Image
+	tooltipItem  *ItemStack
+	previewX     float64
+	previewY     float64
+	previewValid bool
+
+	// Last position of the mouse in framebuffer pixels
+	mouseX, mouseY float64
+	width, height  int
+	hovered        int
+
+	// Slot and button of the last press, used for double clicks and
+	// drags that only cover a single slot
+	pressSlot   int
+	pressButton int
+	lastPress   time.Time
+}
+
+func newContainerScreen(w *containerWindow) *containerScreen {
+	cs := &containerScreen{
+		scene:        scene.New(true),
+		itemScene:    scene.New(true),
+		cursorScene:  scene.New(true),
+		tooltipScene: scene.New(true),
+		previewScene: scene.New(true),
+		win:          w,
+		hovered:      noSlot,
+		pressSlot:    noSlot,
+	}
+	l := w.layout
+
+	cs.background = ui.NewImage(render.GetTexture("solid"), 0, 0, 854, 480, 0, 0, 1, 1, 0, 0, 0)
+	cs.background.SetA(160)
+	cs.scene.AddDrawable(cs.background.Attach(ui.Top, ui.Left))
+
+	cs.frame = ui.NewContainer(0, 0, l.width*2, l.height*2).Attach(ui.Middle, ui.Center)
+	tex := render.GetTexture(l.texture)
+	if l.split > 0 {
+		top := ui.NewImage(tex, 0, 0, l.width*2, l.split*2, 0, 0, l.width/256, l.split/256, 255, 255, 255)
+		top.AttachTo(cs.frame)
+		cs.scene.AddDrawable(top.Attach(ui.Top, ui.Left))
+		bottom := ui.NewImage(tex, 0, l.split*2, l.width*2, 96*2, 0, 126.0/256, l.width/256, 96.0/256, 255, 255, 255)
+		bottom.AttachTo(cs.frame)
+		cs.scene.AddDrawable(bottom.Attach(ui.Top, ui.Left))
+	} else {
+		img := ui.NewImage(tex, 0, 0, l.width*2, l.height*2, 0, 0, l.width/256, l.height/256, 255, 255, 255)
+		img.AttachTo(cs.frame)
+		cs.scene.AddDrawable(img.Attach(ui.Top, ui.Left))
+	}
+
+	if w.id == 0 {
+		// The player's inventory only labels the crafting grid
+		title := ui.NewFormatted(grayComponent(w.title), 86*2, 16*2).Attach(ui.Top, ui.Left)
+		title.AttachTo(cs.frame)
+		cs.scene.AddDrawable(title)
+	} else {
+		title := ui.NewFormatted(grayComponent(w.title), 16, 12).Attach(ui.Top, ui.Left)
+		title.AttachTo(cs.frame)
+		cs.scene.AddDrawable(title)
+		inv := ui.NewFormatted(grayComponent(chat.AnyComponent{Value: &chat.TranslateComponent{
+			Translate: "container.inventory",
+		}}), l.playerX*2, (l.playerY-11)*2).Attach(ui.Top, ui.Left)
+		inv.AttachTo(cs.frame)
+		cs.scene.AddDrawable(inv)
+	}
+
+	cs.highlight = ui.NewImage(render.GetTexture("solid"), 0, 0, 32, 32, 0, 0, 1, 1, 255, 255, 255)
+	cs.highlight.SetA(80)
+	cs.highlight.SetLayer(1)
+	cs.highlight.SetDraw(false)
+	cs.highlight.AttachTo(cs.frame)
+	cs.scene.AddDrawable(cs.highlight.Attach(ui.Top, ui.Left))
+	return cs
+}
+
+// grayComponent wraps the component so that it is drawn in dark gray
+// unless it sets its own color.
+func grayComponent(c chat.AnyComponent) chat.AnyComponent {
+	return chat.AnyComponent{Value: &chat.TextComponent{
+		Component: chat.Component{
+			Color: chat.DarkGray,
+			Extra: []chat.AnyComponent{c},
+		},
+	}}
+}
+
+func (cs *containerScreen) init() {
+	window.SetKeyCallback(cs.handleKey)
+}
+
+func (cs *containerScreen) tick(delta float64) {
+	width, height := window.GetFramebufferSize()
+	cs.background.SetWidth(float64(width) / ui.Scale)
+	cs.background.SetHeight(float64(height) / ui.Scale)
+
+	if cs.win.dirty {
+		cs.win.dirty = false
+		cs.rebuild()
+	}
+	if Client.cursorItem != cs.lastCursor {
+		cs.rebuildCursor()
+	}
+	if cs.cursorIcon != nil {
+		if x, y, ok := ui.Intersects(cs.background, cs.mouseX, cs.mouseY, cs.width, cs.height); ok {
+			cs.cursorIcon.SetX(x - 16)
+			cs.cursorIcon.SetY(y - 16)
+		}
+	}
+
+	if cs.hovered >= 0 {
+		x, y := cs.slotPosition(cs.hovered)
+		cs.highlight.SetX(x * 2)
+		cs.highlight.SetY(y * 2)
+		cs.highlight.SetDraw(true)
+	} else {
+		cs.highlight.SetDraw(false)
+	}
+	cs.updateTooltip()
+	if cs.win.id == 0 {
+		cs.updatePreview()
+	}
+}
+
+// updateTooltip shows the name and lore of the hovered item next to
+// the mouse.
+func (cs *containerScreen) updateTooltip() {
+	var item *ItemStack
+	if cs.hovered >= 0 && Client.cursorItem == nil {
+		item = cs.win.items[cs.hovered]
+	}
+	if item != cs.tooltipItem {
+		cs.tooltipItem = item
+		cs.tooltipScene.Hide()
+		cs.tooltipScene = scene.New(true)
+		cs.tooltip = nil
+		if item == nil {
+			return
+		}
+		lines := []chat.AnyComponent{item.displayName()}
+		for _, l := range item.lore() {
+			lines = append(lines, chat.AnyComponent{Value: &chat.TextComponent{Text: "\n"}}, l)
+		}
+		cs.tooltip = ui.NewFormatted(chat.AnyComponent{Value: &chat.TextComponent{
+			Component: chat.Component{Extra: lines},
+		}}, 0, 0).Attach(ui.Top, ui.Left)
+		cs.tooltip.AttachTo(cs.background)
+		for _, t := range cs.tooltip.Text {
+			t.SetLayer(3)
+		}
+		w, h := cs.tooltip.Size()
+		cs.tooltipBack = ui.NewImage(render.GetTexture("solid"), -6, -6, w+12, h+12, 0, 0, 1, 1, 16, 0, 16)
+		cs.tooltipBack.SetA(240)
+		cs.tooltipBack.SetLayer(3)
+		cs.tooltipBack.AttachTo(cs.tooltip)
+		cs.tooltipScene.AddDrawable(cs.tooltipBack.Attach(ui.Top, ui.Left))
+		cs.tooltipScene.AddDrawable(cs.tooltip)
+	}
+	if cs.tooltip == nil {
+		return
+	}
+	if x, y, ok := ui.Intersects(cs.background, cs.mouseX, cs.mouseY, cs.width, cs.height); ok {
+		cs.tooltip.SetX(x + 24)
+		cs.tooltip.SetY(y - 24)
+	}
+}
+
+// updatePreview rebuilds the model of the player shown in the
+// inventory so that it faces the mouse.
+func (cs *containerScreen) updatePreview() {
+	x, y, ok := cs.localPosition(cs.mouseX, cs.mouseY, cs.width, cs.height)
+	if !ok {
+		// Look straight ahead
+		x, y = 51, 25
+	}
+	if cs.previewValid && x == cs.previewX && y == cs.previewY {
+		return
+	}
+	cs.previewX, cs.previewY, cs.previewValid = x, y, true
+
+	var skin render.TextureInfo
+	if info := Client.playerList.info[Client.entity.UUID()]; info != nil {
+		skin = info.skin
+	} else {
+		skin = render.RelativeTexture(render.GetTexture("entity/steve"), 64, 64)
+	}
+	// Relative to the player's head
+	dx, dy := x-51, y-25
+	cs.previewScene.Hide()
+	cs.previewScene = scene.New(true)
+	mdl := playerModelToUI(skin, math.Atan(dx/40)*0.5, -math.Atan(dy/40)*0.5)
+	mdl.SetX(51*2 - 16)
+	mdl.SetY(43*2 - 16)
+	mdl.AttachTo(cs.frame)
+	cs.previewScene.AddDrawable(mdl.Attach(ui.Top, ui.Left))
+}
+
+// slotPosition returns the position of the slot in the window's
+// texture.
+func (cs *containerScreen) slotPosition(slot int) (float64, float64) {
+	l := cs.win.layout
+	if slot < cs.win.size {
+		p := l.slots[slot]
+		return p.x, p.y
+	}
+	slot -= cs.win.size
+	if slot >= playerMainSlots {
+		return l.playerX + float64(slot-playerMainSlots)*18, l.playerY + 58
+	}
+	return l.playerX + float64(slot%9)*18, l.playerY + float64(slot/9)*18
+}
+
+// slotAt returns the slot at the position, noSlot if there isn't one
+// or slotOutside if the position is outside of the window.
+func (cs *containerScreen) slotAt(x, y float64, w, h int) int {
+	ox, oy, ok := cs.localPosition(x, y, w, h)
+	if !ok {
+		return slotOutside
+	}
+	for i := range cs.win.items {
+		sx, sy := cs.slotPosition(i)
+		if ox >= sx-1 && ox < sx+17 && oy >= sy-1 && oy < sy+17 {
+			return i
+		}
+	}
+	return noSlot
+}
+
+// localPosition converts the position into the window's texture
+// coordinates.
+func (cs *containerScreen) localPosition(x, y float64, w, h int) (float64, float64, bool) {
+	ox, oy, ok := ui.Intersects(cs.frame, x, y, w, h)
+	return ox / 2, oy / 2, ok
+}
+
+func (cs *containerScreen) rebuild() {
+	cs.itemScene.Hide()
+	cs.itemScene = scene.New(true)
+	for i, item := range cs.win.items {
+		if item == nil {
+			continue
+		}
+		x, y := cs.slotPosition(i)
+		icon := createItemIcon(item, cs.itemScene, x*2, y*2).Attach(ui.Top, ui.Left)
+		icon.AttachTo(cs.frame)
+	}
+	cs.drawProgress()
+	// Keep the held item above the window's items
+	cs.rebuildCursor()
+}
+
+func (cs *containerScreen) rebuildCursor() {
+	cs.cursorScene.Hide()
+	cs.cursorScene = scene.New(true)
+	cs.cursorIcon = nil
+	cs.lastCursor = Client.cursorItem
+	if Client.cursorItem == nil {
+		return
+	}
+	cs.cursorIcon = createItemIcon(Client.cursorItem, cs.cursorScene, 0, 0).Attach(ui.Top, ui.Left)
+	cs.cursorIcon.AttachTo(cs.background)
+}
+
+// drawProgress draws the parts of the window controlled by the
+// window's properties.
+func (cs *containerScreen) drawProgress() {
+	w := cs.win
+	tex := render.GetTexture(w.layout.texture)
+	image := func(x, y, tx, ty, width, height float64) {
+		img := ui.NewImage(tex, x*2, y*2, width*2, height*2, tx/256, ty/256, width/256, height/256, 255, 255, 255)
+		img.AttachTo(cs.frame)
+		cs.itemScene.AddDrawable(img.Attach(ui.Top, ui.Left))
+	}
+	switch w.ty {
+	case "minecraft:furnace":
+		// Fuel left, fuel total, cook progress and cook total
+		if burn, total := w.properties[0], w.properties[1]; burn > 0 {
+			if total == 0 {
+				total = 200
+			}
+			k := float64(int(burn) * 13 / int(total))
+			image(56, 36+12-k, 176, 12-k, 14, k+1)
+		}
+		if cook, total := w.properties[2], w.properties[3]; cook > 0 && total > 0 {
+			image(79, 34, 176, 14, float64(int(cook)*24/int(total))+1, 16)
+		}
+	case "minecraft:brewing_stand":
+		if t := w.properties[0]; t > 0 {
+			image(97, 16, 176, 0, 9, float64(28*(400-int(t))/400))
+		}
+	case "minecraft:enchanting_table":
+		// The level required for each of the three options
+		for i := int16(0); i < 3; i++ {
+			y := 14 + 19*float64(i)
+			level := w.properties[i]
+			if level <= 0 {
+				image(60, y, 0, 185, 108, 19)
+				continue
+			}
+			image(60, y, 0, 166, 108, 19)
+			txt := ui.NewText(fmt.Sprint(level), (176-60-108+2)*2, (y+10)*2-9, 128, 255, 32).
+				Attach(ui.Top, ui.Right)
+			txt.AttachTo(cs.frame)
+			txt.SetLayer(1)
+			cs.itemScene.AddDrawable(txt)
+		}
+	case "minecraft:anvil":
+		if cost := w.properties[0]; cost > 0 {
+			txt := ui.NewText(fmt.Sprintf("Enchantment Cost: %d", cost), 16, 134, 128, 255, 32).
+				Attach(ui.Top, ui.Right)
+			txt.AttachTo(cs.frame)
+			cs.itemScene.AddDrawable(txt)
+		}
+	}
+}
+
+func (cs *containerScreen) hover(x, y float64, w, h int) {
+	cs.mouseX, cs.mouseY = x, y
+	cs.width, cs.height = w, h
+	cs.hovered = cs.slotAt(x, y, w, h)
+	if cs.win.dragButton != -1 {
+		cs.win.dragOver(cs.hovered)
+	}
+}
+
+// click isn't used, mouseClick handles every button.
+func (cs *containerScreen) click(down bool, x, y float64, w, h int) {}
+
+func (cs *containerScreen) mouseClick(button glfw.MouseButton, down bool, mods glfw.ModifierKey, x, y float64, w, h int) {
+	win := cs.win
+	slot := cs.slotAt(x, y, w, h)
+	switch button {
+	case glfw.MouseButtonLeft, glfw.MouseButtonRight:
+	case glfw.MouseButtonMiddle:
+		if down && slot >= 0 {
+			win.middleClick(slot)
+		}
+		return
+	default:
+		return
+	}
+	btn := 0
+	if button == glfw.MouseButtonRight {
+		btn = 1
+	}
+
+	if !down {
+		if win.dragButton != -1 && !win.endDrag() && cs.pressSlot >= 0 {
+			win.click(cs.pressSlot, byte(cs.pressButton))
+		}
+		return
+	}
+
+	now := time.Now()
+	double := btn == 0 && slot == cs.pressSlot && now.Sub(cs.lastPress) < doubleClickTime
+	cs.pressSlot, cs.pressButton, cs.lastPress = slot, btn, now
+
+	switch {
+	case slot == noSlot:
+		if btn == 0 {
+			cs.clickEnchantment(x, y, w, h)
+		}
+	case slot == slotOutside:
+		win.click(slotOutside, byte(btn))
+	case mods&glfw.ModShift != 0:
+		win.shiftClick(slot, byte(btn))
+	case double && Client.cursorItem != nil:
+		win.collect(slot)
+		// Stops a third click counting as another double click
+		cs.lastPress = time.Time{}
+	case Client.cursorItem != nil:
+		// Placing is done when the button is released so that
+		// the item can be dragged over multiple slots
+		win.startDrag(btn)
+		win.dragOver(slot)
+	default:
+		win.click(slot, byte(btn))
+	}
+}
+
+// clickEnchantment picks one of the enchanting table's options if
+// one was clicked.
+func (cs *containerScreen) clickEnchantment(x, y float64, w, h int) {
+	if cs.win.ty != "minecraft:enchanting_table" {
+		return
+	}
+	ox, oy, _ := cs.localPosition(x, y, w, h)
+	for i := int16(0); i < 3; i++ {
+		top := 14 + 19*float64(i)
+		if ox < 60 || ox >= 60+108 || oy < top || oy >= top+19 {
+			continue
+		}
+		if cs.win.properties[i] > 0 {
+			Client.network.Write(&protocol.EnchantItem{ID: cs.win.id, Enchantment: byte(i)})
+		}
+		return
+	}
+}
+
+func (cs *containerScreen) handleKey(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+	if key == glfw.KeyEscape || key == glfw.KeyE {
+		// Closed on release so that the key isn't seen by the
+		// game once the window is gone
+		if action == glfw.Release {
+			Client.closeWindow(true)
+		}
+		return
+	}
+	if action != glfw.Press {
+		return
+	}
+	switch {
+	case key >= glfw.Key1 && key <= glfw.Key9:
+		if cs.hovered >= 0 {
+			cs.win.swapHotbar(cs.hovered, int(key-glfw.Key1))
+		}
+	case key == glfw.KeyQ:
+		if cs.hovered >= 0 {
+			cs.win.drop(cs.hovered, mods&glfw.ModControl != 0)
+		}
+	}
+}
+
+func (cs *containerScreen) remove() {
+	cs.scene.Hide()
+	cs.itemScene.Hide()
+	cs.cursorScene.Hide()
+	cs.tooltipScene.Hide()
+	cs.previewScene.Hide()
+	window.SetKeyCallback(onKey)
+	// Closed by something other than the server or the player,
+	// e.g. disconnecting
+	if Client.currentWindow == cs.win {
+		Client.forgetWindow(true)
+	}
+}
@@ -14,7 +14,10 @@
 
 package steven
 
-import "github.com/go-gl/glfw/v3.1/glfw"
+import (
+	"github.com/go-gl/glfw/v3.1/glfw"
+	"github.com/thinkofdeath/steven/render"
+)
 
 var currentScreen screen
 
@@ -26,7 +29,18 @@ type screen interface {
 	remove()
 }
 
+// mouseScreen is implemented by screens that need every mouse button
+// and the held modifier keys instead of just left clicks.
+type mouseScreen interface {
+	mouseClick(button glfw.MouseButton, down bool, mods glfw.ModifierKey, x, y float64, w, h int)
+}
+
 func setScreen(s screen) {
+	if render.Headless {
+		// Screens need a window, bots handle the cases they
+		// would be used for themselves (e.g. respawning)
+		return
+	}
 	if currentScreen != nil {
 		currentScreen.remove()
 	}
@@ -21,7 +21,9 @@ import (
 	"errors"
 	"fmt"
 	"image/png"
+	"log"
 	"math"
+	"path/filepath"
 	"strings"
 
 	"github.com/go-gl/glfw/v3.1/glfw"
@@ -52,6 +54,9 @@ type serverListItem struct {
 	container *ui.Container
 	offset    float64
 	id        string
+	// The protocol version reported by the server's ping,
+	// 0 until it replies
+	protocol int
 }
 
 func newServerList() screen {
@@ -76,6 +81,21 @@ func newServerList() screen {
 		setScreen(newEditServer(-1))
 	}
 
+	imp, txt := newButtonText("Import", 100, -50-15, 100, 30)
+	sl.scene.AddDrawable(imp.Attach(ui.Center, ui.Middle))
+	sl.scene.AddDrawable(txt)
+	imp.ClickFunc = func() {
+		path := filepath.Join(vanillaDirectory(), "servers.dat")
+		n, err := importVanillaServers(path)
+		if err != nil {
+			log.Printf("Failed to import servers from %s: %s\n", path, err)
+			return
+		}
+		if n > 0 {
+			sl.redraw()
+		}
+	}
+
 	options := ui.NewButton(5, 25, 40, 40)
 	sl.scene.AddDrawable(options.Attach(ui.Bottom, ui.Right))
 	cog := ui.NewImage(render.GetTexture("steven:gui/cog"), 0, 0, 40, 40, 0, 0, 1, 1, 255, 255, 255)
@@ -178,9 +198,9 @@ func (sl *serverList) redraw() {
 		motd.AttachTo(container)
 		sc.AddDrawable(motd)
 		s := s
-		go sl.pingServer(s.Address, motd, icon, si.id, ping, players)
+		go sl.pingServer(si, s.Address, motd, icon, ping, players)
 		container.ClickFunc = func() {
-			sl.connect(s.Address)
+			sl.connect(s.Address, si.protocol)
 		}
 		container.HoverFunc = func(over bool) {
 			if over {
@@ -212,8 +232,9 @@ func (sl *serverList) redraw() {
 	}
 }
 
-func (sl *serverList) pingServer(addr string, motd *ui.Formatted,
-	icon *ui.Image, id string, ping *ui.Image, players *ui.Text) {
+func (sl *serverList) pingServer(si *serverListItem, addr string, motd *ui.Formatted,
+	icon *ui.Image, ping *ui.Image, players *ui.Text) {
+	id := si.id
 	conn, err := protocol.Dial(addr)
 	if err != nil {
 		syncChan <- func() {
@@ -249,6 +270,7 @@ func (sl *serverList) pingServer(addr string, motd *ui.Formatted,
 			y = 56 / 256.0
 		}
 		ping.SetTextureY(y)
+		si.protocol = resp.Version.Protocol
 
 		players.Update(fmt.Sprintf("%d/%d", resp.Players.Online, resp.Players.Max))
 
@@ -274,8 +296,9 @@ func (sl *serverList) pingServer(addr string, motd *ui.Formatted,
 	}
 }
 
-func (sl *serverList) connect(s string) {
+func (sl *serverList) connect(s string, protocol int) {
 	server = s
+	serverProtocol = protocol
 	initClient()
 	connect()
 	setScreen(nil)
@@ -0,0 +1,78 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steven
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/thinkofdeath/steven/encoding/nbt"
+)
+
+// vanillaDirectory returns the directory the vanilla launcher installs
+// the game into for the current platform.
+func vanillaDirectory() string {
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(os.Getenv("APPDATA"), ".minecraft")
+	case "darwin":
+		return filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "minecraft")
+	}
+	return filepath.Join(os.Getenv("HOME"), ".minecraft")
+}
+
+type vanillaServers struct {
+	Servers []struct {
+		Name   string `nbt:"name"`
+		IP     string `nbt:"ip"`
+		Hidden bool   `nbt:"hidden,omitempty"`
+	} `nbt:"servers"`
+}
+
+// importVanillaServers adds the servers from vanilla's server list
+// (servers.dat) to the config skipping any that are already in it.
+// Returns the number of servers added.
+func importVanillaServers(path string) (int, error) {
+	root, _, err := nbt.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	var list vanillaServers
+	if err := nbt.Unmarshal(root, &list); err != nil {
+		return 0, err
+	}
+
+	known := map[string]bool{}
+	for _, s := range Config.Servers {
+		known[s.Address] = true
+	}
+	added := 0
+	for _, s := range list.Servers {
+		if s.Hidden || known[s.IP] {
+			continue
+		}
+		known[s.IP] = true
+		Config.Servers = append(Config.Servers, ConfigServer{
+			Name:    s.Name,
+			Address: s.IP,
+		})
+		added++
+	}
+	if added > 0 {
+		saveConfig()
+	}
+	return added, nil
+}
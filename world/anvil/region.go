@@ -0,0 +1,277 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package anvil implements reading and writing of the region (.mca) files
+// used by Minecraft to store worlds.
+package anvil
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/thinkofdeath/steven/encoding/nbt"
+)
+
+const (
+	sectorSize = 4096
+	// Number of chunks along each side of a region
+	regionSize = 32
+
+	compressionGzip = 1
+	compressionZlib = 2
+)
+
+var (
+	// ErrNoChunk is returned when reading a chunk that hasn't been
+	// saved to the region.
+	ErrNoChunk = errors.New("chunk not in region")
+	// ErrChunkTooLarge is returned when a chunk is too large to be
+	// stored in a region (1MB once compressed).
+	ErrChunkTooLarge = errors.New("chunk too large")
+)
+
+// RegionPosition returns the position of the region containing the chunk
+func RegionPosition(chunkX, chunkZ int) (x, z int) {
+	return chunkX >> 5, chunkZ >> 5
+}
+
+// RegionFile returns the standard file name for the region at the
+// position.
+func RegionFile(x, z int) string {
+	return fmt.Sprintf("r.%d.%d.mca", x, z)
+}
+
+// Region is an open region file which contains a 32x32 area of
+// chunk columns.
+type Region struct {
+	f *os.File
+
+	// offset << 8 | sector count
+	locations  [regionSize * regionSize]uint32
+	timestamps [regionSize * regionSize]uint32
+	// Whether each sector of the file is in use
+	used []bool
+}
+
+// OpenRegion opens the region file at the path creating it if it
+// doesn't exist.
+func OpenRegion(path string) (*Region, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
+	}
+	r := &Region{f: f}
+	if err := r.init(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Region) init() error {
+	info, err := r.f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < sectorSize*2 {
+		// New (or broken) region, write an empty header
+		if _, err := r.f.WriteAt(make([]byte, sectorSize*2), 0); err != nil {
+			return err
+		}
+		r.used = []bool{true, true}
+		return nil
+	}
+
+	buf := bufio.NewReader(io.NewSectionReader(r.f, 0, sectorSize*2))
+	if err := binary.Read(buf, binary.BigEndian, r.locations[:]); err != nil {
+		return err
+	}
+	if err := binary.Read(buf, binary.BigEndian, r.timestamps[:]); err != nil {
+		return err
+	}
+	r.used = make([]bool, (info.Size()+sectorSize-1)/sectorSize)
+	r.used[0], r.used[1] = true, true
+	for i, loc := range r.locations {
+		offset, count := int(loc>>8), int(loc&0xFF)
+		if loc == 0 {
+			continue
+		}
+		if offset < 2 || offset+count > len(r.used) {
+			// Points outside of the file, treat as missing
+			r.locations[i] = 0
+			continue
+		}
+		for s := offset; s < offset+count; s++ {
+			r.used[s] = true
+		}
+	}
+	return nil
+}
+
+func index(x, z int) int {
+	return (x & (regionSize - 1)) | (z&(regionSize-1))*regionSize
+}
+
+// HasChunk returns whether the chunk has been saved to the region.
+// The position is in chunks and may be either absolute or relative
+// to the region.
+func (r *Region) HasChunk(x, z int) bool {
+	return r.locations[index(x, z)] != 0
+}
+
+// ReadChunk reads the chunk's root tag from the region. The position
+// is in chunks and may be either absolute or relative to the region.
+func (r *Region) ReadChunk(x, z int) (*nbt.Compound, error) {
+	loc := r.locations[index(x, z)]
+	if loc == 0 {
+		return nil, ErrNoChunk
+	}
+	sr := io.NewSectionReader(r.f, int64(loc>>8)*sectorSize, int64(loc&0xFF)*sectorSize)
+	var length int32
+	if err := binary.Read(sr, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+	if length < 1 || int64(length) > sr.Size()-4 {
+		return nil, fmt.Errorf("invalid chunk length %d", length)
+	}
+	var compression [1]byte
+	if _, err := io.ReadFull(sr, compression[:]); err != nil {
+		return nil, err
+	}
+	data := io.LimitReader(sr, int64(length-1))
+	var cr io.Reader
+	switch compression[0] {
+	case compressionGzip:
+		gr, err := gzip.NewReader(data)
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close()
+		cr = gr
+	case compressionZlib:
+		zr, err := zlib.NewReader(data)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		cr = zr
+	default:
+		return nil, fmt.Errorf("unknown compression type %d", compression[0])
+	}
+	br := bufio.NewReader(cr)
+	id, err := br.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if nbt.TypeID(id) != nbt.TagCompound {
+		return nil, nbt.ErrInvalidCompound
+	}
+	c := nbt.NewCompound()
+	if err := c.Deserialize(br); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// WriteChunk saves the chunk's root tag to the region replacing any
+// existing copy. The position is in chunks and may be either absolute
+// or relative to the region.
+func (r *Region) WriteChunk(x, z int, c *nbt.Compound) error {
+	var buf bytes.Buffer
+	// Length is filled in once known
+	buf.Write([]byte{0, 0, 0, 0, compressionZlib})
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write([]byte{byte(nbt.TagCompound)}); err != nil {
+		return err
+	}
+	if err := c.Serialize(zw); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	binary.BigEndian.PutUint32(data, uint32(len(data)-4))
+	count := (len(data) + sectorSize - 1) / sectorSize
+	if count > 0xFF {
+		return ErrChunkTooLarge
+	}
+	// Pad to a whole number of sectors
+	data = append(data, make([]byte, count*sectorSize-len(data))...)
+
+	idx := index(x, z)
+	// Free the old copy so that its space can be reused
+	if loc := r.locations[idx]; loc != 0 {
+		for s := int(loc >> 8); s < int(loc>>8+loc&0xFF); s++ {
+			r.used[s] = false
+		}
+	}
+	offset := r.allocate(count)
+	if _, err := r.f.WriteAt(data, int64(offset)*sectorSize); err != nil {
+		return err
+	}
+
+	r.locations[idx] = uint32(offset)<<8 | uint32(count)
+	r.timestamps[idx] = uint32(time.Now().Unix())
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], r.locations[idx])
+	if _, err := r.f.WriteAt(header[:], int64(idx)*4); err != nil {
+		return err
+	}
+	binary.BigEndian.PutUint32(header[:], r.timestamps[idx])
+	_, err := r.f.WriteAt(header[:], sectorSize+int64(idx)*4)
+	return err
+}
+
+// allocate finds (or creates) a run of free sectors of the requested
+// length and marks them as used.
+func (r *Region) allocate(count int) int {
+	run := 0
+	for i, used := range r.used {
+		if used {
+			run = 0
+			continue
+		}
+		run++
+		if run == count {
+			start := i - count + 1
+			for s := start; s <= i; s++ {
+				r.used[s] = true
+			}
+			return start
+		}
+	}
+	// Extend the file, reusing any free sectors at the end
+	start := len(r.used) - run
+	for i := 0; i < count-run; i++ {
+		r.used = append(r.used, false)
+	}
+	for s := start; s < start+count; s++ {
+		r.used[s] = true
+	}
+	return start
+}
+
+// Close closes the region file.
+func (r *Region) Close() error {
+	return r.f.Close()
+}
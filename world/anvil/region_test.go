@@ -0,0 +1,91 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anvil
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/thinkofdeath/steven/encoding/nbt"
+)
+
+func TestRegion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "anvil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, RegionFile(RegionPosition(-1, 40)))
+	if filepath.Base(path) != "r.-1.1.mca" {
+		t.Errorf("wrong region file %s", filepath.Base(path))
+	}
+
+	r, err := OpenRegion(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	small := nbt.NewCompound()
+	small.Items["xPos"] = int32(-1)
+	small.Items["Blocks"] = []byte{1, 2, 3}
+	if err := r.WriteChunk(-1, 40, small); err != nil {
+		t.Fatal(err)
+	}
+	// Random data doesn't compress so this needs multiple sectors
+	// and has to be moved
+	large := nbt.NewCompound()
+	noise := make([]byte, sectorSize*3)
+	rand.Read(noise)
+	large.Items["Blocks"] = noise
+	if err := r.WriteChunk(0, 0, small); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.WriteChunk(-1, 40, large); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err = OpenRegion(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if r.HasChunk(1, 1) {
+		t.Error("unexpected chunk 1,1")
+	}
+	if _, err := r.ReadChunk(1, 1); err != ErrNoChunk {
+		t.Errorf("expected ErrNoChunk, got %v", err)
+	}
+	for _, c := range []struct {
+		x, z int
+		tag  *nbt.Compound
+	}{
+		{-1, 40, large},
+		{0, 0, small},
+	} {
+		tag, err := r.ReadChunk(c.x, c.z)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(tag.Items, c.tag.Items) {
+			t.Errorf("chunk %d,%d doesn't match what was written", c.x, c.z)
+		}
+	}
+}
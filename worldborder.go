@@ -0,0 +1,288 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steven
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/thinkofdeath/steven/protocol"
+	"github.com/thinkofdeath/steven/render"
+	"github.com/thinkofdeath/steven/type/direction"
+	"github.com/thinkofdeath/steven/ui"
+	"github.com/thinkofdeath/steven/ui/scene"
+)
+
+const (
+	defaultBorderSize = 60000000
+	// Distance from the border at which the wall starts to
+	// be drawn
+	borderViewDistance = 64.0
+	// Number of 2x2 tiles along each side of a wall segment
+	borderTiles = 17
+	// Size of a wall segment in blocks
+	borderSegment = borderTiles * 2
+	// Blocks the texture scrolls each tick
+	borderScrollSpeed = 0.05
+)
+
+// worldBorder tracks the border that limits how far the player can
+// move. The size is the length of each side of the square border and
+// may be moving between two sizes over time.
+type worldBorder struct {
+	x, z float64
+
+	oldSize, newSize float64
+	// Time remaining (and total) for the size change in
+	// milliseconds
+	lerpRemaining, lerpTime float64
+
+	portalBoundary int
+	// Seconds before the border reaches the player to start
+	// warning them
+	warningTime int
+	// Distance to the border to start warning the player
+	warningBlocks int
+
+	scroll   float64
+	model    *render.StaticModel
+	scene    *scene.Type
+	vignette *ui.Image
+}
+
+func (w *worldBorder) init() {
+	w.oldSize, w.newSize = defaultBorderSize, defaultBorderSize
+	w.portalBoundary = 29999984
+	w.warningTime = 15
+	w.warningBlocks = 5
+
+	var verts []*render.StaticVertex
+	tex := render.GetTexture("misc/forcefield")
+	for tx := 0; tx < borderTiles; tx++ {
+		for ty := 0; ty < borderTiles; ty++ {
+			// Both sides of the wall are visible
+			for _, dir := range []direction.Type{direction.North, direction.South} {
+				for _, v := range faceVertices[dir].verts {
+					verts = append(verts, &render.StaticVertex{
+						X:        float32(tx*2) + float32(v.X)*2,
+						Y:        float32(ty*2) + float32(v.Y)*2,
+						Texture:  tex,
+						TextureX: float64(v.TOffsetX),
+						TextureY: float64(v.TOffsetY),
+						R:        255,
+						G:        255,
+						B:        255,
+						A:        255,
+					})
+				}
+			}
+		}
+	}
+	// One part per a side of the border
+	w.model = render.NewStaticModel([][]*render.StaticVertex{
+		verts, verts, verts, verts,
+	})
+
+	w.scene = scene.New(true)
+	w.vignette = ui.NewImage(render.GetTexture("misc/vignette"), 0, 0, 854, 480, 0, 0, 1, 1, 255, 0, 0)
+	w.vignette.SetA(0)
+	w.scene.AddDrawable(w.vignette.Attach(ui.Top, ui.Left))
+}
+
+func (w *worldBorder) free() {
+	w.model.Free()
+	w.scene.Hide()
+}
+
+// update handles a WorldBorder packet.
+func (w *worldBorder) update(p *protocol.WorldBorder) {
+	switch p.Action {
+	case 0: // Set size
+		w.oldSize, w.newSize = p.NewRadius, p.NewRadius
+		w.lerpRemaining, w.lerpTime = 0, 0
+	case 1: // Lerp size
+		w.lerp(p.OldRadius, p.NewRadius, int64(p.Speed))
+	case 2: // Set center
+		w.x, w.z = p.X, p.Z
+	case 3: // Initialize
+		w.x, w.z = p.X, p.Z
+		w.lerp(p.OldRadius, p.NewRadius, int64(p.Speed))
+		w.portalBoundary = int(p.PortalBoundary)
+		w.warningTime = int(p.WarningTime)
+		w.warningBlocks = int(p.WarningBlocks)
+	case 4: // Set warning time
+		w.warningTime = int(p.WarningTime)
+	case 5: // Set warning blocks
+		w.warningBlocks = int(p.WarningBlocks)
+	}
+}
+
+func (w *worldBorder) lerp(from, to float64, ms int64) {
+	w.oldSize, w.newSize = from, to
+	if ms <= 0 {
+		w.oldSize = to
+		ms = 0
+	}
+	w.lerpRemaining, w.lerpTime = float64(ms), float64(ms)
+}
+
+// size returns the current size of the border.
+func (w *worldBorder) size() float64 {
+	if w.lerpRemaining <= 0 {
+		return w.newSize
+	}
+	return w.newSize + (w.oldSize-w.newSize)*(w.lerpRemaining/w.lerpTime)
+}
+
+// bounds returns the current edges of the border.
+func (w *worldBorder) bounds() (minX, minZ, maxX, maxZ float64) {
+	half := w.size() / 2
+	return w.x - half, w.z - half, w.x + half, w.z + half
+}
+
+// distance returns the distance from the point to the nearest side
+// of the border. This is negative if the point is outside.
+func (w *worldBorder) distance(x, z float64) float64 {
+	minX, minZ, maxX, maxZ := w.bounds()
+	return math.Min(math.Min(x-minX, maxX-x), math.Min(z-minZ, maxZ-z))
+}
+
+// collisionBoxes returns boxes along the outside of each side of
+// the border for the player at the position to collide with. Players
+// outside of the border (e.g. after it shrinks) are free to move so
+// that they can return.
+func (w *worldBorder) collisionBoxes(x, z float64) []physicsBox {
+	if w.distance(x, z) < 0 {
+		return nil
+	}
+	const (
+		thickness = 1.0
+		height    = 1 << 16
+	)
+	minX, minZ, maxX, maxZ := w.bounds()
+	return []physicsBox{
+		{minX - thickness, -height, minZ, minX, height, maxZ},
+		{maxX, -height, minZ, maxX + thickness, height, maxZ},
+		{minX, -height, minZ - thickness, maxX, height, minZ},
+		{minX, -height, maxZ, maxX, height, maxZ + thickness},
+	}
+}
+
+// tick advances the size change by delta (in 60ths of a second) and
+// updates the wall and warning around the player.
+func (w *worldBorder) tick(delta float64) {
+	if w.lerpRemaining > 0 {
+		w.lerpRemaining -= delta * (1000.0 / 60.0)
+		if w.lerpRemaining <= 0 {
+			w.lerpRemaining = 0
+			w.oldSize = w.newSize
+		}
+	}
+	w.scroll = math.Mod(w.scroll+(delta/3)*borderScrollSpeed, 2)
+
+	x, y, z := Client.X, Client.Y, Client.Z
+	w.updateWarning(x, z)
+
+	minX, minZ, maxX, maxZ := w.bounds()
+	var r, g, b byte = 32, 160, 255
+	switch {
+	case w.lerpRemaining > 0 && w.newSize < w.oldSize:
+		r, g, b = 255, 48, 48
+	case w.lerpRemaining > 0 && w.newSize > w.oldSize:
+		r, g, b = 64, 255, 128
+	}
+
+	// Tiles are aligned to the world so that the texture doesn't
+	// move with the player, apart from the scrolling
+	baseY := math.Floor((y-borderSegment/2)/2)*2 - w.scroll
+	walls := [4]struct {
+		dist, along, min, max float64
+		fixed                 float64
+		rotate                bool
+	}{
+		{z - minZ, x, minX, maxX, minZ, false},
+		{maxZ - z, x, minX, maxX, maxZ, false},
+		{x - minX, z, minZ, maxZ, minX, true},
+		{maxX - x, z, minZ, maxZ, maxX, true},
+	}
+	for i, wall := range walls {
+		if wall.dist > borderViewDistance || wall.dist < -borderViewDistance {
+			// Collapse the part so nothing is drawn
+			w.model.Matrix[i] = mgl32.Scale3D(0, 0, 0)
+			continue
+		}
+		start := math.Floor((wall.along-borderSegment/2)/2) * 2
+		scale := 1.0
+		if length := wall.max - wall.min; length < borderSegment {
+			start, scale = wall.min, length/borderSegment
+		} else if start < wall.min {
+			start = wall.min
+		} else if start+borderSegment > wall.max {
+			start = wall.max - borderSegment
+		}
+		var mat mgl32.Mat4
+		if wall.rotate {
+			mat = mgl32.Translate3D(float32(wall.fixed), -float32(baseY), float32(start)).
+				Mul4(mgl32.Rotate3DY(-math.Pi / 2).Mat4())
+		} else {
+			mat = mgl32.Translate3D(float32(start), -float32(baseY), float32(wall.fixed))
+		}
+		w.model.Matrix[i] = mat.Mul4(mgl32.Scale3D(float32(scale), 1, 1))
+
+		alpha := 1 - math.Abs(wall.dist)/borderViewDistance
+		w.model.Colors[i] = [4]float32{
+			float32(r) / 255, float32(g) / 255, float32(b) / 255,
+			float32(alpha * alpha),
+		}
+
+		// Posts at the corners of the border
+		const post = 1.0 / 16.0
+		for _, c := range [2]float64{wall.min, wall.max} {
+			px, pz := c, wall.fixed
+			if wall.rotate {
+				px, pz = wall.fixed, c
+			}
+			render.DrawBox(
+				px-post, baseY, pz-post,
+				px+post, baseY+borderSegment, pz+post,
+				r, g, b, byte(alpha*255),
+			)
+		}
+	}
+}
+
+// updateWarning tints the edges of the screen when the player is
+// close to the border or it will reach them soon.
+func (w *worldBorder) updateWarning(x, z float64) {
+	dist := w.distance(x, z)
+	warn := float64(w.warningBlocks)
+	if w.lerpRemaining > 0 {
+		// The distance the border will move within the
+		// warning time
+		speed := math.Abs(w.newSize-w.oldSize) / w.lerpTime
+		moving := math.Min(speed*float64(w.warningTime)*1000, math.Abs(w.newSize-w.size()))
+		warn = math.Max(warn, moving)
+	}
+	amount := 0.0
+	if dist < warn {
+		amount = 1 - dist/warn
+	}
+	w.vignette.SetA(int(math.Min(amount, 1) * 200))
+	if !render.Headless {
+		width, height := window.GetFramebufferSize()
+		w.vignette.SetWidth(float64(width) / ui.Scale)
+		w.vignette.SetHeight(float64(height) / ui.Scale)
+	}
+}
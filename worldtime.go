@@ -0,0 +1,156 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steven
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/thinkofdeath/steven/protocol"
+	"github.com/thinkofdeath/steven/render"
+	"github.com/thinkofdeath/steven/world/biome"
+)
+
+const (
+	ticksPerDay = 24000
+	// Noon, used until the server sends the time
+	defaultTimeOfDay = 6000
+)
+
+var (
+	// Sky colors at full brightness, the overworld's depends on
+	// the biome (see biomeSkyColor)
+	netherSkyColor = mgl32.Vec3{51.0 / 255.0, 8.0 / 255.0, 8.0 / 255.0}
+	endSkyColor    = mgl32.Vec3{10.0 / 255.0, 8.0 / 255.0, 15.0 / 255.0}
+)
+
+// worldTime tracks the age of the world and time of day. Both are
+// in ticks and advanced locally between updates from the server.
+type worldTime struct {
+	age       float64
+	timeOfDay float64
+	// Whether the server has disabled the day/night cycle
+	stopped bool
+}
+
+func (w *worldTime) init() {
+	w.timeOfDay = defaultTimeOfDay
+	render.SkyBrightness = 1.0
+	render.SkyColor = biomeSkyColor(biome.Plains)
+}
+
+// update syncs the time with the server's.
+func (w *worldTime) update(p *protocol.TimeUpdate) {
+	w.age = float64(p.WorldAge)
+	// A negative time means the time of day doesn't advance
+	w.stopped = p.TimeOfDay < 0
+	t := p.TimeOfDay
+	if w.stopped {
+		t = -t
+	}
+	w.timeOfDay = float64(t % ticksPerDay)
+}
+
+// tick advances the time by delta (in 60ths of a second) and updates
+// the sky for the new time.
+func (w *worldTime) tick(delta float64) {
+	ticks := delta / 3
+	w.age += ticks
+	if !w.stopped {
+		w.timeOfDay = math.Mod(w.timeOfDay+ticks, ticksPerDay)
+	}
+
+	switch Client.Dimension {
+	case -1: // Nether
+		render.SkyBrightness = 1.0
+		render.SkyColor = netherSkyColor
+	case 1: // End
+		render.SkyBrightness = 1.0
+		render.SkyColor = endSkyColor
+	default:
+		brightness := w.brightness()
+		render.SkyBrightness = float32(brightness*0.8 + 0.2)
+		sky := biomeSkyColor(cameraBiome())
+		render.SkyColor = mgl32.Vec3{
+			sky.X() * float32(brightness*0.94+0.06),
+			sky.Y() * float32(brightness*0.94+0.06),
+			sky.Z() * float32(brightness*0.91+0.09),
+		}
+	}
+}
+
+// celestialAngle returns the position of the sun in the sky, 0 is
+// noon and 0.5 is midnight. The sun moves faster around sunrise
+// and sunset like it does in vanilla.
+func (w *worldTime) celestialAngle() float64 {
+	a := w.timeOfDay/ticksPerDay - 0.25
+	if a < 0 {
+		a++
+	}
+	eased := 1 - (math.Cos(a*math.Pi)+1)/2
+	return a + (eased-a)/3
+}
+
+// brightness returns how lit the world is by the sun, 1 during the
+// day and 0 at night.
+func (w *worldTime) brightness() float64 {
+	b := math.Cos(w.celestialAngle()*math.Pi*2)*2 + 0.5
+	return math.Max(0, math.Min(1, b))
+}
+
+// cameraBiome returns the biome the camera is in, plains if its
+// chunk isn't loaded.
+func cameraBiome() *biome.Type {
+	x, z := int(math.Floor(render.Camera.X)), int(math.Floor(render.Camera.Z))
+	c := chunkMap[chunkPosition{x >> 4, z >> 4}]
+	if c == nil {
+		return biome.Plains
+	}
+	return c.biome(x&0xF, z&0xF)
+}
+
+// biomeSkyColor returns the color of the sky in the biome at full
+// brightness. Like vanilla the sky gets bluer the colder the biome
+// is.
+func biomeSkyColor(b *biome.Type) mgl32.Vec3 {
+	t := b.Temperature / 3
+	return hsvColor(0.62222224-t*0.05, 0.5+t*0.1, 1.0)
+}
+
+// hsvColor converts the hue, saturation and value (all 0-1) into
+// an rgb color.
+func hsvColor(h, s, v float64) mgl32.Vec3 {
+	h = (h - math.Floor(h)) * 6
+	f := h - math.Floor(h)
+	p := v * (1 - s)
+	q := v * (1 - s*f)
+	t := v * (1 - s*(1-f))
+	var r, g, b float64
+	switch int(h) {
+	case 0:
+		r, g, b = v, t, p
+	case 1:
+		r, g, b = q, v, p
+	case 2:
+		r, g, b = p, v, t
+	case 3:
+		r, g, b = p, q, v
+	case 4:
+		r, g, b = t, p, v
+	default:
+		r, g, b = v, p, q
+	}
+	return mgl32.Vec3{float32(r), float32(g), float32(b)}
+}
@@ -0,0 +1,41 @@
+// Copyright 2015 Matthew Collins
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steven
+
+import (
+	"testing"
+
+	"github.com/thinkofdeath/steven/world/biome"
+)
+
+func TestBiomeSkyColor(t *testing.T) {
+	// Colors from the vanilla client
+	tests := []struct {
+		name    string
+		biome   *biome.Type
+		r, g, b byte
+	}{
+		{"plains", biome.Plains, 0x78, 0xA7, 0xFF},
+		{"ice plains", biome.IcePlains, 0x7F, 0xA1, 0xFF},
+	}
+	for _, test := range tests {
+		c := biomeSkyColor(test.biome)
+		r, g, b := byte(c.X()*255), byte(c.Y()*255), byte(c.Z()*255)
+		if r != test.r || g != test.g || b != test.b {
+			t.Errorf("%s: got %02X%02X%02X, wanted %02X%02X%02X",
+				test.name, r, g, b, test.r, test.g, test.b)
+		}
+	}
+}